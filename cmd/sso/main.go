@@ -3,11 +3,22 @@ package main
 import (
 	"AuthGRPC/internal/app"
 	"AuthGRPC/internal/config"
+	"AuthGRPC/internal/grpc/ratelimit"
+	"AuthGRPC/internal/lib/aesgcm"
+	"AuthGRPC/internal/lib/hasher"
 	"AuthGRPC/internal/lib/logger/handlers/slogpretty"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/mailer"
+	"AuthGRPC/internal/services/auth"
+	"AuthGRPC/internal/storage/memory"
+	"AuthGRPC/internal/storage/sqlite"
+	"encoding/base64"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 )
 
 const (
@@ -16,25 +27,189 @@ const (
 	envProd  = "prod"
 )
 
+const (
+	mailerFile = "file"
+	mailerSMTP = "smtp"
+)
+
+const storageMemory = "memory"
+
 func main() {
 	cfg := config.MustLoad()
 	log := setupLogger(cfg.Env)
 	log.Info("starting server",
 		slog.String("env", cfg.Env),
 		slog.Any("cfg", cfg),
-		slog.Int("port", cfg.GRPC.Port))
+		slog.Int("port", cfg.GRPC.Port),
+		slog.Int("httpPort", cfg.HTTP.Port))
 
-	application := app.New(log, cfg.GRPC.Port, cfg.StoragePath, cfg.TokenTTl)
+	passwordHasher := mustPasswordHasher(cfg.PasswordHashing)
+	application := app.New(
+		log,
+		cfg.GRPC.Port,
+		cfg.HTTP.Port,
+		cfg.HTTP.Timeout,
+		cfg.Storage.Driver,
+		cfg.StoragePath,
+		sqlite.Options{
+			JournalMode:     cfg.Storage.SQLite.JournalMode,
+			Synchronous:     cfg.Storage.SQLite.Synchronous,
+			ForeignKeys:     cfg.Storage.SQLite.ForeignKeys,
+			BusyTimeout:     cfg.Storage.SQLite.BusyTimeout,
+			MaxOpenConns:    cfg.Storage.SQLite.MaxOpenConns,
+			MaxIdleConns:    cfg.Storage.SQLite.MaxIdleConns,
+			ConnMaxLifetime: cfg.Storage.SQLite.ConnMaxLifetime,
+			ConnMaxIdleTime: cfg.Storage.SQLite.ConnMaxIdleTime,
+		},
+		memoryOptions(cfg.Storage, passwordHasher),
+		cfg.TokenTTl,
+		cfg.RefreshTokenTTL,
+		cfg.CleanupInterval,
+		keyRotationInterval(cfg.KeyRotation),
+		passwordHasher,
+		auth.LockoutPolicy{
+			MaxAttempts:   cfg.Lockout.MaxAttempts,
+			IPMaxAttempts: cfg.Lockout.IPMaxAttempts,
+			Window:        cfg.Lockout.Window,
+			BaseDuration:  cfg.Lockout.BaseDuration,
+			MaxDuration:   cfg.Lockout.MaxDuration,
+		},
+		rateLimitConfig(cfg.RateLimit),
+		mustMailer(cfg.Mailer),
+		auth.EmailVerification{
+			TokenTTL: cfg.EmailVerification.TokenTTL,
+			URL:      cfg.EmailVerification.URL,
+		},
+		auth.PasswordReset{
+			TokenTTL: cfg.PasswordReset.TokenTTL,
+			URL:      cfg.PasswordReset.URL,
+		},
+		auth.PasswordPolicy{
+			MinLength:     cfg.PasswordPolicy.MinLength,
+			MaxLength:     cfg.PasswordPolicy.MaxLength,
+			RequireUpper:  cfg.PasswordPolicy.RequireUpper,
+			RequireLower:  cfg.PasswordPolicy.RequireLower,
+			RequireDigit:  cfg.PasswordPolicy.RequireDigit,
+			RequireSymbol: cfg.PasswordPolicy.RequireSymbol,
+		},
+		mustSecretCipher(cfg.MFA.EncryptionKey),
+		auth.MFAPolicy{
+			Issuer:        cfg.MFA.Issuer,
+			ChallengeTTL:  cfg.MFA.ChallengeTTL,
+			MaxAttempts:   cfg.MFA.MaxAttempts,
+			Skew:          cfg.MFA.Skew,
+			RecoveryCodes: cfg.MFA.RecoveryCodes,
+		},
+		auth.OAuthPolicy{
+			Issuer:  strings.TrimSuffix(cfg.OAuth.Issuer, "/"),
+			CodeTTL: cfg.OAuth.CodeTTL,
+		},
+	)
 	go application.GRPCSrv.MustRun()
+	go application.HTTPSrv.MustRun()
+	go application.Cleanup.Run()
 
 	//Graceful shutdown
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	signl := <-stop
 	application.GRPCSrv.Stop()
+	application.HTTPSrv.Stop()
+	application.Cleanup.Stop()
+	if err := application.Storage.Close(); err != nil {
+		log.Error("failed to close storage", sl.Err(err))
+	}
 	log.Info("shutting down...", slog.String("Signal", signl.String()))
 }
 
+func keyRotationInterval(cfg config.KeyRotation) time.Duration {
+	if !cfg.Enabled {
+		return 0
+	}
+	return cfg.Interval
+}
+
+func rateLimitConfig(cfg config.RateLimit) ratelimit.Config {
+	limit := func(l config.Limit) ratelimit.Limit {
+		if l.Key == "" {
+			l.Key = ratelimit.KeyIP
+		}
+		return ratelimit.Limit{RPS: l.RPS, Burst: l.Burst, Key: l.Key}
+	}
+	methods := make(map[string]ratelimit.Limit, len(cfg.Methods))
+	for method, l := range cfg.Methods {
+		methods[method] = limit(l)
+	}
+	return ratelimit.Config{
+		Enabled: cfg.Enabled,
+		Default: limit(cfg.Default),
+		Methods: methods,
+	}
+}
+
+func mustMailer(cfg config.Mailer) auth.Mailer {
+	switch cfg.Driver {
+	case mailerFile:
+		if cfg.FilePath == "" {
+			return mailer.NewWriter(cfg.From, os.Stdout)
+		}
+		f, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			panic(err)
+		}
+		return mailer.NewWriter(cfg.From, f)
+	case mailerSMTP:
+		return mailer.NewSMTP(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.From, cfg.SMTP.Timeout)
+	}
+	panic("unknown mailer driver: " + cfg.Driver)
+}
+
+func mustSecretCipher(encodedKey string) *aesgcm.Cipher {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		panic("mfa encryption key is not valid base64: " + err.Error())
+	}
+	c, err := aesgcm.New(key)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// memoryOptions seeds the memory storage. The admin password is only hashed when
+// the memory storage is used.
+func memoryOptions(cfg config.Storage, passwordHasher *hasher.Hasher) memory.Options {
+	if cfg.Driver != storageMemory || cfg.Memory.AdminEmail == "" {
+		return memory.Options{AppSecret: cfg.Memory.AppSecret}
+	}
+	if cfg.Memory.AdminPassword == "" {
+		panic("storage.memory.admin_password is required with admin_email")
+	}
+	passHash, err := passwordHasher.Hash(cfg.Memory.AdminPassword)
+	if err != nil {
+		panic(err)
+	}
+	return memory.Options{
+		AppSecret:     cfg.Memory.AppSecret,
+		AdminEmail:    cfg.Memory.AdminEmail,
+		AdminPassHash: passHash,
+	}
+}
+
+func mustPasswordHasher(cfg config.PasswordHashing) *hasher.Hasher {
+	h, err := hasher.New(cfg.Algorithm, cfg.BcryptCost, hasher.Argon2Params{
+		Memory:  cfg.Argon2.MemoryKiB,
+		Time:    cfg.Argon2.Time,
+		Threads: cfg.Argon2.Threads,
+		KeyLen:  cfg.Argon2.KeyLen,
+		SaltLen: cfg.Argon2.SaltLen,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
 func setupLogger(env string) *slog.Logger {
 	var log *slog.Logger
 	switch env {
@@ -1,25 +1,103 @@
 package app
 
 import (
+	cleanupapp "AuthGRPC/internal/app/cleanup"
 	grpcapp "AuthGRPC/internal/app/grpc"
+	httpapp "AuthGRPC/internal/app/http"
+	"AuthGRPC/internal/grpc/ratelimit"
+	"AuthGRPC/internal/lib/hasher"
+	"AuthGRPC/internal/lib/policy"
 	"AuthGRPC/internal/services/auth"
+	"AuthGRPC/internal/storage/memory"
 	"AuthGRPC/internal/storage/sqlite"
+	"google.golang.org/grpc"
 	"log/slog"
 	"time"
 )
 
+const (
+	storageSQLite = "sqlite"
+	storageMemory = "memory"
+)
+
 type App struct {
 	GRPCSrv *grpcapp.App
+	HTTPSrv *httpapp.App
+	Cleanup *cleanupapp.App
+	Storage Storage
+}
+
+// Storage is a storage backend; it is closed once the servers have stopped.
+type Storage interface {
+	auth.Storage
+	Close() error
 }
 
-func New(log *slog.Logger, grpcPort int, storagePath string, tokenTLL time.Duration) *App {
-	storage, err := sqlite.New(storagePath)
+func New(
+	log *slog.Logger,
+	grpcPort int,
+	httpPort int,
+	httpTimeout time.Duration,
+	storageDriver string,
+	storagePath string,
+	sqliteOptions sqlite.Options,
+	memoryOptions memory.Options,
+	tokenTLL time.Duration,
+	refreshTokenTTL time.Duration,
+	cleanupInterval time.Duration,
+	keyRotationInterval time.Duration,
+	passwordHasher *hasher.Hasher,
+	lockout auth.LockoutPolicy,
+	rateLimit ratelimit.Config,
+	mailer auth.Mailer,
+	verification auth.EmailVerification,
+	passwordReset auth.PasswordReset,
+	passwordPolicy auth.PasswordPolicy,
+	secrets auth.SecretCipher,
+	mfa auth.MFAPolicy,
+	oauth auth.OAuthPolicy,
+) *App {
+	storage := mustStorage(storageDriver, storagePath, sqliteOptions, memoryOptions)
+	policyEngine, err := policy.New()
 	if err != nil {
 		panic(err)
 	}
-	authService := auth.New(log, storage, tokenTLL)
-	grpcApp := grpcapp.New(log, grpcPort, authService)
+	authService := auth.New(log, storage, passwordHasher, tokenTLL, refreshTokenTTL, keyRotationInterval, lockout, mailer, verification, passwordReset, passwordPolicy, secrets, mfa, oauth, policyEngine)
+	var interceptors []grpc.UnaryServerInterceptor
+	if rateLimit.Enabled {
+		limiter, err := ratelimit.New(log, rateLimit, authService, storage)
+		if err != nil {
+			panic(err)
+		}
+		interceptors = append(interceptors, limiter.UnaryServerInterceptor())
+	}
+	grpcApp := grpcapp.New(log, grpcPort, authService, interceptors...)
+	httpApp := httpapp.New(log, httpPort, httpTimeout, authService)
+	cleanupApp := cleanupapp.New(log, cleanupInterval, authService)
 	return &App{
 		GRPCSrv: grpcApp,
+		HTTPSrv: httpApp,
+		Cleanup: cleanupApp,
+		Storage: storage,
+	}
+}
+
+func mustStorage(driver string, path string, sqliteOptions sqlite.Options, memoryOptions memory.Options) Storage {
+	switch driver {
+	case storageSQLite:
+		if path == "" {
+			panic("storage path is empty")
+		}
+		storage, err := sqlite.New(path, sqliteOptions)
+		if err != nil {
+			panic(err)
+		}
+		return storage
+	case storageMemory:
+		if memoryOptions.AdminEmail == "" {
+			panic("memory storage needs an admin")
+		}
+		return memory.New(memoryOptions)
 	}
+	panic("unknown storage driver: " + driver)
 }
@@ -0,0 +1,64 @@
+package cleanupapp
+
+import (
+	"AuthGRPC/internal/lib/logger/sl"
+	"context"
+	"log/slog"
+	"time"
+)
+
+type Cleaner interface {
+	Cleanup(ctx context.Context) error
+}
+
+type App struct {
+	log      *slog.Logger
+	cleaner  Cleaner
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func New(log *slog.Logger, interval time.Duration, cleaner Cleaner) *App {
+	return &App{
+		log:      log,
+		cleaner:  cleaner,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Run calls the cleaner every interval until Stop is called.
+func (a *App) Run() {
+	const op = "cleanupapp.Run"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("interval", a.interval.String()))
+	log.Info("starting cleanup")
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), a.interval)
+			if err := a.cleaner.Cleanup(ctx); err != nil {
+				log.Error("cleanup failed", sl.Err(err))
+			}
+			cancel()
+		}
+	}
+}
+
+func (a *App) Stop() {
+	const op = "cleanupapp.Stop"
+
+	a.log.With(
+		slog.String("op", op)).Info("stopping cleanup")
+	close(a.stop)
+	<-a.done
+}
@@ -2,7 +2,6 @@ package grpcapp
 
 import (
 	authgrpc "AuthGRPC/internal/grpc/auth"
-	"context"
 	"fmt"
 	"google.golang.org/grpc"
 	"log/slog"
@@ -10,10 +9,9 @@ import (
 	"strconv"
 )
 
+// Auth is everything the gRPC handlers need from the auth service.
 type Auth interface {
-	Login(ctx context.Context, email string, password string, appID int32) (token string, err error)
-	Register(ctx context.Context, email string, password string) (userID int64, err error)
-	IsAdmin(ctx context.Context, userID int64) (isAdmin bool, err error)
+	authgrpc.Auth
 }
 
 func (a *App) MustRun() {
@@ -28,8 +26,8 @@ type App struct {
 	port       int
 }
 
-func New(log *slog.Logger, port int, authService Auth) *App {
-	gRPCServer := grpc.NewServer()
+func New(log *slog.Logger, port int, authService Auth, interceptors ...grpc.UnaryServerInterceptor) *App {
+	gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
 	authgrpc.Register(gRPCServer, authService)
 	return &App{
 		log:        log,
@@ -0,0 +1,90 @@
+package httpapp
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/http/jwks"
+	"AuthGRPC/internal/http/oauth"
+	"AuthGRPC/internal/http/oidc"
+	"AuthGRPC/internal/lib/jwt"
+	"AuthGRPC/internal/lib/logger/sl"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Auth interface {
+	JWKS(ctx context.Context, appID int32) (keySet []jwt.JWK, err error)
+	ValidateAuthorizationRequest(ctx context.Context, appID int32, redirectURI string) error
+	AuthorizeWithPassword(ctx context.Context, req models.AuthorizationRequest, email string, password string, clientIP string) (code string, mfaToken string, err error)
+	AuthorizeWithMFA(ctx context.Context, req models.AuthorizationRequest, mfaToken string, mfaCode string, clientIP string) (code string, err error)
+	ExchangeAuthorizationCode(ctx context.Context, code string, appID int32, redirectURI string, codeVerifier string) (tokens models.TokenPair, scope string, err error)
+	ClientCredentials(ctx context.Context, appID int32, secret string, scopes []string) (tokens models.TokenPair, granted []string, err error)
+	UserInfo(ctx context.Context, accessToken string) (user models.User, err error)
+	Issuer() string
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		panic(err)
+	}
+}
+
+type App struct {
+	log        *slog.Logger
+	httpServer *http.Server
+	port       int
+}
+
+func New(log *slog.Logger, port int, timeout time.Duration, authService Auth) *App {
+	mux := http.NewServeMux()
+	mux.Handle("GET /.well-known/jwks.json", jwks.New(log, authService))
+	authorize := oauth.NewAuthorize(log, authService)
+	mux.Handle("GET /authorize", authorize)
+	mux.Handle("POST /authorize", authorize)
+	mux.Handle("POST /token", oauth.NewToken(log, authService))
+	mux.Handle("GET /.well-known/openid-configuration", oidc.NewDiscovery(log, authService.Issuer()))
+	userInfo := oidc.NewUserInfo(log, authService)
+	mux.Handle("GET /userinfo", userInfo)
+	mux.Handle("POST /userinfo", userInfo)
+	return &App{
+		log: log,
+		httpServer: &http.Server{
+			Addr:         ":" + strconv.Itoa(port),
+			Handler:      mux,
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+		},
+		port: port,
+	}
+}
+
+func (a *App) Run() error {
+	const op = "httpapp.Run"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("port", strconv.Itoa(a.port)))
+	lis, err := net.Listen("tcp", a.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("starting HTTP server", slog.String("address", lis.Addr().String()))
+	if err := a.httpServer.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (a *App) Stop() {
+	const op = "httpapp.Stop"
+
+	a.log.With(
+		slog.String("op", op)).Info("stopping HTTP server", slog.String("port", strconv.Itoa(a.port)))
+	if err := a.httpServer.Shutdown(context.Background()); err != nil {
+		a.log.Error("failed to stop HTTP server", sl.Err(err))
+	}
+}
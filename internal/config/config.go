@@ -3,15 +3,73 @@ package config
 import (
 	"flag"
 	"github.com/ilyakaznacheev/cleanenv"
+	"log/slog"
 	"os"
 	"time"
 )
 
+const redacted = "[REDACTED]"
+
 type Config struct {
-	Env         string        `yaml:"env" env-default:"local"`
-	StoragePath string        `yaml:"storage_path" env-required:"true"`
-	TokenTTl    time.Duration `yaml:"token_ttl" env-required:"true"`
-	GRPC        GRPCConfig    `yaml:"grpc"`
+	Env               string            `yaml:"env" env-default:"local"`
+	StoragePath       string            `yaml:"storage_path"`
+	Storage           Storage           `yaml:"storage"`
+	TokenTTl          time.Duration     `yaml:"token_ttl" env-required:"true"`
+	RefreshTokenTTL   time.Duration     `yaml:"refresh_token_ttl" env-default:"720h"`
+	CleanupInterval   time.Duration     `yaml:"cleanup_interval" env-default:"10m"`
+	GRPC              GRPCConfig        `yaml:"grpc"`
+	HTTP              HTTPConfig        `yaml:"http"`
+	KeyRotation       KeyRotation       `yaml:"key_rotation"`
+	PasswordHashing   PasswordHashing   `yaml:"password_hashing"`
+	Lockout           Lockout           `yaml:"lockout"`
+	RateLimit         RateLimit         `yaml:"rate_limit"`
+	EmailVerification EmailVerification `yaml:"email_verification"`
+	PasswordReset     PasswordReset     `yaml:"password_reset"`
+	PasswordPolicy    PasswordPolicy    `yaml:"password_policy"`
+	Mailer            Mailer            `yaml:"mailer"`
+	MFA               MFA               `yaml:"mfa"`
+	OAuth             OAuth             `yaml:"oauth"`
+}
+
+// LogValue hides the secrets, so the config can be logged as a whole.
+func (c Config) LogValue() slog.Value {
+	c.Mailer.SMTP.Password = redact(c.Mailer.SMTP.Password)
+	c.MFA.EncryptionKey = redact(c.MFA.EncryptionKey)
+	c.Storage.Memory.AppSecret = redact(c.Storage.Memory.AppSecret)
+	c.Storage.Memory.AdminPassword = redact(c.Storage.Memory.AdminPassword)
+	// config has no methods, so logging it doesn't end up here again.
+	type config Config
+	return slog.AnyValue(config(c))
+}
+
+// Storage selects the storage backend: "sqlite" keeps the data in the database
+// at StoragePath, "memory" keeps it in process and loses it on restart.
+type Storage struct {
+	Driver string `yaml:"driver" env-default:"sqlite"`
+	SQLite SQLite `yaml:"sqlite"`
+	Memory Memory `yaml:"memory"`
+}
+
+// Memory seeds the memory storage, which starts out empty. AppSecret is the
+// secret of the seeded app. The admin is required, otherwise nobody could use
+// the admin API.
+type Memory struct {
+	AppSecret     string `yaml:"app_secret" env:"MEMORY_APP_SECRET"`
+	AdminEmail    string `yaml:"admin_email" env:"MEMORY_ADMIN_EMAIL"`
+	AdminPassword string `yaml:"admin_password" env:"MEMORY_ADMIN_PASSWORD"`
+}
+
+// SQLite tunes the sqlite storage. JournalMode, Synchronous and ForeignKeys are
+// the values of the pragmas of the same name.
+type SQLite struct {
+	JournalMode     string        `yaml:"journal_mode" env-default:"WAL"`
+	Synchronous     string        `yaml:"synchronous" env-default:"NORMAL"`
+	ForeignKeys     string        `yaml:"foreign_keys" env-default:"on"`
+	BusyTimeout     time.Duration `yaml:"busy_timeout" env-default:"5s"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env-default:"10"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env-default:"10"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env-default:"1h"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env-default:"10m"`
 }
 
 type GRPCConfig struct {
@@ -19,6 +77,103 @@ type GRPCConfig struct {
 	Timeout time.Duration `yaml:"timeout"`
 }
 
+type HTTPConfig struct {
+	Port    int           `yaml:"port" env-default:"8080"`
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+type KeyRotation struct {
+	Enabled  bool          `yaml:"enabled" env-default:"true"`
+	Interval time.Duration `yaml:"interval" env-default:"720h"`
+}
+
+type PasswordHashing struct {
+	Algorithm  string `yaml:"algorithm" env-default:"argon2id"`
+	BcryptCost int    `yaml:"bcrypt_cost" env-default:"12"`
+	Argon2     Argon2 `yaml:"argon2"`
+}
+
+type Argon2 struct {
+	MemoryKiB uint32 `yaml:"memory_kib" env-default:"19456"`
+	Time      uint32 `yaml:"time" env-default:"2"`
+	Threads   uint8  `yaml:"threads" env-default:"1"`
+	KeyLen    uint32 `yaml:"key_len" env-default:"32"`
+	SaltLen   uint32 `yaml:"salt_len" env-default:"16"`
+}
+
+type Lockout struct {
+	MaxAttempts   int           `yaml:"max_attempts" env-default:"5"`
+	IPMaxAttempts int           `yaml:"ip_max_attempts" env-default:"50"`
+	Window        time.Duration `yaml:"window" env-default:"15m"`
+	BaseDuration  time.Duration `yaml:"base_duration" env-default:"1m"`
+	MaxDuration   time.Duration `yaml:"max_duration" env-default:"1h"`
+}
+
+type RateLimit struct {
+	Enabled bool             `yaml:"enabled" env-default:"false"`
+	Default Limit            `yaml:"default"`
+	Methods map[string]Limit `yaml:"methods"`
+}
+
+// Limit is a token bucket; Key is one of ip, app_id or subject.
+type Limit struct {
+	RPS   float64 `yaml:"rps" env-default:"10"`
+	Burst int     `yaml:"burst" env-default:"20"`
+	Key   string  `yaml:"key" env-default:"ip"`
+}
+
+type EmailVerification struct {
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"24h"`
+	URL      string        `yaml:"url"`
+}
+
+type PasswordReset struct {
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"1h"`
+	URL      string        `yaml:"url"`
+}
+
+type PasswordPolicy struct {
+	MinLength     int  `yaml:"min_length" env-default:"8"`
+	MaxLength     int  `yaml:"max_length" env-default:"128"`
+	RequireUpper  bool `yaml:"require_upper" env-default:"false"`
+	RequireLower  bool `yaml:"require_lower" env-default:"false"`
+	RequireDigit  bool `yaml:"require_digit" env-default:"false"`
+	RequireSymbol bool `yaml:"require_symbol" env-default:"false"`
+}
+
+// Mailer selects how emails are delivered: "file" writes them to FilePath
+// (stdout when empty), "smtp" sends them through the SMTP relay.
+type Mailer struct {
+	Driver   string `yaml:"driver" env-default:"file"`
+	From     string `yaml:"from" env-default:"no-reply@localhost"`
+	FilePath string `yaml:"file_path"`
+	SMTP     SMTP   `yaml:"smtp"`
+}
+
+type SMTP struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port" env-default:"587"`
+	Username string        `yaml:"username"`
+	Password string        `yaml:"password" env:"SMTP_PASSWORD"`
+	Timeout  time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+type MFA struct {
+	Issuer string `yaml:"issuer" env-default:"GoSSO"`
+	// EncryptionKey is the base64 encoded AES key TOTP secrets are encrypted with.
+	EncryptionKey string        `yaml:"encryption_key" env:"MFA_ENCRYPTION_KEY" env-required:"true"`
+	ChallengeTTL  time.Duration `yaml:"challenge_ttl" env-default:"5m"`
+	MaxAttempts   int           `yaml:"max_attempts" env-default:"5"`
+	Skew          int           `yaml:"skew" env-default:"1"`
+	RecoveryCodes int           `yaml:"recovery_codes" env-default:"10"`
+}
+
+type OAuth struct {
+	// Issuer is the public base URL of the HTTP server, without a trailing slash.
+	Issuer  string        `yaml:"issuer" env-default:"http://localhost:8080"`
+	CodeTTL time.Duration `yaml:"code_ttl" env-default:"1m"`
+}
+
 func MustLoad() *Config {
 	path := fetchConfigPath()
 	if path == "" {
@@ -38,6 +193,13 @@ func MustLoadByPath(path string) *Config {
 	return &cfg
 }
 
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
 func fetchConfigPath() string {
 	var res string
 
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+func TestConfig_LogValue(t *testing.T) {
+	cfg := &Config{
+		Env:     "prod",
+		Storage: Storage{Memory: Memory{AppSecret: "app-secret", AdminPassword: "admin-password"}},
+		Mailer:  Mailer{SMTP: SMTP{Host: "smtp.example.com", Password: "smtp-password"}},
+		MFA:     MFA{EncryptionKey: "mfa-encryption-key"},
+	}
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("starting", slog.Any("cfg", cfg))
+
+	assert.NotContains(t, buf.String(), "smtp-password")
+	assert.NotContains(t, buf.String(), "mfa-encryption-key")
+	assert.NotContains(t, buf.String(), "app-secret")
+	assert.NotContains(t, buf.String(), "admin-password")
+	assert.Contains(t, buf.String(), redacted)
+	assert.Contains(t, buf.String(), "smtp.example.com")
+	assert.Equal(t, "smtp-password", cfg.Mailer.SMTP.Password, "the config itself is left alone")
+}
@@ -1,7 +1,22 @@
 package models
 
 type App struct {
-	ID     int
-	Name   string
-	Secret string
+	ID         int
+	Name       string
+	Secret     string
+	SigningAlg string
+	// RequireVerifiedEmail refuses logins of users who haven't verified their email.
+	RequireVerifiedEmail bool
+	// Restricted apps only let in users granted access and the app's admins.
+	Restricted bool
+	// ClientScopes are the scopes the app may request for its own client credentials tokens.
+	ClientScopes []string
+	// RedirectURIs are saved with the app, but only the OAuth flow needs them,
+	// so App and Apps of the storage leave them empty.
+	RedirectURIs []string
+	// OrgID is the org whose users may log into the app.
+	OrgID int64
+	// SecretSigning is set for HS256 apps that predate managed signing keys. They
+	// sign tokens with their secret until their first key rotation.
+	SecretSigning bool
 }
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+const (
+	AuditRecoveryCodeUsed         = "mfa.recovery_code_used"
+	AuditRecoveryCodesRegenerated = "mfa.recovery_codes_regenerated"
+	AuditMFADisabled              = "mfa.disabled"
+	AuditAppCreated               = "app.created"
+	AuditAppUpdated               = "app.updated"
+	AuditAppSecretRotated         = "app.secret_rotated"
+	AuditSigningKeyRotated        = "app.signing_key_rotated"
+	AuditAppDeleted               = "app.deleted"
+	AuditUserAdminGranted         = "user.admin_granted"
+	AuditUserAdminRevoked         = "user.admin_revoked"
+	AuditUserDisabled             = "user.disabled"
+	AuditUserEnabled              = "user.enabled"
+	AuditUserDeleted              = "user.deleted"
+	AuditRoleCreated              = "role.created"
+	AuditRoleUpdated              = "role.updated"
+	AuditRoleDeleted              = "role.deleted"
+	AuditRoleAssigned             = "role.assigned"
+	AuditRoleUnassigned           = "role.unassigned"
+	AuditPolicyUpdated            = "policy.updated"
+	AuditOrgCreated               = "org.created"
+	AuditAppAdminGranted          = "app.admin_granted"
+	AuditAppAdminRevoked          = "app.admin_revoked"
+	AuditAppAccessGranted         = "app.access_granted"
+	AuditAppAccessRevoked         = "app.access_revoked"
+)
+
+// AuditEvent records a security relevant action of a user. For admin actions
+// UserID is the admin.
+type AuditEvent struct {
+	ID        int64
+	UserID    int64
+	Event     string
+	IP        string
+	Detail    string
+	CreatedAt time.Time
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+const (
+	// EmailTokenVerifyEmail confirms the user owns the email address.
+	EmailTokenVerifyEmail = "verify_email"
+	// EmailTokenResetPassword lets the user set a new password.
+	EmailTokenResetPassword = "reset_password"
+)
+
+// EmailToken is a single-use token sent to a user by email. Only its hash is stored.
+type EmailToken struct {
+	TokenHash string
+	UserID    int64
+	Purpose   string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	// KeyStatusActive keys sign new tokens and verify existing ones.
+	KeyStatusActive = "active"
+	// KeyStatusRetiring keys only verify tokens issued before a rotation, until RetireAt.
+	KeyStatusRetiring = "retiring"
+	// KeyStatusRetired keys are kept for bookkeeping and are not used anymore.
+	KeyStatusRetired = "retired"
+)
+
+type SigningKey struct {
+	ID    string
+	AppID int
+	Alg   string
+	// Key holds the shared secret for HS256 and a PKCS #8 DER private key otherwise.
+	Key []byte
+	// Sealed is set while Key is encrypted for storage.
+	Sealed    bool
+	Status    string
+	CreatedAt time.Time
+	RetireAt  time.Time
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type LoginAttempts struct {
+	Key         string
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// MFA is the TOTP enrollment of a user. Secret is encrypted; the enrollment
+// only counts once it was confirmed with a valid code.
+type MFA struct {
+	UserID  int64
+	Secret  []byte
+	Enabled bool
+	// LastUsedStep is the time step of the last accepted code, so a code can't be replayed.
+	LastUsedStep int64
+}
+
+// MFAChallenge is issued by Login to users with MFA and exchanged for tokens by VerifyMFA.
+type MFAChallenge struct {
+	TokenHash string
+	UserID    int64
+	AppID     int
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+// RecoveryCode can be used once in place of a TOTP code. Only its hash is stored.
+type RecoveryCode struct {
+	ID       int64
+	UserID   int64
+	CodeHash []byte
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// AuthorizationCode is issued by the OAuth authorize endpoint and exchanged once
+// for tokens. CodeChallenge is the PKCE S256 challenge; only the code's hash is stored.
+type AuthorizationCode struct {
+	CodeHash      string
+	AppID         int
+	UserID        int64
+	RedirectURI   string
+	CodeChallenge string
+	Scope         string
+	Nonce         string
+	// AuthTime is when the user signed in, reported in the ID token.
+	AuthTime  time.Time
+	ExpiresAt time.Time
+}
+
+// AuthorizationRequest holds the validated parameters of an OAuth authorize request.
+type AuthorizationRequest struct {
+	AppID         int32
+	RedirectURI   string
+	CodeChallenge string
+	Scope         string
+	Nonce         string
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// DefaultOrgID is the org everything created before orgs existed belongs to.
+// Its admins manage every org.
+const DefaultOrgID int64 = 1
+
+// Org is a tenant. Its users and apps are invisible to the admins of other orgs.
+type Org struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+}
+
+// AdminScope is what an admin may manage. OrgID is zero for admins of the
+// default org, who manage every org. AppIDs is only set for admins of single
+// apps, who manage these apps and nothing else of their org.
+type AdminScope struct {
+	UserID int64
+	OrgID  int64
+	AppIDs []int
+}
+
+// CoversOrg reports whether the admin may see the org's users and apps.
+func (s AdminScope) CoversOrg(orgID int64) bool {
+	return s.OrgID == 0 || s.OrgID == orgID
+}
+
+// ManagesOrg reports whether the admin manages the whole org rather than single apps.
+func (s AdminScope) ManagesOrg() bool {
+	return s.AppIDs == nil
+}
+
+// CoversApp reports whether the admin may manage the app.
+func (s AdminScope) CoversApp(app App) bool {
+	return s.CoversOrg(app.OrgID) && (s.ManagesOrg() || slices.Contains(s.AppIDs, app.ID))
+}
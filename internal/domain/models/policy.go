@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+const (
+	PolicyAllow = "allow"
+	PolicyDeny  = "deny"
+)
+
+// PolicyRule applies Effect when its CEL Condition evaluates to true.
+type PolicyRule struct {
+	Name      string
+	Effect    string
+	Condition string
+}
+
+// Policy is one version of an app's authorization rules. Versions are never
+// changed; the newest one is in effect.
+type Policy struct {
+	AppID     int
+	Version   int
+	Rules     []PolicyRule
+	CreatedBy int64
+	CreatedAt time.Time
+}
+
+// Decision is the outcome of evaluating a policy. Rule names the rule that
+// decided and is empty when none matched, in which case Reason may say why.
+type Decision struct {
+	Allowed       bool
+	Rule          string
+	PolicyVersion int
+	Reason        string
+}
@@ -0,0 +1,10 @@
+package models
+
+// Role is a named set of permissions defined by an app. Users get the roles
+// assigned to them for the app they log into as claims in their access token.
+type Role struct {
+	ID          int64
+	AppID       int
+	Name        string
+	Permissions []string
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	// IDToken is only set for OpenID Connect requests.
+	IDToken string
+	// ExpiresIn is the lifetime of the access token.
+	ExpiresIn time.Duration
+}
+
+type RefreshToken struct {
+	ID        int64
+	TokenHash string
+	FamilyID  string
+	UserID    int64
+	AppID     int
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool
+}
+
+type TokenClaims struct {
+	ID     string
+	UserID int64
+	Email  string
+	AppID  int
+	// OrgID is the org of the user, or of the app for client tokens.
+	OrgID int64
+	// Client tokens are issued to the app itself by the client credentials
+	// grant; they have no UserID and Email.
+	Client bool
+	Scopes []string
+	// Roles and Permissions are the user's roles in the app and the permissions
+	// they granted when the token was issued.
+	Roles       []string
+	Permissions []string
+	IssuedAt    time.Time
+	ExpiresAt   time.Time
+	// KeyID is the kid of the managed key the token was signed with. Tokens
+	// signed with an app secret have none.
+	KeyID string
+}
@@ -1,7 +1,31 @@
 package models
 
+import "time"
+
 type User struct {
-	ID       int64
-	Email    string
-	PassHash []byte
+	ID            int64
+	Email         string
+	PassHash      []byte
+	EmailVerified bool
+	// TokensRevokedAt invalidates every access token issued before it; zero if never set.
+	TokensRevokedAt time.Time
+	IsAdmin         bool
+	// Disabled users can't sign in and their tokens are rejected.
+	Disabled bool
+	// CreatedAt is zero for users registered before it was tracked.
+	CreatedAt time.Time
+	// OrgID is the org the user registered in. Emails are unique per org.
+	OrgID int64
+}
+
+// UserFilter selects users for the admin user list. Zero fields don't filter.
+type UserFilter struct {
+	OrgID         int64
+	EmailPrefix   string
+	IsAdmin       *bool
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// AfterID continues a listing after the user with this ID.
+	AfterID int64
+	Limit   int
 }
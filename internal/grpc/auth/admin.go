@@ -0,0 +1,628 @@
+package auth
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/services/auth"
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// adminServerAPI serves the Admin service. Every method must start with requireAdmin.
+type adminServerAPI struct {
+	ssoa.UnimplementedAdminServer
+	*serverAPI
+}
+
+func (s *adminServerAPI) UnlockAccount(ctx context.Context, req *ssoa.UnlockAccountRequest) (*ssoa.UnlockAccountResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.UnlockAccount(ctx, admin, req.GetOrgId(), req.GetEmail(), req.GetIp()); err != nil {
+		return nil, orgError(err)
+	}
+	return &ssoa.UnlockAccountResponse{}, nil
+}
+
+func (s *adminServerAPI) CreateApp(ctx context.Context, req *ssoa.CreateAppRequest) (*ssoa.CreateAppResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	app, secret, err := s.auth.CreateApp(ctx, admin, models.App{
+		OrgID:                req.GetOrgId(),
+		Name:                 req.GetName(),
+		SigningAlg:           req.GetSigningAlg(),
+		RequireVerifiedEmail: req.GetRequireVerifiedEmail(),
+		Restricted:           req.GetRestricted(),
+		ClientScopes:         req.GetClientScopes(),
+		RedirectURIs:         req.GetRedirectUris(),
+	})
+	if err != nil {
+		return nil, appError(err)
+	}
+	return &ssoa.CreateAppResponse{App: appToProto(app), Secret: secret}, nil
+}
+
+func (s *adminServerAPI) GetApp(ctx context.Context, req *ssoa.GetAppRequest) (*ssoa.GetAppResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	app, err := s.auth.GetApp(ctx, admin, req.GetAppId())
+	if err != nil {
+		return nil, appError(err)
+	}
+	return &ssoa.GetAppResponse{App: appToProto(app)}, nil
+}
+
+func (s *adminServerAPI) ListApps(ctx context.Context, req *ssoa.ListAppsRequest) (*ssoa.ListAppsResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	apps, err := s.auth.ListApps(ctx, admin)
+	if err != nil {
+		return nil, appError(err)
+	}
+	resp := &ssoa.ListAppsResponse{Apps: make([]*ssoa.App, 0, len(apps))}
+	for _, app := range apps {
+		resp.Apps = append(resp.Apps, appToProto(app))
+	}
+	return resp, nil
+}
+
+func (s *adminServerAPI) UpdateApp(ctx context.Context, req *ssoa.UpdateAppRequest) (*ssoa.UpdateAppResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	app, err := s.auth.UpdateApp(ctx, admin, models.App{
+		ID:                   int(req.GetAppId()),
+		Name:                 req.GetName(),
+		SigningAlg:           req.GetSigningAlg(),
+		RequireVerifiedEmail: req.GetRequireVerifiedEmail(),
+		Restricted:           req.GetRestricted(),
+		ClientScopes:         req.GetClientScopes(),
+		RedirectURIs:         req.GetRedirectUris(),
+	})
+	if err != nil {
+		return nil, appError(err)
+	}
+	return &ssoa.UpdateAppResponse{App: appToProto(app)}, nil
+}
+
+func (s *adminServerAPI) RotateAppSecret(ctx context.Context, req *ssoa.RotateAppSecretRequest) (*ssoa.RotateAppSecretResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	secret, err := s.auth.RotateAppSecret(ctx, admin, req.GetAppId())
+	if err != nil {
+		return nil, appError(err)
+	}
+	return &ssoa.RotateAppSecretResponse{Secret: secret}, nil
+}
+
+func (s *adminServerAPI) RotateSigningKey(ctx context.Context, req *ssoa.RotateSigningKeyRequest) (*ssoa.RotateSigningKeyResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	kid, err := s.auth.RotateSigningKey(ctx, admin, req.GetAppId())
+	if err != nil {
+		return nil, appError(err)
+	}
+	return &ssoa.RotateSigningKeyResponse{Kid: kid}, nil
+}
+
+func (s *adminServerAPI) DeleteApp(ctx context.Context, req *ssoa.DeleteAppRequest) (*ssoa.DeleteAppResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.DeleteApp(ctx, admin, req.GetAppId()); err != nil {
+		return nil, appError(err)
+	}
+	return &ssoa.DeleteAppResponse{}, nil
+}
+
+func (s *adminServerAPI) ListUsers(ctx context.Context, req *ssoa.ListUsersRequest) (*ssoa.ListUsersResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	filter := models.UserFilter{OrgID: req.GetOrgId(), EmailPrefix: req.GetEmailPrefix(), IsAdmin: req.IsAdmin}
+	if req.GetCreatedAfter() > 0 {
+		filter.CreatedAfter = time.Unix(req.GetCreatedAfter(), 0)
+	}
+	if req.GetCreatedBefore() > 0 {
+		filter.CreatedBefore = time.Unix(req.GetCreatedBefore(), 0)
+	}
+	users, nextPageToken, err := s.auth.ListUsers(ctx, admin, filter, int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, userError(err)
+	}
+	resp := &ssoa.ListUsersResponse{Users: make([]*ssoa.User, 0, len(users)), NextPageToken: nextPageToken}
+	for _, user := range users {
+		resp.Users = append(resp.Users, userToProto(user))
+	}
+	return resp, nil
+}
+
+func (s *adminServerAPI) GetUser(ctx context.Context, req *ssoa.GetUserRequest) (*ssoa.GetUserResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	user, err := s.auth.GetUser(ctx, admin, req.GetUserId())
+	if err != nil {
+		return nil, userError(err)
+	}
+	return &ssoa.GetUserResponse{User: userToProto(user)}, nil
+}
+
+func (s *adminServerAPI) SetAdmin(ctx context.Context, req *ssoa.SetAdminRequest) (*ssoa.SetAdminResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.SetAdmin(ctx, admin, req.GetUserId(), req.GetIsAdmin()); err != nil {
+		return nil, userError(err)
+	}
+	return &ssoa.SetAdminResponse{}, nil
+}
+
+func (s *adminServerAPI) SetAppAdmin(ctx context.Context, req *ssoa.SetAppAdminRequest) (*ssoa.SetAppAdminResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.SetAppAdmin(ctx, admin, req.GetUserId(), req.GetAppId(), req.GetIsAdmin()); err != nil {
+		return nil, appMemberError(err)
+	}
+	return &ssoa.SetAppAdminResponse{}, nil
+}
+
+func (s *adminServerAPI) GrantAppAccess(ctx context.Context, req *ssoa.GrantAppAccessRequest) (*ssoa.GrantAppAccessResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.SetAppAccess(ctx, admin, req.GetUserId(), req.GetAppId(), true); err != nil {
+		return nil, appMemberError(err)
+	}
+	return &ssoa.GrantAppAccessResponse{}, nil
+}
+
+func (s *adminServerAPI) RevokeAppAccess(ctx context.Context, req *ssoa.RevokeAppAccessRequest) (*ssoa.RevokeAppAccessResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.SetAppAccess(ctx, admin, req.GetUserId(), req.GetAppId(), false); err != nil {
+		return nil, appMemberError(err)
+	}
+	return &ssoa.RevokeAppAccessResponse{}, nil
+}
+
+// appMemberError maps the errors of calls taking both a user and an app.
+func appMemberError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidAppId):
+		return appError(err)
+	case errors.Is(err, auth.ErrOrgMismatch):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return userError(err)
+}
+
+func (s *adminServerAPI) DisableUser(ctx context.Context, req *ssoa.DisableUserRequest) (*ssoa.DisableUserResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.SetUserDisabled(ctx, admin, req.GetUserId(), true); err != nil {
+		return nil, userError(err)
+	}
+	return &ssoa.DisableUserResponse{}, nil
+}
+
+func (s *adminServerAPI) EnableUser(ctx context.Context, req *ssoa.EnableUserRequest) (*ssoa.EnableUserResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.SetUserDisabled(ctx, admin, req.GetUserId(), false); err != nil {
+		return nil, userError(err)
+	}
+	return &ssoa.EnableUserResponse{}, nil
+}
+
+func (s *adminServerAPI) DeleteUser(ctx context.Context, req *ssoa.DeleteUserRequest) (*ssoa.DeleteUserResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.DeleteUser(ctx, admin, req.GetUserId()); err != nil {
+		return nil, userError(err)
+	}
+	return &ssoa.DeleteUserResponse{}, nil
+}
+
+func (s *adminServerAPI) CreateRole(ctx context.Context, req *ssoa.CreateRoleRequest) (*ssoa.CreateRoleResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	role, err := s.auth.CreateRole(ctx, admin, models.Role{
+		AppID:       int(req.GetAppId()),
+		Name:        req.GetName(),
+		Permissions: req.GetPermissions(),
+	})
+	if err != nil {
+		return nil, roleError(err)
+	}
+	return &ssoa.CreateRoleResponse{Role: roleToProto(role)}, nil
+}
+
+func (s *adminServerAPI) ListRoles(ctx context.Context, req *ssoa.ListRolesRequest) (*ssoa.ListRolesResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	roles, err := s.auth.ListRoles(ctx, admin, req.GetAppId())
+	if err != nil {
+		return nil, roleError(err)
+	}
+	resp := &ssoa.ListRolesResponse{Roles: make([]*ssoa.Role, 0, len(roles))}
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, roleToProto(role))
+	}
+	return resp, nil
+}
+
+func (s *adminServerAPI) UpdateRole(ctx context.Context, req *ssoa.UpdateRoleRequest) (*ssoa.UpdateRoleResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	role, err := s.auth.UpdateRole(ctx, admin, models.Role{
+		ID:          req.GetRoleId(),
+		Name:        req.GetName(),
+		Permissions: req.GetPermissions(),
+	})
+	if err != nil {
+		return nil, roleError(err)
+	}
+	return &ssoa.UpdateRoleResponse{Role: roleToProto(role)}, nil
+}
+
+func (s *adminServerAPI) DeleteRole(ctx context.Context, req *ssoa.DeleteRoleRequest) (*ssoa.DeleteRoleResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.DeleteRole(ctx, admin, req.GetRoleId()); err != nil {
+		return nil, roleError(err)
+	}
+	return &ssoa.DeleteRoleResponse{}, nil
+}
+
+func (s *adminServerAPI) AssignRole(ctx context.Context, req *ssoa.AssignRoleRequest) (*ssoa.AssignRoleResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.AssignRole(ctx, admin, req.GetUserId(), req.GetRoleId()); err != nil {
+		return nil, roleError(err)
+	}
+	return &ssoa.AssignRoleResponse{}, nil
+}
+
+func (s *adminServerAPI) UnassignRole(ctx context.Context, req *ssoa.UnassignRoleRequest) (*ssoa.UnassignRoleResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.UnassignRole(ctx, admin, req.GetUserId(), req.GetRoleId()); err != nil {
+		return nil, roleError(err)
+	}
+	return &ssoa.UnassignRoleResponse{}, nil
+}
+
+func (s *adminServerAPI) PutPolicy(ctx context.Context, req *ssoa.PutPolicyRequest) (*ssoa.PutPolicyResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	rules := make([]models.PolicyRule, 0, len(req.GetRules()))
+	for _, rule := range req.GetRules() {
+		rules = append(rules, models.PolicyRule{
+			Name:      rule.GetName(),
+			Effect:    policyEffects[rule.GetEffect()],
+			Condition: rule.GetCondition(),
+		})
+	}
+	saved, err := s.auth.PutPolicy(ctx, admin, req.GetAppId(), rules)
+	if err != nil {
+		return nil, policyError(err)
+	}
+	return &ssoa.PutPolicyResponse{Policy: policyToProto(saved)}, nil
+}
+
+func (s *adminServerAPI) GetPolicy(ctx context.Context, req *ssoa.GetPolicyRequest) (*ssoa.GetPolicyResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	policy, err := s.auth.GetPolicy(ctx, admin, req.GetAppId(), int(req.GetVersion()))
+	if err != nil {
+		return nil, policyError(err)
+	}
+	return &ssoa.GetPolicyResponse{Policy: policyToProto(policy)}, nil
+}
+
+func (s *adminServerAPI) ListPolicyVersions(ctx context.Context, req *ssoa.ListPolicyVersionsRequest) (*ssoa.ListPolicyVersionsResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	policies, err := s.auth.ListPolicyVersions(ctx, admin, req.GetAppId())
+	if err != nil {
+		return nil, policyError(err)
+	}
+	resp := &ssoa.ListPolicyVersionsResponse{Policies: make([]*ssoa.Policy, 0, len(policies))}
+	for _, policy := range policies {
+		resp.Policies = append(resp.Policies, policyToProto(policy))
+	}
+	return resp, nil
+}
+
+func (s *adminServerAPI) CreateOrg(ctx context.Context, req *ssoa.CreateOrgRequest) (*ssoa.CreateOrgResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	org, err := s.auth.CreateOrg(ctx, admin, req.GetName())
+	if err != nil {
+		return nil, orgError(err)
+	}
+	return &ssoa.CreateOrgResponse{Org: orgToProto(org)}, nil
+}
+
+func (s *adminServerAPI) ListOrgs(ctx context.Context, req *ssoa.ListOrgsRequest) (*ssoa.ListOrgsResponse, error) {
+	admin, err := s.requireAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orgs, err := s.auth.ListOrgs(ctx, admin)
+	if err != nil {
+		return nil, orgError(err)
+	}
+	resp := &ssoa.ListOrgsResponse{Orgs: make([]*ssoa.Org, 0, len(orgs))}
+	for _, org := range orgs {
+		resp.Orgs = append(resp.Orgs, orgToProto(org))
+	}
+	return resp, nil
+}
+
+func appToProto(app models.App) *ssoa.App {
+	return &ssoa.App{
+		Id:                   int32(app.ID),
+		Name:                 app.Name,
+		SigningAlg:           app.SigningAlg,
+		RequireVerifiedEmail: app.RequireVerifiedEmail,
+		Restricted:           app.Restricted,
+		ClientScopes:         app.ClientScopes,
+		RedirectUris:         app.RedirectURIs,
+		OrgId:                app.OrgID,
+	}
+}
+
+func appError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrInvalidAppId):
+		return status.Error(codes.NotFound, "app not found")
+	case errors.Is(err, auth.ErrAppExists):
+		return status.Error(codes.AlreadyExists, "app already exists")
+	case errors.Is(err, auth.ErrInvalidAppSettings):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, auth.ErrOrgNotFound):
+		return status.Error(codes.NotFound, "org not found")
+	case errors.Is(err, auth.ErrOutOfScope):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Internal, "Internal Server Error")
+}
+
+func userToProto(user models.User) *ssoa.User {
+	resp := &ssoa.User{
+		Id:            user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		IsAdmin:       user.IsAdmin,
+		Disabled:      user.Disabled,
+		OrgId:         user.OrgID,
+	}
+	if !user.CreatedAt.IsZero() {
+		resp.CreatedAt = user.CreatedAt.Unix()
+	}
+	return resp
+}
+
+func userError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, auth.ErrCannotModifySelf):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, auth.ErrInvalidPageToken):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, auth.ErrOutOfScope):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Internal, "Internal Server Error")
+}
+
+func roleToProto(role models.Role) *ssoa.Role {
+	return &ssoa.Role{
+		Id:          role.ID,
+		AppId:       int32(role.AppID),
+		Name:        role.Name,
+		Permissions: role.Permissions,
+	}
+}
+
+func roleError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrRoleNotFound):
+		return status.Error(codes.NotFound, "role not found")
+	case errors.Is(err, auth.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, auth.ErrInvalidAppId):
+		return status.Error(codes.NotFound, "app not found")
+	case errors.Is(err, auth.ErrRoleExists):
+		return status.Error(codes.AlreadyExists, "role already exists")
+	case errors.Is(err, auth.ErrInvalidRole):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, "Internal Server Error")
+}
+
+var policyEffects = map[ssoa.PolicyEffect]string{
+	ssoa.PolicyEffect_POLICY_EFFECT_ALLOW: models.PolicyAllow,
+	ssoa.PolicyEffect_POLICY_EFFECT_DENY:  models.PolicyDeny,
+}
+
+func policyToProto(policy models.Policy) *ssoa.Policy {
+	resp := &ssoa.Policy{
+		AppId:     int32(policy.AppID),
+		Version:   int32(policy.Version),
+		Rules:     make([]*ssoa.PolicyRule, 0, len(policy.Rules)),
+		CreatedBy: policy.CreatedBy,
+		CreatedAt: policy.CreatedAt.Unix(),
+	}
+	for _, rule := range policy.Rules {
+		effect := ssoa.PolicyEffect_POLICY_EFFECT_DENY
+		if rule.Effect == models.PolicyAllow {
+			effect = ssoa.PolicyEffect_POLICY_EFFECT_ALLOW
+		}
+		resp.Rules = append(resp.Rules, &ssoa.PolicyRule{Name: rule.Name, Effect: effect, Condition: rule.Condition})
+	}
+	return resp
+}
+
+func policyError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrPolicyNotFound):
+		return status.Error(codes.NotFound, "policy not found")
+	case errors.Is(err, auth.ErrInvalidAppId):
+		return status.Error(codes.NotFound, "app not found")
+	case errors.Is(err, auth.ErrInvalidPolicy):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, "Internal Server Error")
+}
+
+func orgToProto(org models.Org) *ssoa.Org {
+	return &ssoa.Org{
+		Id:        org.ID,
+		Name:      org.Name,
+		CreatedAt: org.CreatedAt.Unix(),
+	}
+}
+
+func orgError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrOutOfScope):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, auth.ErrOrgExists):
+		return status.Error(codes.AlreadyExists, "org already exists")
+	case errors.Is(err, auth.ErrInvalidOrg):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, "Internal Server Error")
+}
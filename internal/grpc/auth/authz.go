@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/grpc/grpcutil"
+	"AuthGRPC/internal/services/auth"
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// authenticate validates the bearer access token sent in the authorization metadata.
+// Client tokens are rejected, since every caller acts on behalf of a user.
+func (s *serverAPI) authenticate(ctx context.Context) (models.TokenClaims, error) {
+	token := grpcutil.BearerToken(ctx)
+	if token == "" {
+		return models.TokenClaims{}, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	claims, err := s.auth.ValidateToken(ctx, token, 0)
+	if err != nil {
+		if _, ok := invalidTokenReason(err); ok {
+			return models.TokenClaims{}, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return models.TokenClaims{}, status.Error(codes.Internal, "Internal Server Error")
+	}
+	if claims.Client {
+		return models.TokenClaims{}, status.Error(codes.PermissionDenied, "a user token is required")
+	}
+	return claims, nil
+}
+
+// requireAdmin lets the call through only when the bearer token belongs to an admin
+// and was signed with a managed key of an app in the admin's org, and returns what
+// the admin may manage.
+func (s *serverAPI) requireAdmin(ctx context.Context) (models.AdminScope, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return models.AdminScope{}, err
+	}
+	admin, err := s.auth.AdminScope(ctx, claims)
+	if err != nil {
+		if errors.Is(err, auth.ErrNotAdmin) {
+			return models.AdminScope{}, status.Error(codes.PermissionDenied, "admin privileges required")
+		}
+		if errors.Is(err, auth.ErrAdminToken) {
+			return models.AdminScope{}, status.Error(codes.PermissionDenied, auth.ErrAdminToken.Error())
+		}
+		return models.AdminScope{}, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return admin, nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/internal/grpc/grpcutil"
+	"AuthGRPC/internal/services/auth"
+	"context"
+	"errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *serverAPI) EnrollMFA(ctx context.Context, req *ssoa.EnrollMFARequest) (*ssoa.EnrollMFAResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secret, uri, err := s.auth.EnrollMFA(ctx, claims.UserID)
+	if err != nil {
+		return nil, mfaError(ctx, err)
+	}
+	return &ssoa.EnrollMFAResponse{Secret: secret, OtpauthUri: uri}, nil
+}
+
+func (s *serverAPI) ConfirmMFA(ctx context.Context, req *ssoa.ConfirmMFARequest) (*ssoa.ConfirmMFAResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodes, err := s.auth.ConfirmMFA(ctx, claims.UserID, req.GetCode())
+	if err != nil {
+		return nil, mfaError(ctx, err)
+	}
+	return &ssoa.ConfirmMFAResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (s *serverAPI) DisableMFA(ctx context.Context, req *ssoa.DisableMFARequest) (*ssoa.DisableMFAResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.auth.DisableMFA(ctx, claims.UserID, req.GetCode(), grpcutil.ClientIP(ctx)); err != nil {
+		return nil, mfaError(ctx, err)
+	}
+	return &ssoa.DisableMFAResponse{}, nil
+}
+
+func (s *serverAPI) VerifyMFA(ctx context.Context, req *ssoa.VerifyMFARequest) (*ssoa.VerifyMFAResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	tokens, err := s.auth.VerifyMFA(ctx, req.GetMfaToken(), req.GetCode(), grpcutil.ClientIP(ctx))
+	if err != nil {
+		return nil, mfaError(ctx, err)
+	}
+	return &ssoa.VerifyMFAResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+func (s *serverAPI) RegenerateRecoveryCodes(ctx context.Context, req *ssoa.RegenerateRecoveryCodesRequest) (*ssoa.RegenerateRecoveryCodesResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodes, err := s.auth.RegenerateRecoveryCodes(ctx, claims.UserID, req.GetCode(), grpcutil.ClientIP(ctx))
+	if err != nil {
+		return nil, mfaError(ctx, err)
+	}
+	return &ssoa.RegenerateRecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (s *serverAPI) RecoveryCodesRemaining(ctx context.Context, req *ssoa.RecoveryCodesRemainingRequest) (*ssoa.RecoveryCodesRemainingResponse, error) {
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remaining, err := s.auth.RecoveryCodesRemaining(ctx, claims.UserID)
+	if err != nil {
+		return nil, mfaError(ctx, err)
+	}
+	return &ssoa.RecoveryCodesRemainingResponse{Remaining: int32(remaining)}, nil
+}
+
+func mfaError(ctx context.Context, err error) error {
+	var locked *auth.LockedError
+	if errors.As(err, &locked) {
+		return grpcutil.RetryLaterError(ctx, err.Error(), locked.RetryAfter)
+	}
+	switch {
+	case errors.Is(err, auth.ErrInvalidMFACode):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, auth.ErrInvalidMFAChallenge):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, auth.ErrMFAAlreadyEnabled), errors.Is(err, auth.ErrMFANotEnrolled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, auth.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, auth.ErrUserDisabled):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Internal, "Internal Server Error")
+}
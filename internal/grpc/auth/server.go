@@ -1,10 +1,13 @@
 package auth
 
 import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/grpc/grpcutil"
+	"AuthGRPC/internal/lib/jwt"
 	"AuthGRPC/internal/services/auth"
 	"context"
 	"errors"
-	ssoa "github.com/AmirBazanov/protoForAuthGRPC/gen/go/sso"
 	"github.com/go-playground/validator/v10"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -12,9 +15,62 @@ import (
 )
 
 type Auth interface {
-	Login(ctx context.Context, email string, password string, appID int32) (token string, err error)
-	Register(ctx context.Context, email string, password string) (userID int64, err error)
-	IsAdmin(ctx context.Context, userID int64) (isAdmin bool, err error)
+	Login(ctx context.Context, email string, password string, appID int32, clientIP string) (tokens models.TokenPair, mfaToken string, err error)
+	Register(ctx context.Context, email string, password string, appID int32) (userID int64, err error)
+	IsAdmin(ctx context.Context, userID int64, appID int32) (isAdmin bool, err error)
+	AdminScope(ctx context.Context, claims models.TokenClaims) (scope models.AdminScope, err error)
+	CheckPermission(ctx context.Context, userID int64, appID int32, permission string) (allowed bool, err error)
+	Authorize(ctx context.Context, token string, action string, resource map[string]any, requestContext map[string]any) (decision models.Decision, err error)
+	Refresh(ctx context.Context, refreshToken string) (tokens models.TokenPair, err error)
+	ValidateToken(ctx context.Context, token string, appID int32) (claims models.TokenClaims, err error)
+	Logout(ctx context.Context, token string, refreshToken string) error
+	RevokeToken(ctx context.Context, token string) error
+	JWKS(ctx context.Context, appID int32) (keySet []jwt.JWK, err error)
+	UnlockAccount(ctx context.Context, admin models.AdminScope, orgID int64, email string, clientIP string) error
+	VerifyEmail(ctx context.Context, token string) (userID int64, err error)
+	ResendVerification(ctx context.Context, email string, appID int32) error
+	RequestPasswordReset(ctx context.Context, email string, appID int32) error
+	ConfirmPasswordReset(ctx context.Context, token string, newPassword string) error
+	ChangePassword(
+		ctx context.Context,
+		userID int64,
+		appID int32,
+		currentPassword string,
+		newPassword string,
+		revokeOtherSessions bool,
+	) (tokens models.TokenPair, err error)
+	EnrollMFA(ctx context.Context, userID int64) (secret string, uri string, err error)
+	ConfirmMFA(ctx context.Context, userID int64, code string) (recoveryCodes []string, err error)
+	DisableMFA(ctx context.Context, userID int64, code string, clientIP string) error
+	VerifyMFA(ctx context.Context, mfaToken string, code string, clientIP string) (tokens models.TokenPair, err error)
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string, clientIP string) (recoveryCodes []string, err error)
+	RecoveryCodesRemaining(ctx context.Context, userID int64) (remaining int, err error)
+	ClientCredentials(ctx context.Context, appID int32, secret string, scopes []string) (tokens models.TokenPair, granted []string, err error)
+	CreateApp(ctx context.Context, admin models.AdminScope, app models.App) (created models.App, secret string, err error)
+	GetApp(ctx context.Context, admin models.AdminScope, appID int32) (app models.App, err error)
+	ListApps(ctx context.Context, admin models.AdminScope) (apps []models.App, err error)
+	UpdateApp(ctx context.Context, admin models.AdminScope, app models.App) (updated models.App, err error)
+	RotateAppSecret(ctx context.Context, admin models.AdminScope, appID int32) (secret string, err error)
+	RotateSigningKey(ctx context.Context, admin models.AdminScope, appID int32) (kid string, err error)
+	DeleteApp(ctx context.Context, admin models.AdminScope, appID int32) error
+	ListUsers(ctx context.Context, admin models.AdminScope, filter models.UserFilter, pageSize int, pageToken string) (users []models.User, nextPageToken string, err error)
+	GetUser(ctx context.Context, admin models.AdminScope, userID int64) (user models.User, err error)
+	SetAdmin(ctx context.Context, admin models.AdminScope, userID int64, isAdmin bool) error
+	SetAppAdmin(ctx context.Context, admin models.AdminScope, userID int64, appID int32, isAdmin bool) error
+	SetAppAccess(ctx context.Context, admin models.AdminScope, userID int64, appID int32, hasAccess bool) error
+	SetUserDisabled(ctx context.Context, admin models.AdminScope, userID int64, disabled bool) error
+	DeleteUser(ctx context.Context, admin models.AdminScope, userID int64) error
+	CreateRole(ctx context.Context, admin models.AdminScope, role models.Role) (created models.Role, err error)
+	ListRoles(ctx context.Context, admin models.AdminScope, appID int32) (roles []models.Role, err error)
+	UpdateRole(ctx context.Context, admin models.AdminScope, role models.Role) (updated models.Role, err error)
+	DeleteRole(ctx context.Context, admin models.AdminScope, roleID int64) error
+	AssignRole(ctx context.Context, admin models.AdminScope, userID int64, roleID int64) error
+	UnassignRole(ctx context.Context, admin models.AdminScope, userID int64, roleID int64) error
+	PutPolicy(ctx context.Context, admin models.AdminScope, appID int32, rules []models.PolicyRule) (saved models.Policy, err error)
+	GetPolicy(ctx context.Context, admin models.AdminScope, appID int32, version int) (models.Policy, error)
+	ListPolicyVersions(ctx context.Context, admin models.AdminScope, appID int32) (policies []models.Policy, err error)
+	CreateOrg(ctx context.Context, admin models.AdminScope, name string) (org models.Org, err error)
+	ListOrgs(ctx context.Context, admin models.AdminScope) (orgs []models.Org, err error)
 }
 
 type serverAPI struct {
@@ -30,34 +86,54 @@ func Register(gRPC *grpc.Server, auth Auth) {
 		auth:      auth,
 	}
 	ssoa.RegisterAuthServer(gRPC, server)
+	ssoa.RegisterAdminServer(gRPC, &adminServerAPI{serverAPI: server})
 }
 
 func (s *serverAPI) Login(ctx context.Context, req *ssoa.LoginRequest) (*ssoa.LoginResponse, error) {
 	if err := s.validateGrpc(req); err != nil {
 		return nil, err
 	}
-	token, err := s.auth.Login(ctx, req.GetEmail(), req.GetPassword(), req.GetAppId())
+	tokens, mfaToken, err := s.auth.Login(ctx, req.GetEmail(), req.GetPassword(), req.GetAppId(), grpcutil.ClientIP(ctx))
 	if err != nil {
+		var locked *auth.LockedError
+		if errors.As(err, &locked) {
+			return nil, grpcutil.RetryLaterError(ctx, err.Error(), locked.RetryAfter)
+		}
 		if errors.Is(err, auth.ErrInvalidCredentials) {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
 		if errors.Is(err, auth.ErrInvalidAppId) {
 			return nil, status.Error(codes.NotFound, err.Error())
 		}
+		if errors.Is(err, auth.ErrEmailNotVerified) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if errors.Is(err, auth.ErrUserDisabled) || errors.Is(err, auth.ErrAppAccessDenied) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "Internal Server Error")
 	}
-	return &ssoa.LoginResponse{Token: token}, nil
+	if mfaToken != "" {
+		return &ssoa.LoginResponse{MfaRequired: true, MfaToken: mfaToken}, nil
+	}
+	return &ssoa.LoginResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
 }
 
 func (s *serverAPI) Register(ctx context.Context, req *ssoa.RegisterRequest) (*ssoa.RegisterResponse, error) {
 	if err := s.validateGrpc(req); err != nil {
 		return nil, err
 	}
-	userID, err := s.auth.Register(ctx, req.GetEmail(), req.GetPassword())
+	userID, err := s.auth.Register(ctx, req.GetEmail(), req.GetPassword(), req.GetAppId())
 	if err != nil {
 		if errors.Is(err, auth.ErrUserExists) {
 			return nil, status.Error(codes.AlreadyExists, err.Error())
 		}
+		if errors.Is(err, auth.ErrInvalidAppId) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		if errors.Is(err, auth.ErrWeakPassword) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Error(codes.Internal, "Internal Server Error")
 	}
 	return &ssoa.RegisterResponse{UserId: userID}, nil
@@ -67,9 +143,9 @@ func (s *serverAPI) IsAdmin(ctx context.Context, req *ssoa.IsAdminRequest) (*sso
 	if err := s.validateGrpc(req); err != nil {
 		return nil, err
 	}
-	isAdmin, err := s.auth.IsAdmin(ctx, req.GetUserId())
+	isAdmin, err := s.auth.IsAdmin(ctx, req.GetUserId(), req.GetAppId())
 	if err != nil {
-		if errors.Is(err, auth.ErrUserNotFound) {
+		if errors.Is(err, auth.ErrUserNotFound) || errors.Is(err, auth.ErrInvalidAppId) {
 			return nil, status.Error(codes.NotFound, err.Error())
 		}
 		return nil, status.Error(codes.Internal, "Internal Server Error")
@@ -77,6 +153,252 @@ func (s *serverAPI) IsAdmin(ctx context.Context, req *ssoa.IsAdminRequest) (*sso
 	return &ssoa.IsAdminResponse{IsAdmin: isAdmin}, nil
 }
 
+func (s *serverAPI) CheckPermission(ctx context.Context, req *ssoa.CheckPermissionRequest) (*ssoa.CheckPermissionResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	allowed, err := s.auth.CheckPermission(ctx, req.GetUserId(), req.GetAppId(), req.GetPermission())
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) || errors.Is(err, auth.ErrInvalidAppId) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.CheckPermissionResponse{Allowed: allowed}, nil
+}
+
+func (s *serverAPI) Authorize(ctx context.Context, req *ssoa.AuthorizeRequest) (*ssoa.AuthorizeResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	decision, err := s.auth.Authorize(ctx, req.GetToken(), req.GetAction(), req.GetResource().AsMap(), req.GetContext().AsMap())
+	if err != nil {
+		if _, ok := invalidTokenReason(err); ok {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(err, auth.ErrClientToken) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.AuthorizeResponse{
+		Allowed:       decision.Allowed,
+		Rule:          decision.Rule,
+		PolicyVersion: int32(decision.PolicyVersion),
+		Reason:        decision.Reason,
+	}, nil
+}
+
+func (s *serverAPI) Refresh(ctx context.Context, req *ssoa.RefreshRequest) (*ssoa.RefreshResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	tokens, err := s.auth.Refresh(ctx, req.GetRefreshToken())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidRefreshToken) || errors.Is(err, auth.ErrRefreshTokenReused) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if errors.Is(err, auth.ErrAppAccessDenied) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.RefreshResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+func (s *serverAPI) ClientCredentials(ctx context.Context, req *ssoa.ClientCredentialsRequest) (*ssoa.ClientCredentialsResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	tokens, scopes, err := s.auth.ClientCredentials(ctx, req.GetAppId(), req.GetClientSecret(), req.GetScopes())
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidClient):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		case errors.Is(err, auth.ErrInvalidScope):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.ClientCredentialsResponse{
+		Token:     tokens.AccessToken,
+		ExpiresIn: int64(tokens.ExpiresIn.Seconds()),
+		Scopes:    scopes,
+	}, nil
+}
+
+func (s *serverAPI) ValidateToken(ctx context.Context, req *ssoa.ValidateTokenRequest) (*ssoa.ValidateTokenResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	claims, err := s.auth.ValidateToken(ctx, req.GetToken(), req.GetAppId())
+	if err != nil {
+		if reason, ok := invalidTokenReason(err); ok {
+			return &ssoa.ValidateTokenResponse{Valid: false, Reason: reason}, nil
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.ValidateTokenResponse{
+		Valid:       true,
+		UserId:      claims.UserID,
+		Email:       claims.Email,
+		AppId:       int32(claims.AppID),
+		Exp:         claims.ExpiresAt.Unix(),
+		Client:      claims.Client,
+		Scopes:      claims.Scopes,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		OrgId:       claims.OrgID,
+	}, nil
+}
+
+func (s *serverAPI) Logout(ctx context.Context, req *ssoa.LogoutRequest) (*ssoa.LogoutResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.Logout(ctx, req.GetToken(), req.GetRefreshToken()); err != nil {
+		if _, ok := invalidTokenReason(err); ok || errors.Is(err, auth.ErrInvalidRefreshToken) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.LogoutResponse{}, nil
+}
+
+func (s *serverAPI) RevokeToken(ctx context.Context, req *ssoa.RevokeTokenRequest) (*ssoa.RevokeTokenResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.RevokeToken(ctx, req.GetToken()); err != nil {
+		if _, ok := invalidTokenReason(err); ok {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.RevokeTokenResponse{}, nil
+}
+
+func (s *serverAPI) JWKS(ctx context.Context, req *ssoa.JWKSRequest) (*ssoa.JWKSResponse, error) {
+	keySet, err := s.auth.JWKS(ctx, req.GetAppId())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidAppId) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	keys := make([]*ssoa.JWK, 0, len(keySet))
+	for _, key := range keySet {
+		keys = append(keys, &ssoa.JWK{
+			Kty: key.Kty,
+			Kid: key.Kid,
+			Use: key.Use,
+			Alg: key.Alg,
+			N:   key.N,
+			E:   key.E,
+			Crv: key.Crv,
+			X:   key.X,
+			Y:   key.Y,
+		})
+	}
+	return &ssoa.JWKSResponse{Keys: keys}, nil
+}
+
+func (s *serverAPI) VerifyEmail(ctx context.Context, req *ssoa.VerifyEmailRequest) (*ssoa.VerifyEmailResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	userID, err := s.auth.VerifyEmail(ctx, req.GetToken())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidEmailToken) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.VerifyEmailResponse{UserId: userID}, nil
+}
+
+func (s *serverAPI) ResendVerification(ctx context.Context, req *ssoa.ResendVerificationRequest) (*ssoa.ResendVerificationResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.ResendVerification(ctx, req.GetEmail(), req.GetAppId()); err != nil {
+		if errors.Is(err, auth.ErrInvalidAppId) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.ResendVerificationResponse{}, nil
+}
+
+func (s *serverAPI) RequestPasswordReset(ctx context.Context, req *ssoa.RequestPasswordResetRequest) (*ssoa.RequestPasswordResetResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.RequestPasswordReset(ctx, req.GetEmail(), req.GetAppId()); err != nil {
+		if errors.Is(err, auth.ErrInvalidAppId) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.RequestPasswordResetResponse{}, nil
+}
+
+func (s *serverAPI) ConfirmPasswordReset(ctx context.Context, req *ssoa.ConfirmPasswordResetRequest) (*ssoa.ConfirmPasswordResetResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	if err := s.auth.ConfirmPasswordReset(ctx, req.GetToken(), req.GetNewPassword()); err != nil {
+		if errors.Is(err, auth.ErrInvalidEmailToken) || errors.Is(err, auth.ErrWeakPassword) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.ConfirmPasswordResetResponse{}, nil
+}
+
+// ChangePassword requires the caller's access token in the authorization metadata.
+func (s *serverAPI) ChangePassword(ctx context.Context, req *ssoa.ChangePasswordRequest) (*ssoa.ChangePasswordResponse, error) {
+	if err := s.validateGrpc(req); err != nil {
+		return nil, err
+	}
+	claims, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := s.auth.ChangePassword(ctx, claims.UserID, int32(claims.AppID),
+		req.GetCurrentPassword(), req.GetNewPassword(), req.GetRevokeOtherSessions())
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidCredentials), errors.Is(err, auth.ErrWeakPassword):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, auth.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, auth.ErrPasswordChanged):
+			return nil, status.Error(codes.Aborted, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "Internal Server Error")
+	}
+	return &ssoa.ChangePasswordResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+func invalidTokenReason(err error) (ssoa.TokenInvalidReason, bool) {
+	switch {
+	case errors.Is(err, auth.ErrTokenMalformed):
+		return ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_MALFORMED, true
+	case errors.Is(err, auth.ErrTokenExpired):
+		return ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_EXPIRED, true
+	case errors.Is(err, auth.ErrTokenSignature):
+		return ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_BAD_SIGNATURE, true
+	case errors.Is(err, auth.ErrInvalidAppId):
+		return ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_UNKNOWN_APP, true
+	case errors.Is(err, auth.ErrTokenAppMismatch):
+		return ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_APP_MISMATCH, true
+	case errors.Is(err, auth.ErrTokenRevoked):
+		return ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_REVOKED, true
+	}
+	return ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_UNSPECIFIED, false
+}
+
 func (s *serverAPI) validateGrpc(req interface{}) error {
 	if err := s.validator.Struct(req); err != nil {
 		return status.Error(codes.InvalidArgument, err.Error())
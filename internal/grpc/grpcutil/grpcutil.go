@@ -0,0 +1,64 @@
+// Package grpcutil reads the caller of a gRPC call and builds the errors that
+// both the handlers and the interceptors answer with, so they agree on both.
+package grpcutil
+
+import (
+	"context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AuthorizationHeader = "authorization"
+	RetryAfterHeader    = "retry-after"
+
+	bearerPrefix = "bearer "
+)
+
+// ClientIP returns the address of the caller without the port. Lockouts and
+// rate limits key on it, so they always agree on who the caller is.
+func ClientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// BearerToken returns the token of the authorization metadata, or an empty
+// string if there is no bearer token.
+func BearerToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(AuthorizationHeader)
+	if len(values) == 0 || !strings.HasPrefix(strings.ToLower(values[0]), bearerPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(values[0][len(bearerPrefix):])
+}
+
+// RetryLaterError builds a ResourceExhausted status carrying RetryInfo and sets
+// the retry-after header for clients that don't read status details.
+func RetryLaterError(ctx context.Context, msg string, retryAfter time.Duration) error {
+	seconds := int64((retryAfter + time.Second - 1) / time.Second)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, strconv.FormatInt(seconds, 10)))
+	st, err := status.New(codes.ResourceExhausted, msg).WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(retryAfter),
+	})
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, msg)
+	}
+	return st.Err()
+}
@@ -0,0 +1,51 @@
+package grpcutil
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "no peer", ctx: context.Background(), want: ""},
+		{name: "ipv4", ctx: peerContext(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}), want: "10.0.0.1"},
+		{name: "ipv6", ctx: peerContext(&net.TCPAddr{IP: net.ParseIP("::1"), Port: 5000}), want: "::1"},
+		{name: "no port", ctx: peerContext(&net.UnixAddr{Name: "/tmp/sso.sock", Net: "unix"}), want: "/tmp/sso.sock"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClientIP(tt.ctx))
+		})
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []string
+		want   string
+	}{
+		{name: "missing", want: ""},
+		{name: "bearer", header: []string{"Bearer token"}, want: "token"},
+		{name: "case insensitive", header: []string{"bearer  token "}, want: "token"},
+		{name: "other scheme", header: []string{"Basic dXNlcjpwYXNz"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{AuthorizationHeader: tt.header})
+			assert.Equal(t, tt.want, BearerToken(ctx))
+		})
+	}
+}
+
+func peerContext(addr net.Addr) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+}
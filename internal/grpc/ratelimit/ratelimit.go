@@ -0,0 +1,252 @@
+package ratelimit
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/grpc/grpcutil"
+	"AuthGRPC/internal/lib/opaque"
+	"context"
+	"fmt"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	KeyIP      = "ip"
+	KeyAppID   = "app_id"
+	KeySubject = "subject"
+
+	sweepInterval = time.Minute
+	idleTimeout   = 10 * time.Minute
+
+	// resolvedTTL is how long a resolved app id or token subject is trusted
+	// without asking the database again; maxResolved bounds how many are.
+	resolvedTTL = time.Minute
+	maxResolved = 10_000
+)
+
+// Limit is a token bucket refilled with RPS tokens per second up to Burst.
+// Key selects what callers share a bucket: the peer IP, the app_id of the
+// request or the subject of the bearer token. Calls that don't carry the
+// selected key, or carry an unknown app or an invalid token, fall back to the
+// peer IP.
+type Limit struct {
+	RPS   float64
+	Burst int
+	Key   string
+}
+
+// Config holds the limits of every method. Methods without an entry use Default.
+type Config struct {
+	Enabled bool
+	Default Limit
+	Methods map[string]Limit
+}
+
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string, appID int32) (claims models.TokenClaims, err error)
+}
+
+type AppProvider interface {
+	App(ctx context.Context, appID int32) (app models.App, err error)
+}
+
+type Limiter struct {
+	log       *slog.Logger
+	def       Limit
+	methods   map[string]Limit
+	validator TokenValidator
+	apps      AppProvider
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	resolved  map[string]resolvedKey
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// resolvedKey is the bucket key an app id or a token resolved to.
+type resolvedKey struct {
+	key     string
+	expires time.Time
+}
+
+// New creates a limiter enforcing cfg. The validator resolves the subject
+// of bearer tokens for limits keyed by subject, apps the app ids of limits
+// keyed by app_id.
+func New(log *slog.Logger, cfg Config, validator TokenValidator, apps AppProvider) (*Limiter, error) {
+	for method, limit := range cfg.Methods {
+		if err := limit.validate(); err != nil {
+			return nil, fmt.Errorf("rate limit for %s: %w", method, err)
+		}
+	}
+	if err := cfg.Default.validate(); err != nil {
+		return nil, fmt.Errorf("default rate limit: %w", err)
+	}
+	return &Limiter{
+		log:       log,
+		def:       cfg.Default,
+		methods:   cfg.Methods,
+		validator: validator,
+		apps:      apps,
+		buckets:   make(map[string]*bucket),
+		resolved:  make(map[string]resolvedKey),
+		lastSweep: time.Now(),
+	}, nil
+}
+
+func (l Limit) validate() error {
+	switch l.Key {
+	case KeyIP, KeyAppID, KeySubject:
+	default:
+		return fmt.Errorf("unknown key %q", l.Key)
+	}
+	if l.RPS <= 0 || l.Burst <= 0 {
+		return fmt.Errorf("rps and burst must be positive")
+	}
+	return nil
+}
+
+// UnaryServerInterceptor rejects calls over the limit with ResourceExhausted
+// and a retry-after header holding the number of seconds to wait.
+//
+// App ids and token subjects need the database to resolve. Until they are, the
+// call is charged to the peer IP first, so unknown apps and forged tokens can
+// neither dodge the limit nor make the limiter query the database while the
+// caller is over it.
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		limit, ok := l.methods[info.FullMethod]
+		if !ok {
+			limit = l.def
+		}
+		ipKey := "ip:" + grpcutil.ClientIP(ctx)
+		key, cacheKey := l.callerKey(ctx, req, limit.Key)
+		if key == "" {
+			if err := l.check(ctx, info.FullMethod, ipKey, limit); err != nil {
+				return nil, err
+			}
+			key = l.resolve(ctx, req, limit.Key, cacheKey)
+		}
+		if key != "" {
+			if err := l.check(ctx, info.FullMethod, key, limit); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// check takes a token from the bucket of the caller key for the method.
+func (l *Limiter) check(ctx context.Context, method string, callerKey string, limit Limit) error {
+	key := method + "|" + callerKey
+	delay, allowed := l.allow(key, limit)
+	if allowed {
+		return nil
+	}
+	const op = "grpc.ratelimit.UnaryServerInterceptor"
+	l.log.With(slog.String("op", op)).Warn("rate limit exceeded",
+		slog.String("key", key),
+		slog.Duration("retryAfter", delay))
+	return grpcutil.RetryLaterError(ctx, "rate limit exceeded", delay)
+}
+
+func (l *Limiter) allow(key string, limit Limit) (delay time.Duration, allowed bool) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		for k, b := range l.buckets {
+			if now.Sub(b.lastSeen) > idleTimeout {
+				delete(l.buckets, k)
+			}
+		}
+		for k, r := range l.resolved {
+			if now.After(r.expires) {
+				delete(l.resolved, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.RPS), limit.Burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if delay = reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay, false
+	}
+	return 0, true
+}
+
+// callerKey returns the bucket key of the call without touching the database.
+// It is empty when the app id or token still has to be resolved; cacheKey then
+// names the resolution in the cache.
+func (l *Limiter) callerKey(ctx context.Context, req any, key string) (callerKey string, cacheKey string) {
+	switch key {
+	case KeyAppID:
+		if r, ok := req.(interface{ GetAppId() int32 }); ok && r.GetAppId() != 0 && l.apps != nil {
+			cacheKey = "app:" + strconv.Itoa(int(r.GetAppId()))
+		}
+	case KeySubject:
+		if token := grpcutil.BearerToken(ctx); token != "" && l.validator != nil {
+			cacheKey = "token:" + opaque.Hash(token)
+		}
+	}
+	if cacheKey == "" {
+		return "ip:" + grpcutil.ClientIP(ctx), ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r, ok := l.resolved[cacheKey]; ok && time.Now().Before(r.expires) {
+		return r.key, cacheKey
+	}
+	return "", cacheKey
+}
+
+// resolve looks up the app id or validates the token of the call and caches the
+// result. It returns an empty key for unknown apps and invalid tokens, whose
+// calls count against the peer IP only.
+func (l *Limiter) resolve(ctx context.Context, req any, key string, cacheKey string) string {
+	var callerKey string
+	expires := time.Now().Add(resolvedTTL)
+	switch key {
+	case KeyAppID:
+		appID := req.(interface{ GetAppId() int32 }).GetAppId()
+		if _, err := l.apps.App(ctx, appID); err != nil {
+			return ""
+		}
+		callerKey = cacheKey
+	case KeySubject:
+		claims, err := l.validator.ValidateToken(ctx, grpcutil.BearerToken(ctx), 0)
+		if err != nil {
+			return ""
+		}
+		if claims.Client {
+			callerKey = "client:" + strconv.Itoa(claims.AppID)
+		} else {
+			callerKey = "sub:" + strconv.FormatInt(claims.UserID, 10)
+		}
+		if claims.ExpiresAt.Before(expires) {
+			expires = claims.ExpiresAt
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.resolved) < maxResolved {
+		l.resolved[cacheKey] = resolvedKey{key: callerKey, expires: expires}
+	}
+	return callerKey
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/grpc/grpcutil"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+const method = "/auth.Auth/Login"
+
+type fakeValidator struct {
+	calls  int
+	claims map[string]models.TokenClaims
+}
+
+func (v *fakeValidator) ValidateToken(_ context.Context, token string, _ int32) (models.TokenClaims, error) {
+	v.calls++
+	claims, ok := v.claims[token]
+	if !ok {
+		return models.TokenClaims{}, errors.New("invalid token")
+	}
+	return claims, nil
+}
+
+type fakeApps struct {
+	calls int
+	ids   map[int32]bool
+}
+
+func (a *fakeApps) App(_ context.Context, appID int32) (models.App, error) {
+	a.calls++
+	if !a.ids[appID] {
+		return models.App{}, errors.New("app not found")
+	}
+	return models.App{ID: int(appID)}, nil
+}
+
+// headerStream records the headers the interceptor sets.
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return method }
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func newLimiter(t *testing.T, limit Limit, validator TokenValidator, apps AppProvider) grpc.UnaryServerInterceptor {
+	t.Helper()
+	l, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)), Config{Enabled: true, Default: limit}, validator, apps)
+	require.NoError(t, err)
+	return l.UnaryServerInterceptor()
+}
+
+func callerContext(ip string, token string) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 4242}})
+	if token != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+	}
+	return ctx
+}
+
+func call(interceptor grpc.UnaryServerInterceptor, ctx context.Context, req any) error {
+	_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, any) (any, error) {
+		return "ok", nil
+	})
+	return err
+}
+
+func TestInterceptor_Exhausted(t *testing.T) {
+	interceptor := newLimiter(t, Limit{RPS: 0.5, Burst: 1, Key: KeyIP}, nil, nil)
+	stream := &headerStream{}
+	ctx := grpc.NewContextWithServerTransportStream(callerContext("10.0.0.1", ""), stream)
+
+	require.NoError(t, call(interceptor, ctx, &ssoa.LoginRequest{}))
+	err := call(interceptor, ctx, &ssoa.LoginRequest{})
+	require.Error(t, err)
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	delay := retryInfo.GetRetryDelay().AsDuration()
+	assert.Greater(t, delay, time.Duration(0))
+	assert.LessOrEqual(t, delay, 2*time.Second)
+	assert.Equal(t, []string{"2"}, stream.header.Get(grpcutil.RetryAfterHeader))
+
+	assert.NoError(t, call(interceptor, callerContext("10.0.0.2", ""), &ssoa.LoginRequest{}), "other IPs have their own bucket")
+}
+
+func TestInterceptor_AppID(t *testing.T) {
+	apps := &fakeApps{ids: map[int32]bool{1: true}}
+	interceptor := newLimiter(t, Limit{RPS: 0.001, Burst: 1, Key: KeyAppID}, nil, apps)
+
+	require.NoError(t, call(interceptor, callerContext("10.0.0.1", ""), &ssoa.LoginRequest{AppId: 1}))
+	err := call(interceptor, callerContext("10.0.0.2", ""), &ssoa.LoginRequest{AppId: 1})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "callers of an app share its bucket")
+	assert.Equal(t, 1, apps.calls, "known apps are cached")
+
+	require.NoError(t, call(interceptor, callerContext("10.0.0.3", ""), &ssoa.LoginRequest{AppId: 1000}))
+	err = call(interceptor, callerContext("10.0.0.3", ""), &ssoa.LoginRequest{AppId: 1001})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "unknown apps fall back to the IP")
+	assert.Equal(t, 2, apps.calls, "limited calls aren't looked up")
+}
+
+func TestInterceptor_Subject(t *testing.T) {
+	validator := &fakeValidator{claims: map[string]models.TokenClaims{
+		"valid": {UserID: 7, ExpiresAt: time.Now().Add(time.Hour)},
+	}}
+	interceptor := newLimiter(t, Limit{RPS: 0.001, Burst: 1, Key: KeySubject}, validator, nil)
+
+	require.NoError(t, call(interceptor, callerContext("10.0.0.1", "valid"), &ssoa.LoginRequest{}))
+	err := call(interceptor, callerContext("10.0.0.2", "valid"), &ssoa.LoginRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "a subject shares its bucket across IPs")
+	assert.Equal(t, 1, validator.calls, "validated tokens are cached")
+
+	require.NoError(t, call(interceptor, callerContext("10.0.0.3", "forged-1"), &ssoa.LoginRequest{}))
+	err = call(interceptor, callerContext("10.0.0.3", "forged-2"), &ssoa.LoginRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "invalid tokens fall back to the IP")
+	assert.Equal(t, 2, validator.calls, "limited calls aren't validated")
+}
@@ -0,0 +1,54 @@
+package jwks
+
+import (
+	"AuthGRPC/internal/lib/jwt"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/services/auth"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type KeySetProvider interface {
+	JWKS(ctx context.Context, appID int32) (keySet []jwt.JWK, err error)
+}
+
+type keySet struct {
+	Keys []jwt.JWK `json:"keys"`
+}
+
+// New serves the JWKS document. The optional app_id query parameter limits it to one app.
+func New(log *slog.Logger, provider KeySetProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http.jwks.New"
+		log := log.With(slog.String("op", op))
+
+		var appID int64
+		if raw := r.URL.Query().Get("app_id"); raw != "" {
+			var err error
+			appID, err = strconv.ParseInt(raw, 10, 32)
+			if err != nil {
+				http.Error(w, "invalid app_id", http.StatusBadRequest)
+				return
+			}
+		}
+		keys, err := provider.JWKS(r.Context(), int32(appID))
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidAppId) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			log.Error("failed to get jwks", sl.Err(err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if err := json.NewEncoder(w).Encode(keySet{Keys: keys}); err != nil {
+			log.Error("failed to write jwks", sl.Err(err))
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package oauth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/services/auth"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const codeChallengeMethodS256 = "S256"
+
+type Authorizer interface {
+	ValidateAuthorizationRequest(ctx context.Context, appID int32, redirectURI string) error
+	AuthorizeWithPassword(ctx context.Context, req models.AuthorizationRequest, email string, password string, clientIP string) (code string, mfaToken string, err error)
+	AuthorizeWithMFA(ctx context.Context, req models.AuthorizationRequest, mfaToken string, mfaCode string, clientIP string) (code string, err error)
+}
+
+// NewAuthorize serves the authorization endpoint of the code flow (RFC 6749 4.1) with a
+// built-in sign-in page. GET shows the page, POST checks the credentials and
+// redirects back to the client with a code. PKCE with S256 is mandatory, and
+// POSTs must carry the CSRF token of the page.
+func NewAuthorize(log *slog.Logger, authorizer Authorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http.oauth.Authorize"
+		log := log.With(slog.String("op", op))
+
+		if err := r.ParseForm(); err != nil {
+			renderError(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+		p := page{
+			ClientID:            r.Form.Get("client_id"),
+			RedirectURI:         r.Form.Get("redirect_uri"),
+			ResponseType:        r.Form.Get("response_type"),
+			Scope:               r.Form.Get("scope"),
+			State:               r.Form.Get("state"),
+			Nonce:               r.Form.Get("nonce"),
+			CodeChallenge:       r.Form.Get("code_challenge"),
+			CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+		}
+		appID, err := strconv.ParseInt(p.ClientID, 10, 32)
+		if err != nil {
+			renderError(w, http.StatusBadRequest, "invalid client_id")
+			return
+		}
+		if err := authorizer.ValidateAuthorizationRequest(r.Context(), int32(appID), p.RedirectURI); err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidAppId):
+				renderError(w, http.StatusBadRequest, "unknown client_id")
+			case errors.Is(err, auth.ErrInvalidRedirectURI):
+				renderError(w, http.StatusBadRequest, "redirect_uri is not registered for this client")
+			default:
+				log.Error("failed to validate authorization request", sl.Err(err))
+				renderError(w, http.StatusInternalServerError, "Internal Server Error")
+			}
+			return
+		}
+		// From here on errors go back to the client.
+		if p.ResponseType != "code" {
+			redirectError(w, r, p, "unsupported_response_type", "only response_type=code is supported")
+			return
+		}
+		if p.CodeChallenge == "" || p.CodeChallengeMethod != codeChallengeMethodS256 {
+			redirectError(w, r, p, "invalid_request", "code_challenge with code_challenge_method=S256 is required")
+			return
+		}
+		if r.Method != http.MethodPost {
+			if p.CSRFToken, err = newCSRFToken(w, r); err != nil {
+				log.Error("failed to create csrf token", sl.Err(err))
+				renderError(w, http.StatusInternalServerError, "Internal Server Error")
+				return
+			}
+			renderPage(w, log, http.StatusOK, p)
+			return
+		}
+		if !validCSRFToken(r) {
+			log.Warn("csrf token missing or wrong")
+			renderError(w, http.StatusForbidden, "the sign-in form has expired, reload the page")
+			return
+		}
+		p.CSRFToken = r.PostForm.Get(csrfField)
+
+		req := models.AuthorizationRequest{
+			AppID:         int32(appID),
+			RedirectURI:   p.RedirectURI,
+			CodeChallenge: p.CodeChallenge,
+			Scope:         p.Scope,
+			Nonce:         p.Nonce,
+		}
+		var code string
+		if mfaToken := r.PostForm.Get("mfa_token"); mfaToken != "" {
+			code, err = authorizer.AuthorizeWithMFA(r.Context(), req, mfaToken, r.PostForm.Get("mfa_code"), clientIP(r))
+			p.MFAToken = mfaToken
+		} else {
+			p.Email = r.PostForm.Get("email")
+			code, p.MFAToken, err = authorizer.AuthorizeWithPassword(r.Context(), req, p.Email, r.PostForm.Get("password"), clientIP(r))
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidCredentials):
+				p.Error = "Invalid email or password."
+				renderPage(w, log, http.StatusUnauthorized, p)
+			case errors.Is(err, auth.ErrTooManyAttempts):
+				p.Error = "Too many failed attempts. Try again later."
+				renderPage(w, log, http.StatusTooManyRequests, p)
+			case errors.Is(err, auth.ErrInvalidMFACode):
+				p.Error = "Invalid code."
+				renderPage(w, log, http.StatusUnauthorized, p)
+			case errors.Is(err, auth.ErrInvalidMFAChallenge):
+				p.MFAToken = ""
+				p.Error = "Your sign-in has expired. Sign in again."
+				renderPage(w, log, http.StatusUnauthorized, p)
+			case errors.Is(err, auth.ErrEmailNotVerified):
+				redirectError(w, r, p, "access_denied", "email is not verified")
+			case errors.Is(err, auth.ErrUserDisabled):
+				redirectError(w, r, p, "access_denied", "user is disabled")
+			case errors.Is(err, auth.ErrAppAccessDenied):
+				redirectError(w, r, p, "access_denied", "user has no access to the app")
+			default:
+				log.Error("failed to authorize", sl.Err(err))
+				redirectError(w, r, p, "server_error", "")
+			}
+			return
+		}
+		if code == "" {
+			renderPage(w, log, http.StatusOK, p)
+			return
+		}
+		redirect(w, r, p, url.Values{"code": {code}})
+	}
+}
+
+func redirectError(w http.ResponseWriter, r *http.Request, p page, code string, description string) {
+	params := url.Values{"error": {code}}
+	if description != "" {
+		params.Set("error_description", description)
+	}
+	redirect(w, r, p, params)
+}
+
+// redirect sends the user agent back to the validated redirect URI, keeping its query.
+func redirect(w http.ResponseWriter, r *http.Request, p page, params url.Values) {
+	target, err := url.Parse(p.RedirectURI)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "invalid redirect_uri")
+		return
+	}
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if p.State != "" {
+		query.Set("state", p.State)
+	}
+	target.RawQuery = query.Encode()
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,91 @@
+package oauth
+
+import (
+	"AuthGRPC/internal/lib/logger/sl"
+	"html/template"
+	"log/slog"
+	"net/http"
+)
+
+// page is the data of the built-in sign-in page. The authorization request is
+// carried along in hidden fields, so the POST doesn't depend on server-side state.
+type page struct {
+	ClientID            string
+	RedirectURI         string
+	ResponseType        string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Email               string
+	MFAToken            string
+	CSRFToken           string
+	Error               string
+}
+
+var pageTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Sign in</title>
+<style>
+body{font-family:sans-serif;max-width:22rem;margin:4rem auto;padding:0 1rem}
+label,input,button{display:block;width:100%;box-sizing:border-box;margin-bottom:.75rem}
+input,button{padding:.5rem}
+.error{color:#b00020}
+</style>
+</head>
+<body>
+<h1>Sign in</h1>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="/authorize">
+<input type="hidden" name="client_id" value="{{.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.RedirectURI}}">
+<input type="hidden" name="response_type" value="{{.ResponseType}}">
+<input type="hidden" name="scope" value="{{.Scope}}">
+<input type="hidden" name="state" value="{{.State}}">
+<input type="hidden" name="nonce" value="{{.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.CodeChallengeMethod}}">
+<input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+{{if .MFAToken}}
+<input type="hidden" name="mfa_token" value="{{.MFAToken}}">
+<label for="mfa_code">Authentication or recovery code</label>
+<input id="mfa_code" name="mfa_code" autocomplete="one-time-code" required autofocus>
+{{else}}
+<label for="email">Email</label>
+<input id="email" name="email" type="email" value="{{.Email}}" autocomplete="username" required autofocus>
+<label for="password">Password</label>
+<input id="password" name="password" type="password" autocomplete="current-password" required>
+{{end}}
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+func renderPage(w http.ResponseWriter, log *slog.Logger, status int, p page) {
+	setPageHeaders(w)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := pageTemplate.Execute(w, p); err != nil {
+		log.Error("failed to render authorize page", sl.Err(err))
+	}
+}
+
+// renderError shows an error that must not be sent to the redirect URI because
+// the client or redirect URI couldn't be verified.
+func renderError(w http.ResponseWriter, status int, msg string) {
+	setPageHeaders(w)
+	http.Error(w, msg, status)
+}
+
+// setPageHeaders keeps the sign-in page out of frames and caches.
+func setPageHeaders(w http.ResponseWriter) {
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+}
@@ -0,0 +1,149 @@
+package oauth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/services/auth"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type TokenIssuer interface {
+	ExchangeAuthorizationCode(ctx context.Context, code string, appID int32, redirectURI string, codeVerifier string) (tokens models.TokenPair, scope string, err error)
+	ClientCredentials(ctx context.Context, appID int32, secret string, scopes []string) (tokens models.TokenPair, granted []string, err error)
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// NewToken serves the token endpoint (RFC 6749 3.2). For authorization_code clients
+// are public and prove possession of the code with the PKCE code_verifier; for
+// client_credentials they authenticate with the app secret, via HTTP Basic or the form.
+func NewToken(log *slog.Logger, issuer TokenIssuer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http.oauth.Token"
+		log := log.With(slog.String("op", op))
+
+		if err := r.ParseForm(); err != nil {
+			writeTokenError(w, log, http.StatusBadRequest, "invalid_request", "malformed form body")
+			return
+		}
+		switch grantType := r.PostForm.Get("grant_type"); grantType {
+		case "authorization_code":
+			exchangeCode(w, r, log, issuer)
+		case "client_credentials":
+			clientCredentials(w, r, log, issuer)
+		case "":
+			writeTokenError(w, log, http.StatusBadRequest, "invalid_request", "grant_type is required")
+		default:
+			writeTokenError(w, log, http.StatusBadRequest, "unsupported_grant_type", "")
+		}
+	}
+}
+
+func exchangeCode(w http.ResponseWriter, r *http.Request, log *slog.Logger, issuer TokenIssuer) {
+	appID, err := strconv.ParseInt(r.PostForm.Get("client_id"), 10, 32)
+	if err != nil {
+		writeTokenError(w, log, http.StatusUnauthorized, "invalid_client", "client_id is required")
+		return
+	}
+	code := r.PostForm.Get("code")
+	codeVerifier := r.PostForm.Get("code_verifier")
+	if code == "" || codeVerifier == "" {
+		writeTokenError(w, log, http.StatusBadRequest, "invalid_request", "code and code_verifier are required")
+		return
+	}
+	tokens, scope, err := issuer.ExchangeAuthorizationCode(r.Context(), code, int32(appID), r.PostForm.Get("redirect_uri"), codeVerifier)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidGrant) {
+			writeTokenError(w, log, http.StatusBadRequest, "invalid_grant", "")
+			return
+		}
+		log.Error("failed to exchange authorization code", sl.Err(err))
+		writeTokenError(w, log, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+	writeJSON(w, log, http.StatusOK, tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		IDToken:      tokens.IDToken,
+		Scope:        scope,
+	})
+}
+
+func clientCredentials(w http.ResponseWriter, r *http.Request, log *slog.Logger, issuer TokenIssuer) {
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		// RFC 6749 2.3.1: both are form-urlencoded before being put into the header.
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	appID, err := strconv.ParseInt(clientID, 10, 32)
+	if err != nil || secret == "" {
+		writeClientError(w, log, basic)
+		return
+	}
+	tokens, scopes, err := issuer.ClientCredentials(r.Context(), int32(appID), secret, strings.Fields(r.PostForm.Get("scope")))
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidClient):
+			writeClientError(w, log, basic)
+		case errors.Is(err, auth.ErrInvalidScope):
+			writeTokenError(w, log, http.StatusBadRequest, "invalid_scope", "")
+		default:
+			log.Error("failed to issue client token", sl.Err(err))
+			writeTokenError(w, log, http.StatusInternalServerError, "server_error", "")
+		}
+		return
+	}
+	writeJSON(w, log, http.StatusOK, tokenResponse{
+		AccessToken: tokens.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(tokens.ExpiresIn.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	})
+}
+
+// writeClientError answers a failed client authentication. Clients that used
+// HTTP Basic get the challenge RFC 6749 5.2 asks for.
+func writeClientError(w http.ResponseWriter, log *slog.Logger, basic bool) {
+	if basic {
+		w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+	}
+	writeTokenError(w, log, http.StatusUnauthorized, "invalid_client", "")
+}
+
+func writeTokenError(w http.ResponseWriter, log *slog.Logger, status int, code string, description string) {
+	writeJSON(w, log, status, errorResponse{Error: code, ErrorDescription: description})
+}
+
+func writeJSON(w http.ResponseWriter, log *slog.Logger, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Error("failed to write response", sl.Err(err))
+	}
+}
@@ -0,0 +1,56 @@
+package oidc
+
+import (
+	"AuthGRPC/internal/lib/jwt"
+	"AuthGRPC/internal/lib/logger/sl"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// providerMetadata is the OpenID Provider Metadata (OpenID Connect Discovery 1.0, section 3).
+type providerMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// NewDiscovery serves the OpenID Connect discovery document. issuer must match
+// the iss claim of issued tokens exactly.
+func NewDiscovery(log *slog.Logger, issuer string) http.HandlerFunc {
+	metadata := providerMetadata{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/authorize",
+		TokenEndpoint:                     issuer + "/token",
+		UserInfoEndpoint:                  issuer + "/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   []string{"openid", "email"},
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "client_credentials"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{jwt.AlgRS256, jwt.AlgES256, jwt.AlgEdDSA, jwt.AlgHS256},
+		TokenEndpointAuthMethodsSupported: []string{"none", "client_secret_basic", "client_secret_post"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "email", "email_verified"},
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http.oidc.Discovery"
+		log := log.With(slog.String("op", op))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if err := json.NewEncoder(w).Encode(metadata); err != nil {
+			log.Error("failed to write discovery document", sl.Err(err))
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package oidc
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/services/auth"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type UserInfoProvider interface {
+	UserInfo(ctx context.Context, accessToken string) (user models.User, err error)
+}
+
+type userInfo struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// NewUserInfo serves the claims of the access token's owner (OpenID Connect Core 1.0, section 5.3).
+// The token is taken from the Authorization header only.
+func NewUserInfo(log *slog.Logger, provider UserInfoProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "http.oidc.UserInfo"
+		log := log.With(slog.String("op", op))
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+		user, err := provider.UserInfo(r.Context(), token)
+		if err != nil {
+			if isTokenError(err) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			log.Error("failed to get user info", sl.Err(err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		err = json.NewEncoder(w).Encode(userInfo{
+			Sub:           strconv.FormatInt(user.ID, 10),
+			Email:         user.Email,
+			EmailVerified: user.EmailVerified,
+		})
+		if err != nil {
+			log.Error("failed to write user info", sl.Err(err))
+		}
+	}
+}
+
+func isTokenError(err error) bool {
+	for _, tokenErr := range []error{
+		auth.ErrTokenMalformed,
+		auth.ErrTokenExpired,
+		auth.ErrTokenSignature,
+		auth.ErrTokenRevoked,
+		auth.ErrInvalidAppId,
+		auth.ErrClientToken,
+	} {
+		if errors.Is(err, tokenErr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package aesgcm
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/hkdf"
+	"io"
+)
+
+var (
+	ErrInvalidKey        = errors.New("key must be 16, 24 or 32 bytes long")
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+)
+
+// Cipher encrypts small secrets for storage with AES-GCM. Every ciphertext
+// carries its own random nonce as a prefix. Secrets that only have to be
+// recognized again are MACed with a key derived from the same one.
+type Cipher struct {
+	aead   cipher.AEAD
+	macKey []byte
+}
+
+func New(key []byte) (*Cipher, error) {
+	const op = "aesgcm.New"
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidKey)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	macKey := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte("aesgcm mac")), macKey); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &Cipher{aead: aead, macKey: macKey}, nil
+}
+
+func (c *Cipher) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (c *Cipher) Open(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < c.aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, sealed := ciphertext[:c.aead.NonceSize()], ciphertext[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+// MAC returns a keyed hash of the message. It is fast, unlike a password hash,
+// which is fine for random secrets: without the key they can't be guessed
+// offline at all.
+func (c *Cipher) MAC(message []byte) []byte {
+	mac := hmac.New(sha256.New, c.macKey)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
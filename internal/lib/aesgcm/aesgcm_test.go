@@ -0,0 +1,37 @@
+package aesgcm
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCipher_SealOpen(t *testing.T) {
+	c, err := New(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	sealed, err := c.Seal([]byte("secret"))
+	require.NoError(t, err)
+	opened, err := c.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), opened)
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = c.Open(sealed)
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+	_, err = New([]byte("short"))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestCipher_MAC(t *testing.T) {
+	c, err := New(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	other, err := New(bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+
+	mac := c.MAC([]byte("1:abcde12345"))
+	assert.Len(t, mac, 32)
+	assert.Equal(t, mac, c.MAC([]byte("1:abcde12345")))
+	assert.NotEqual(t, mac, c.MAC([]byte("2:abcde12345")))
+	assert.NotEqual(t, mac, other.MAC([]byte("1:abcde12345")), "the MAC depends on the key")
+}
@@ -0,0 +1,112 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+type Argon2Params struct {
+	// Memory is in KiB.
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+// Bounds of Argon2Params. argon2.IDKey panics on zero time or threads, and the
+// upper bounds keep a config typo or a crafted hash from making a login allocate
+// gigabytes or run for minutes.
+const (
+	argon2MaxMemory  = 4 << 20 // 4 GiB
+	argon2MaxTime    = 64
+	argon2MinKeyLen  = 16
+	argon2MinSaltLen = 8
+	argon2MaxLen     = 1024
+)
+
+func (p Argon2Params) validate() error {
+	switch {
+	case p.Threads < 1:
+		return fmt.Errorf("argon2 threads must be at least 1, got %d", p.Threads)
+	case p.Time < 1 || p.Time > argon2MaxTime:
+		return fmt.Errorf("argon2 time must be between 1 and %d, got %d", argon2MaxTime, p.Time)
+	case p.Memory < 8*uint32(p.Threads) || p.Memory > argon2MaxMemory:
+		return fmt.Errorf("argon2 memory must be between 8 KiB per thread and %d KiB, got %d", argon2MaxMemory, p.Memory)
+	case p.KeyLen < argon2MinKeyLen || p.KeyLen > argon2MaxLen:
+		return fmt.Errorf("argon2 key length must be between %d and %d, got %d", argon2MinKeyLen, argon2MaxLen, p.KeyLen)
+	case p.SaltLen < argon2MinSaltLen || p.SaltLen > argon2MaxLen:
+		return fmt.Errorf("argon2 salt length must be between %d and %d, got %d", argon2MinSaltLen, argon2MaxLen, p.SaltLen)
+	}
+	return nil
+}
+
+func (p Argon2Params) weakerThan(other Argon2Params) bool {
+	return p.Memory < other.Memory ||
+		p.Time < other.Time ||
+		p.Threads < other.Threads ||
+		p.KeyLen < other.KeyLen ||
+		p.SaltLen < other.SaltLen
+}
+
+// hashArgon2id produces a PHC string:
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>
+func hashArgon2id(password string, params Argon2Params) ([]byte, error) {
+	salt := make([]byte, params.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+	encoded := fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		AlgArgon2id,
+		argon2.Version,
+		params.Memory,
+		params.Time,
+		params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+	return []byte(encoded), nil
+}
+
+func compareArgon2id(hash []byte, password string) (Argon2Params, error) {
+	params, salt, key, err := decodeArgon2id(string(hash))
+	if err != nil {
+		return Argon2Params{}, err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, params.KeyLen)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return Argon2Params{}, ErrMismatch
+	}
+	return params, nil
+}
+
+func decodeArgon2id(encoded string) (params Argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgArgon2id {
+		return Argon2Params{}, nil, nil, ErrUnsupportedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, ErrUnsupportedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return Argon2Params{}, nil, nil, ErrUnsupportedHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return Argon2Params{}, nil, nil, ErrUnsupportedHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return Argon2Params{}, nil, nil, ErrUnsupportedHash
+	}
+	params.SaltLen = uint32(len(salt))
+	params.KeyLen = uint32(len(key))
+	if params.validate() != nil {
+		return Argon2Params{}, nil, nil, ErrUnsupportedHash
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,25 @@
+package hasher
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func validBcryptCost(cost int) bool {
+	return cost >= bcrypt.MinCost && cost <= bcrypt.MaxCost
+}
+
+func hashBcrypt(password string, cost int) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), cost)
+}
+
+func compareBcrypt(hash []byte, password string) (cost int, err error) {
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return 0, ErrMismatch
+		}
+		return 0, err
+	}
+	return bcrypt.Cost(hash)
+}
@@ -0,0 +1,84 @@
+package hasher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	AlgBcrypt   = "bcrypt"
+	AlgArgon2id = "argon2id"
+)
+
+var (
+	ErrMismatch        = errors.New("password does not match hash")
+	ErrUnsupportedAlg  = errors.New("unsupported password hashing algorithm")
+	ErrUnsupportedHash = errors.New("unsupported password hash format")
+	ErrInvalidParams   = errors.New("invalid password hashing parameters")
+)
+
+// Hasher hashes new passwords with the configured algorithm and verifies
+// hashes made by any supported one, so the algorithm can change over time.
+type Hasher struct {
+	alg        string
+	bcryptCost int
+	argon2     Argon2Params
+}
+
+// New checks the parameters of alg up front, since argon2 panics on some of
+// them and bcrypt silently falls back to its default cost on others.
+func New(alg string, bcryptCost int, argon2 Argon2Params) (*Hasher, error) {
+	switch alg {
+	case AlgBcrypt:
+		if !validBcryptCost(bcryptCost) {
+			return nil, fmt.Errorf("%w: bcrypt cost %d", ErrInvalidParams, bcryptCost)
+		}
+	case AlgArgon2id:
+		if err := argon2.validate(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidParams, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+	}
+	return &Hasher{
+		alg:        alg,
+		bcryptCost: bcryptCost,
+		argon2:     argon2,
+	}, nil
+}
+
+func (h *Hasher) Hash(password string) ([]byte, error) {
+	if h.alg == AlgArgon2id {
+		return hashArgon2id(password, h.argon2)
+	}
+	return hashBcrypt(password, h.bcryptCost)
+}
+
+// Compare checks the password against the hash. needsRehash reports whether the hash
+// was made with another algorithm or weaker parameters than the configured ones.
+func (h *Hasher) Compare(hash []byte, password string) (needsRehash bool, err error) {
+	switch {
+	case isArgon2id(hash):
+		params, err := compareArgon2id(hash, password)
+		if err != nil {
+			return false, err
+		}
+		return h.alg != AlgArgon2id || params.weakerThan(h.argon2), nil
+	case isBcrypt(hash):
+		cost, err := compareBcrypt(hash, password)
+		if err != nil {
+			return false, err
+		}
+		return h.alg != AlgBcrypt || cost < h.bcryptCost, nil
+	}
+	return false, ErrUnsupportedHash
+}
+
+func isBcrypt(hash []byte) bool {
+	return strings.HasPrefix(string(hash), "$2")
+}
+
+func isArgon2id(hash []byte) bool {
+	return strings.HasPrefix(string(hash), "$"+AlgArgon2id+"$")
+}
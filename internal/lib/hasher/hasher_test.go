@@ -0,0 +1,135 @@
+package hasher
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+)
+
+// fast keeps the tests quick; real configs use far more memory.
+var fast = Argon2Params{Memory: 64, Time: 2, Threads: 1, KeyLen: 16, SaltLen: 8}
+
+func with(change func(p *Argon2Params)) Argon2Params {
+	p := fast
+	change(&p)
+	return p
+}
+
+func mustNew(t *testing.T, alg string, bcryptCost int, params Argon2Params) *Hasher {
+	t.Helper()
+	h, err := New(alg, bcryptCost, params)
+	require.NoError(t, err)
+	return h
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name       string
+		alg        string
+		bcryptCost int
+		params     Argon2Params
+		wantErr    error
+	}{
+		{name: "argon2id", alg: AlgArgon2id, params: fast},
+		{name: "bcrypt ignores argon2 params", alg: AlgBcrypt, bcryptCost: bcrypt.MinCost},
+		{name: "unknown algorithm", alg: "md5", wantErr: ErrUnsupportedAlg},
+		{name: "zero threads", alg: AlgArgon2id, params: with(func(p *Argon2Params) { p.Threads = 0 }), wantErr: ErrInvalidParams},
+		{name: "zero time", alg: AlgArgon2id, params: with(func(p *Argon2Params) { p.Time = 0 }), wantErr: ErrInvalidParams},
+		{name: "absurd time", alg: AlgArgon2id, params: with(func(p *Argon2Params) { p.Time = 1000 }), wantErr: ErrInvalidParams},
+		{name: "too little memory per thread", alg: AlgArgon2id, params: with(func(p *Argon2Params) { p.Threads = 16 }), wantErr: ErrInvalidParams},
+		{name: "absurd memory", alg: AlgArgon2id, params: with(func(p *Argon2Params) { p.Memory = 1 << 30 }), wantErr: ErrInvalidParams},
+		{name: "zero key length", alg: AlgArgon2id, params: with(func(p *Argon2Params) { p.KeyLen = 0 }), wantErr: ErrInvalidParams},
+		{name: "zero salt length", alg: AlgArgon2id, params: with(func(p *Argon2Params) { p.SaltLen = 0 }), wantErr: ErrInvalidParams},
+		{name: "bcrypt cost too low", alg: AlgBcrypt, bcryptCost: bcrypt.MinCost - 1, wantErr: ErrInvalidParams},
+		{name: "bcrypt cost too high", alg: AlgBcrypt, bcryptCost: bcrypt.MaxCost + 1, wantErr: ErrInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.alg, tt.bcryptCost, tt.params)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestHasher_Compare(t *testing.T) {
+	const password = "correct horse battery staple"
+	argon2Hasher := mustNew(t, AlgArgon2id, bcrypt.MinCost, fast)
+	bcryptHasher := mustNew(t, AlgBcrypt, bcrypt.MinCost+1, fast)
+
+	tests := []struct {
+		name            string
+		hashedWith      *Hasher
+		comparedWith    *Hasher
+		password        string
+		wantNeedsRehash bool
+		wantErr         error
+	}{
+		{name: "argon2id with the same params", hashedWith: argon2Hasher, comparedWith: argon2Hasher},
+		{name: "argon2id with less time", hashedWith: mustNew(t, AlgArgon2id, 0, with(func(p *Argon2Params) { p.Time = 1 })), comparedWith: argon2Hasher, wantNeedsRehash: true},
+		{name: "argon2id with less memory", hashedWith: mustNew(t, AlgArgon2id, 0, with(func(p *Argon2Params) { p.Memory = 32 })), comparedWith: argon2Hasher, wantNeedsRehash: true},
+		{name: "argon2id with a shorter key", hashedWith: mustNew(t, AlgArgon2id, 0, with(func(p *Argon2Params) { p.KeyLen = 16 })), comparedWith: mustNew(t, AlgArgon2id, 0, with(func(p *Argon2Params) { p.KeyLen = 32 })), wantNeedsRehash: true},
+		{name: "argon2id with stronger params", hashedWith: mustNew(t, AlgArgon2id, 0, with(func(p *Argon2Params) { p.Time = 3 })), comparedWith: argon2Hasher},
+		{name: "bcrypt with the same cost", hashedWith: bcryptHasher, comparedWith: bcryptHasher},
+		{name: "bcrypt with a lower cost", hashedWith: mustNew(t, AlgBcrypt, bcrypt.MinCost, fast), comparedWith: bcryptHasher, wantNeedsRehash: true},
+		{name: "bcrypt with a higher cost", hashedWith: bcryptHasher, comparedWith: mustNew(t, AlgBcrypt, bcrypt.MinCost, fast)},
+		{name: "bcrypt hash after switching to argon2id", hashedWith: bcryptHasher, comparedWith: argon2Hasher, wantNeedsRehash: true},
+		{name: "argon2id hash after switching to bcrypt", hashedWith: argon2Hasher, comparedWith: bcryptHasher, wantNeedsRehash: true},
+		{name: "wrong password for argon2id", hashedWith: argon2Hasher, comparedWith: argon2Hasher, password: "wrong", wantErr: ErrMismatch},
+		{name: "wrong password for bcrypt", hashedWith: bcryptHasher, comparedWith: argon2Hasher, password: "wrong", wantErr: ErrMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hashedWith.Hash(password)
+			require.NoError(t, err)
+			if tt.password == "" {
+				tt.password = password
+			}
+			needsRehash, err := tt.comparedWith.Compare(hash, tt.password)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantNeedsRehash, needsRehash)
+		})
+	}
+
+	_, err := argon2Hasher.Compare([]byte("plaintext"), password)
+	assert.ErrorIs(t, err, ErrUnsupportedHash)
+}
+
+func TestDecodeArgon2id(t *testing.T) {
+	const (
+		salt = "c2FsdHNhbHQ"                      // "saltsalt"
+		key  = "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5" // 24 bytes
+	)
+	phc := func(alg string, version int, params string, salt string, key string) string {
+		return fmt.Sprintf("$%s$v=%d$%s$%s$%s", alg, version, params, salt, key)
+	}
+
+	params, decodedSalt, decodedKey, err := decodeArgon2id(phc(AlgArgon2id, 19, "m=64,t=2,p=1", salt, key))
+	require.NoError(t, err)
+	assert.Equal(t, Argon2Params{Memory: 64, Time: 2, Threads: 1, KeyLen: 24, SaltLen: 8}, params)
+	assert.Equal(t, []byte("saltsalt"), decodedSalt)
+	assert.Len(t, decodedKey, 24)
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "argon2i", encoded: phc("argon2i", 19, "m=64,t=2,p=1", salt, key)},
+		{name: "old version", encoded: phc(AlgArgon2id, 16, "m=64,t=2,p=1", salt, key)},
+		{name: "zero threads", encoded: phc(AlgArgon2id, 19, "m=64,t=2,p=0", salt, key)},
+		{name: "zero time", encoded: phc(AlgArgon2id, 19, "m=64,t=0,p=1", salt, key)},
+		{name: "threads overflow", encoded: phc(AlgArgon2id, 19, "m=64,t=2,p=256", salt, key)},
+		{name: "absurd memory", encoded: phc(AlgArgon2id, 19, "m=1073741824,t=2,p=1", salt, key)},
+		{name: "garbled params", encoded: phc(AlgArgon2id, 19, "t=2,m=64,p=1", salt, key)},
+		{name: "bad salt", encoded: phc(AlgArgon2id, 19, "m=64,t=2,p=1", "!!!", key)},
+		{name: "empty key", encoded: phc(AlgArgon2id, 19, "m=64,t=2,p=1", salt, "")},
+		{name: "missing part", encoded: "$argon2id$v=19$m=64,t=2,p=1$" + salt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := decodeArgon2id(tt.encoded)
+			assert.ErrorIs(t, err, ErrUnsupportedHash)
+		})
+	}
+}
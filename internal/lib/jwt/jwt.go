@@ -2,21 +2,231 @@ package jwt
 
 import (
 	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/opaque"
+	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const tokenUseClient = "client"
+
+var (
+	ErrTokenMalformed        = errors.New("token is malformed")
+	ErrTokenExpired          = errors.New("token is expired")
+	ErrTokenSignatureInvalid = errors.New("token signature is invalid")
+)
+
 // NewToken TODO: Test
-func NewToken(user models.User, app models.App, duration time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+// sub, aud and iss are the standard claims for uid and appid, which are kept
+// for existing consumers. org is the user's org. roles and permissions are the
+// user's roles in the app and the permissions they grant; the claims are left
+// out when empty.
+func NewToken(user models.User, app models.App, key models.SigningKey, issuer string, roles []string, permissions []string, duration time.Duration) (string, error) {
+	jti, err := opaque.New()
+	if err != nil {
+		return "", err
+	}
+	// iat has millisecond precision, so tokens issued right after a user's tokens
+	// were revoked aren't mistaken for revoked ones.
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"jti":   jti,
+		"iss":   issuer,
+		"sub":   strconv.FormatInt(user.ID, 10),
+		"aud":   strconv.Itoa(app.ID),
 		"uid":   user.ID,
-		"exp":   time.Now().Add(duration).Unix(),
+		"iat":   float64(now.UnixMilli()) / 1e3,
+		"exp":   now.Add(duration).Unix(),
 		"appid": app.ID,
 		"email": user.Email,
+		"org":   user.OrgID,
+	}
+	if len(roles) > 0 {
+		claims["roles"] = roles
+	}
+	if len(permissions) > 0 {
+		claims["permissions"] = permissions
+	}
+	return sign(key, claims)
+}
+
+// NewIDToken mints an OpenID Connect ID token for the app. It carries no appid
+// claim, so it can't be passed off as an access token.
+func NewIDToken(user models.User, app models.App, key models.SigningKey, issuer string, nonce string, authTime time.Time, duration time.Duration) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            issuer,
+		"sub":            strconv.FormatInt(user.ID, 10),
+		"aud":            strconv.Itoa(app.ID),
+		"iat":            now.Unix(),
+		"exp":            now.Add(duration).Unix(),
+		"auth_time":      authTime.Unix(),
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"org":            user.OrgID,
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	return sign(key, claims)
+}
+
+// NewClientToken mints an access token for the app itself. Its sub is "app:<id>"
+// and token_use is "client", so it can't be confused with a user's token.
+func NewClientToken(app models.App, key models.SigningKey, issuer string, scopes []string, duration time.Duration) (string, error) {
+	jti, err := opaque.New()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	return sign(key, jwt.MapClaims{
+		"jti":       jti,
+		"iss":       issuer,
+		"sub":       ClientSubject(app.ID),
+		"aud":       strconv.Itoa(app.ID),
+		"client_id": strconv.Itoa(app.ID),
+		"token_use": tokenUseClient,
+		"scope":     strings.Join(scopes, " "),
+		"iat":       float64(now.UnixMilli()) / 1e3,
+		"exp":       now.Add(duration).Unix(),
+		"appid":     app.ID,
+		"org":       app.OrgID,
 	})
-	tokenString, err := token.SignedString([]byte(app.Secret))
+}
+
+// ClientSubject is the sub claim of client tokens.
+func ClientSubject(appID int) string {
+	return "app:" + strconv.Itoa(appID)
+}
+
+func sign(key models.SigningKey, claims jwt.MapClaims) (string, error) {
+	method, err := signingMethod(key.Alg)
+	if err != nil {
+		return "", err
+	}
+	signWith, err := signingKey(key)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	tokenString, err := token.SignedString(signWith)
 	if err != nil {
 		return "", err
 	}
 	return tokenString, nil
 }
+
+// AppID returns the appid claim without verifying the token, so the caller
+// can look up the app whose key has to be used for Parse.
+func AppID(tokenString string) (int, error) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil {
+		return 0, ErrTokenMalformed
+	}
+	appID, ok := claims["appid"].(float64)
+	if !ok {
+		return 0, ErrTokenMalformed
+	}
+	return int(appID), nil
+}
+
+// Parse verifies the token signature and expiry. The token is checked with the key
+// whose ID matches its kid header. Tokens signed with an app secret have no kid;
+// they are only accepted when alg, the signing algorithm of the app, is HS256.
+func Parse(tokenString string, alg string, keys []models.SigningKey) (models.TokenClaims, error) {
+	claims := jwt.MapClaims{}
+	var kid string
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ = token.Header["kid"].(string)
+		if kid == "" && alg != AlgHS256 {
+			return nil, ErrTokenSignatureInvalid
+		}
+		for _, key := range keys {
+			if key.ID == kid {
+				if key.Alg != token.Method.Alg() {
+					return nil, ErrTokenSignatureInvalid
+				}
+				return verificationKey(key)
+			}
+		}
+		return nil, ErrTokenSignatureInvalid
+	}, jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgES256, AlgEdDSA}), jwt.WithExpirationRequired())
+	if err != nil {
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
+			return models.TokenClaims{}, ErrTokenExpired
+		case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+			return models.TokenClaims{}, ErrTokenSignatureInvalid
+		default:
+			return models.TokenClaims{}, fmt.Errorf("%w: %s", ErrTokenMalformed, err.Error())
+		}
+	}
+	tokenClaims, err := claimsFromMap(claims)
+	if err != nil {
+		return models.TokenClaims{}, err
+	}
+	tokenClaims.KeyID = kid
+	return tokenClaims, nil
+}
+
+func claimsFromMap(claims jwt.MapClaims) (models.TokenClaims, error) {
+	appID, okApp := claims["appid"].(float64)
+	exp, err := claims.GetExpirationTime()
+	if !okApp || err != nil || exp == nil {
+		return models.TokenClaims{}, ErrTokenMalformed
+	}
+	// Tokens issued before jti was introduced don't carry one and can't be revoked.
+	jti, _ := claims["jti"].(string)
+	// GetIssuedAt would truncate iat to whole seconds.
+	var issuedAt time.Time
+	if iat, ok := claims["iat"].(float64); ok {
+		issuedAt = time.UnixMilli(int64(math.Round(iat * 1e3)))
+	}
+	// Tokens issued before orgs were introduced belong to the default org.
+	orgID := models.DefaultOrgID
+	if org, ok := claims["org"].(float64); ok {
+		orgID = int64(org)
+	}
+	tokenClaims := models.TokenClaims{
+		ID:        jti,
+		OrgID:     orgID,
+		AppID:     int(appID),
+		IssuedAt:  issuedAt,
+		ExpiresAt: exp.Time,
+	}
+	if tokenUse, _ := claims["token_use"].(string); tokenUse == tokenUseClient {
+		scope, _ := claims["scope"].(string)
+		tokenClaims.Client = true
+		tokenClaims.Scopes = strings.Fields(scope)
+		return tokenClaims, nil
+	}
+	uid, okUID := claims["uid"].(float64)
+	email, okEmail := claims["email"].(string)
+	if !okUID || !okEmail {
+		return models.TokenClaims{}, ErrTokenMalformed
+	}
+	tokenClaims.UserID = int64(uid)
+	tokenClaims.Email = email
+	tokenClaims.Roles = stringsClaim(claims["roles"])
+	tokenClaims.Permissions = stringsClaim(claims["permissions"])
+	return tokenClaims, nil
+}
+
+// stringsClaim reads a claim holding a JSON array of strings, skipping other values.
+func stringsClaim(claim any) []string {
+	values, _ := claim.([]any)
+	var strs []string
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
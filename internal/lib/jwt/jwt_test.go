@@ -0,0 +1,39 @@
+package jwt
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	app := models.App{ID: 2, Secret: "rs256-app-secret", SigningAlg: AlgRS256}
+	secret, err := GenerateKey(AlgRS256)
+	require.NoError(t, err)
+	managed := models.SigningKey{ID: "kid-1", AppID: app.ID, Alg: AlgRS256, Key: secret, Status: models.KeyStatusActive}
+	legacy := models.SigningKey{AppID: app.ID, Alg: AlgHS256, Key: []byte(app.Secret)}
+	user := models.User{ID: 7, Email: "user@example.com"}
+
+	token, err := NewToken(user, app, managed, "issuer", nil, nil, time.Hour)
+	require.NoError(t, err)
+	claims, err := Parse(token, AlgRS256, []models.SigningKey{managed})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"uid":   user.ID,
+		"email": user.Email,
+		"appid": app.ID,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(app.Secret))
+	require.NoError(t, err)
+	_, err = Parse(forged, AlgRS256, []models.SigningKey{managed, legacy})
+	assert.ErrorIs(t, err, ErrTokenSignatureInvalid)
+
+	claims, err = Parse(forged, AlgHS256, []models.SigningKey{legacy})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+}
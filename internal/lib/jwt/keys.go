@@ -0,0 +1,159 @@
+package jwt
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+
+	rsaKeyBits  = 2048
+	hmacKeySize = 32
+)
+
+var ErrUnsupportedAlg = errors.New("unsupported signing algorithm")
+
+// JWK is the public part of a signing key as published in a JWKS document (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+func IsAsymmetric(alg string) bool {
+	switch alg {
+	case AlgRS256, AlgES256, AlgEdDSA:
+		return true
+	}
+	return false
+}
+
+// GenerateKey creates a random HS256 secret or, for asymmetric algorithms,
+// a PKCS #8 DER encoded private key.
+func GenerateKey(alg string) ([]byte, error) {
+	var key crypto.Signer
+	var err error
+	switch alg {
+	case AlgHS256:
+		secret := make([]byte, hmacKeySize)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		return secret, nil
+	case AlgRS256:
+		key, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return x509.MarshalPKCS8PrivateKey(key)
+}
+
+// PublicJWK converts an asymmetric signing key into its public JWK.
+func PublicJWK(key models.SigningKey) (JWK, error) {
+	signer, err := privateKey(key)
+	if err != nil {
+		return JWK{}, err
+	}
+	jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Alg}
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64(pub.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = b64(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = b64(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = b64(pub)
+	default:
+		return JWK{}, fmt.Errorf("%w: %s", ErrUnsupportedAlg, key.Alg)
+	}
+	return jwk, nil
+}
+
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case AlgHS256:
+		return jwt.SigningMethodHS256, nil
+	case AlgRS256:
+		return jwt.SigningMethodRS256, nil
+	case AlgES256:
+		return jwt.SigningMethodES256, nil
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+}
+
+// signingKey returns the value the jwt library expects for signing with key.
+func signingKey(key models.SigningKey) (interface{}, error) {
+	if key.Alg == AlgHS256 {
+		return key.Key, nil
+	}
+	return privateKey(key)
+}
+
+// verificationKey returns the value the jwt library expects for verifying with key.
+func verificationKey(key models.SigningKey) (interface{}, error) {
+	if key.Alg == AlgHS256 {
+		return key.Key, nil
+	}
+	signer, err := privateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Public(), nil
+}
+
+func privateKey(key models.SigningKey) (crypto.Signer, error) {
+	if !IsAsymmetric(key.Alg) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, key.Alg)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(key.Key)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, key.Alg)
+	}
+	return signer, nil
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Writer "sends" messages by writing them to w, e.g. stdout or a file.
+// It is meant for local development and tests.
+type Writer struct {
+	mu   sync.Mutex
+	from string
+	w    io.Writer
+}
+
+func NewWriter(from string, w io.Writer) *Writer {
+	return &Writer{from: from, w: w}
+}
+
+func (m *Writer) Send(_ context.Context, msg Message) error {
+	const op = "mailer.Writer.Send"
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.w.Write(format(m.from, msg, time.Now())); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// format renders msg as a plain text RFC 5322 message with CRLF line endings.
+func format(from string, msg Message, date time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,134 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP accepts a single session and records the envelope and data it received.
+type fakeSMTP struct {
+	lis      net.Listener
+	received chan fakeMail
+}
+
+type fakeMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = lis.Close() })
+	s := &fakeSMTP{lis: lis, received: make(chan fakeMail, 1)}
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTP) serve() {
+	conn, err := s.lis.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	var mail fakeMail
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			mail.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			mail.to = append(mail.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			mail.data = data.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			s.received <- mail
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTP_Send(t *testing.T) {
+	srv := newFakeSMTP(t)
+	host, port, err := net.SplitHostPort(srv.lis.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	m := NewSMTP(host, portNum, "", "", "sso@example.com", 5*time.Second)
+	err = m.Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Verify your email",
+		Body:    "line one\nline two",
+	})
+	require.NoError(t, err)
+
+	select {
+	case mail := <-srv.received:
+		assert.Equal(t, "sso@example.com", mail.from)
+		assert.Equal(t, []string{"user@example.com"}, mail.to)
+		assert.Contains(t, mail.data, "To: user@example.com\r\n")
+		assert.Contains(t, mail.data, "Subject: Verify your email\r\n")
+		assert.Contains(t, mail.data, "\r\n\r\nline one\r\nline two\r\n")
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake smtp server received nothing")
+	}
+}
+
+func TestSMTP_SendRejected(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("554 no service\r\n"))
+	}()
+	host, port, _ := net.SplitHostPort(lis.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+
+	m := NewSMTP(host, portNum, "", "", "sso@example.com", 5*time.Second)
+	err = m.Send(context.Background(), Message{To: "user@example.com", Subject: "s", Body: "b"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package policy
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"errors"
+	"fmt"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"slices"
+	"sync"
+)
+
+// costLimit bounds the work a single condition may do, so a policy can't stall
+// the server with e.g. nested comprehensions over large lists.
+const costLimit = 100_000
+
+var ErrInvalidRule = errors.New("invalid policy rule")
+
+// Input holds the variables a condition sees.
+type Input struct {
+	Subject  map[string]any
+	Action   string
+	Resource map[string]any
+	Context  map[string]any
+}
+
+// Engine compiles and evaluates policies whose rule conditions are CEL
+// expressions (https://github.com/google/cel-spec). Compiled policies are
+// cached per app and only reused while the rules are the same, so a new
+// version, or a new app that got an id of a deleted one, recompiles.
+type Engine struct {
+	env *cel.Env
+
+	mu       sync.Mutex
+	compiled map[int]compiledPolicy
+}
+
+type compiledPolicy struct {
+	rules    []models.PolicyRule
+	programs []cel.Program
+}
+
+func New() (*Engine, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("subject", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("action", cel.StringType),
+		cel.Variable("resource", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("context", cel.MapType(cel.StringType, cel.DynType)),
+		// Resource and context attributes arrive as JSON numbers, i.e. doubles,
+		// and have to compare with integer subject attributes.
+		cel.CrossTypeNumericComparisons(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{env: env, compiled: make(map[int]compiledPolicy)}, nil
+}
+
+// Check compiles every rule and fails with ErrInvalidRule for the first one
+// whose condition doesn't compile or can't evaluate to a bool.
+func (e *Engine) Check(rules []models.PolicyRule) error {
+	for _, rule := range rules {
+		if _, err := e.compile(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Evaluate runs the rules in order and the first one whose condition holds decides.
+// If no rule holds, or a condition fails to evaluate or yields something other than
+// a bool, the request is denied with Reason set; a failing rule doesn't let later
+// rules decide.
+func (e *Engine) Evaluate(policy models.Policy, input Input) (models.Decision, error) {
+	programs, err := e.programs(policy)
+	if err != nil {
+		return models.Decision{}, err
+	}
+	vars := map[string]any{
+		"subject":  orEmpty(input.Subject),
+		"action":   input.Action,
+		"resource": orEmpty(input.Resource),
+		"context":  orEmpty(input.Context),
+	}
+	decision := models.Decision{PolicyVersion: policy.Version}
+	for i, rule := range policy.Rules {
+		out, _, err := programs[i].Eval(vars)
+		if err != nil {
+			decision.Reason = fmt.Sprintf("rule %q failed: %s", rule.Name, err)
+			return decision, nil
+		}
+		matched, ok := out.(types.Bool)
+		if !ok {
+			decision.Reason = fmt.Sprintf("rule %q did not evaluate to a bool", rule.Name)
+			return decision, nil
+		}
+		if matched {
+			decision.Allowed = rule.Effect == models.PolicyAllow
+			decision.Rule = rule.Name
+			return decision, nil
+		}
+	}
+	decision.Reason = "no rule matched"
+	return decision, nil
+}
+
+func (e *Engine) programs(policy models.Policy) ([]cel.Program, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if c, ok := e.compiled[policy.AppID]; ok && slices.Equal(c.rules, policy.Rules) {
+		return c.programs, nil
+	}
+	programs := make([]cel.Program, 0, len(policy.Rules))
+	for _, rule := range policy.Rules {
+		program, err := e.compile(rule)
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, program)
+	}
+	e.compiled[policy.AppID] = compiledPolicy{rules: slices.Clone(policy.Rules), programs: programs}
+	return programs, nil
+}
+
+func (e *Engine) compile(rule models.PolicyRule) (cel.Program, error) {
+	ast, issues := e.env.Compile(rule.Condition)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("%w: rule %q: %s", ErrInvalidRule, rule.Name, issues.Err())
+	}
+	if t := ast.OutputType(); !t.IsExactType(types.BoolType) && !t.IsExactType(types.DynType) {
+		return nil, fmt.Errorf("%w: rule %q: condition evaluates to %s, not bool", ErrInvalidRule, rule.Name, t)
+	}
+	program, err := e.env.Program(ast, cel.CostLimit(costLimit))
+	if err != nil {
+		return nil, fmt.Errorf("%w: rule %q: %s", ErrInvalidRule, rule.Name, err)
+	}
+	return program, nil
+}
+
+func orEmpty(m map[string]any) map[string]any {
+	if m == nil {
+		return map[string]any{}
+	}
+	return m
+}
@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestEvaluate(t *testing.T) {
+	engine, err := New()
+	require.NoError(t, err)
+	policy := models.Policy{AppID: 1, Version: 1, Rules: []models.PolicyRule{
+		{Name: "no-deletes", Effect: models.PolicyDeny, Condition: `action == "delete"`},
+		{Name: "own-tenant", Effect: models.PolicyAllow, Condition: `resource.tenant_id == subject.tenant_id && context.time.getHours() >= 9 && context.time.getHours() < 17`},
+	}}
+	require.NoError(t, engine.Check(policy.Rules))
+	workday := time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		input   Input
+		allowed bool
+		rule    string
+		reason  string
+	}{
+		{
+			name:    "allow rule matches",
+			input:   Input{Subject: map[string]any{"tenant_id": int64(7)}, Action: "edit", Resource: map[string]any{"tenant_id": 7.0}, Context: map[string]any{"time": workday}},
+			allowed: true,
+			rule:    "own-tenant",
+		},
+		{
+			name:  "deny rule comes first",
+			input: Input{Subject: map[string]any{"tenant_id": int64(7)}, Action: "delete", Resource: map[string]any{"tenant_id": 7.0}, Context: map[string]any{"time": workday}},
+			rule:  "no-deletes",
+		},
+		{
+			name:   "no rule matches",
+			input:  Input{Subject: map[string]any{"tenant_id": int64(7)}, Action: "edit", Resource: map[string]any{"tenant_id": 8.0}, Context: map[string]any{"time": workday}},
+			reason: "no rule matched",
+		},
+		{
+			name:   "failing rule denies",
+			input:  Input{Subject: map[string]any{"tenant_id": int64(7)}, Action: "edit", Context: map[string]any{"time": workday}},
+			reason: `rule "own-tenant" failed`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := engine.Evaluate(policy, tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.allowed, decision.Allowed)
+			assert.Equal(t, tt.rule, decision.Rule)
+			assert.Contains(t, decision.Reason, tt.reason)
+			assert.Equal(t, 1, decision.PolicyVersion)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	engine, err := New()
+	require.NoError(t, err)
+	assert.NoError(t, engine.Check([]models.PolicyRule{{Name: "dyn", Condition: "resource.public"}}))
+	assert.ErrorIs(t, engine.Check([]models.PolicyRule{{Name: "syntax", Condition: "action =="}}), ErrInvalidRule)
+	assert.ErrorIs(t, engine.Check([]models.PolicyRule{{Name: "not bool", Condition: `action + "x"`}}), ErrInvalidRule)
+	assert.ErrorIs(t, engine.Check([]models.PolicyRule{{Name: "unknown var", Condition: "user.id == 1"}}), ErrInvalidRule)
+}
+
+func TestEvaluate_RecompilesChangedRules(t *testing.T) {
+	engine, err := New()
+	require.NoError(t, err)
+	allow := models.Policy{AppID: 1, Version: 1, Rules: []models.PolicyRule{{Name: "all", Effect: models.PolicyAllow, Condition: "true"}}}
+	decision, err := engine.Evaluate(allow, Input{})
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+
+	// An app created with the id of a deleted one starts again at version 1.
+	deny := models.Policy{AppID: 1, Version: 1, Rules: []models.PolicyRule{{Name: "none", Effect: models.PolicyAllow, Condition: "false"}}}
+	decision, err = engine.Evaluate(deny, Input{})
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+}
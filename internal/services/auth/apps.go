@@ -0,0 +1,351 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/jwt"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/opaque"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CreateApp registers an app and returns it together with its generated secret,
+// which can't be read again later. The app gets a managed signing key right away
+// and never accepts tokens signed with the secret, so its tokens never depend on
+// it. app.OrgID defaults to the admin's org.
+func (a *Auth) CreateApp(ctx context.Context, admin models.AdminScope, app models.App) (created models.App, secret string, err error) {
+	const op = "auth.CreateApp"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("name", app.Name))
+	log.Info("creating app")
+
+	if err := checkAppSettings(&app); err != nil {
+		log.Warn("invalid app settings", sl.Err(err))
+		return models.App{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	app.OrgID = ownOrg(admin, app.OrgID)
+	if err := a.checkOrg(ctx, log, admin, app.OrgID); err != nil {
+		return models.App{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	secret, err = opaque.New()
+	if err != nil {
+		log.Error("failed to generate secret", sl.Err(err))
+		return models.App{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	app.Secret = secret
+	app.ID, err = a.AppProvider.SaveApp(ctx, app)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppAlreadyExists) {
+			log.Warn("app already exists", sl.Err(err))
+			return models.App{}, "", fmt.Errorf("%s: %w", op, ErrAppExists)
+		}
+		log.Error("failed to save app", sl.Err(err))
+		return models.App{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := a.newSigningKey(ctx, app); err != nil {
+		log.Error("failed to generate signing key", sl.Err(err))
+		return models.App{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditAppCreated, Detail: appAuditDetail(app.ID)})
+	app.Secret = ""
+	return app, secret, nil
+}
+
+// GetApp returns the app with its redirect URIs but without its secret.
+func (a *Auth) GetApp(ctx context.Context, admin models.AdminScope, appID int32) (app models.App, err error) {
+	const op = "auth.GetApp"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)))
+
+	app, err = a.scopedApp(ctx, log, admin, appID)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+	app.RedirectURIs, err = a.oauthCodes.RedirectURIs(ctx, app.ID)
+	if err != nil {
+		log.Error("failed to get redirect uris", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+	app.Secret = ""
+	return app, nil
+}
+
+// ListApps returns every app the admin manages like GetApp does.
+func (a *Auth) ListApps(ctx context.Context, admin models.AdminScope) (apps []models.App, err error) {
+	const op = "auth.ListApps"
+	log := a.log.With(slog.String("op", op))
+
+	all, err := a.AppProvider.Apps(ctx)
+	if err != nil {
+		log.Error("failed to get apps", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	for _, app := range all {
+		if admin.CoversApp(app) {
+			apps = append(apps, app)
+		}
+	}
+	for i := range apps {
+		apps[i].RedirectURIs, err = a.oauthCodes.RedirectURIs(ctx, apps[i].ID)
+		if err != nil {
+			log.Error("failed to get redirect uris", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		apps[i].Secret = ""
+	}
+	return apps, nil
+}
+
+// UpdateApp replaces every setting of the app, including its redirect URIs.
+// Changing the signing algorithm takes effect with the next issued token;
+// tokens signed with the old key stay valid until they expire. The app stays in its org.
+func (a *Auth) UpdateApp(ctx context.Context, admin models.AdminScope, app models.App) (updated models.App, err error) {
+	const op = "auth.UpdateApp"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", app.ID))
+	log.Info("updating app")
+
+	if err := checkAppSettings(&app); err != nil {
+		log.Warn("invalid app settings", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+	current, err := a.scopedApp(ctx, log, admin, int32(app.ID))
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+	app.OrgID = current.OrgID
+	if err := a.AppProvider.UpdateApp(ctx, app); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrAppNotFound):
+			log.Warn("app not found", sl.Err(err))
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrInvalidAppId)
+		case errors.Is(err, storage.ErrAppAlreadyExists):
+			log.Warn("app name is taken", sl.Err(err))
+			return models.App{}, fmt.Errorf("%s: %w", op, ErrAppExists)
+		}
+		log.Error("failed to update app", sl.Err(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditAppUpdated, Detail: appAuditDetail(app.ID)})
+	return app, nil
+}
+
+// RotateAppSecret replaces the secret of the app and returns the new one, which
+// can't be read again later. An app that still signs with its secret is moved to
+// a managed signing key first; its tokens signed with the old secret stop validating.
+func (a *Auth) RotateAppSecret(ctx context.Context, admin models.AdminScope, appID int32) (secret string, err error) {
+	const op = "auth.RotateAppSecret"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)))
+	log.Info("rotating app secret")
+
+	app, err := a.scopedApp(ctx, log, admin, appID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	key, err := a.signingKey(ctx, app)
+	if err != nil {
+		log.Error("failed to get signing key", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if key.ID == "" {
+		if _, err := a.newSigningKey(ctx, app); err != nil {
+			log.Error("failed to generate signing key", sl.Err(err))
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	secret, err = opaque.New()
+	if err != nil {
+		log.Error("failed to generate secret", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.AppProvider.UpdateAppSecret(ctx, app.ID, secret); err != nil {
+		log.Error("failed to save secret", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditAppSecretRotated, Detail: appAuditDetail(app.ID)})
+	return secret, nil
+}
+
+// RotateSigningKey replaces the active signing key of the app right away, e.g.
+// after a leak, and returns the id of the new one. The previous key keeps
+// verifying the tokens it signed until they expire.
+func (a *Auth) RotateSigningKey(ctx context.Context, admin models.AdminScope, appID int32) (kid string, err error) {
+	const op = "auth.RotateSigningKey"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)))
+	log.Info("rotating signing key")
+
+	app, err := a.scopedApp(ctx, log, admin, appID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	key, err := a.newSigningKey(ctx, app)
+	if err != nil {
+		log.Error("failed to generate signing key", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditSigningKeyRotated, Detail: appAuditDetail(app.ID)})
+	return key.ID, nil
+}
+
+// DeleteApp removes the app with its keys and sessions. Its tokens stop validating at once.
+// Admins of the app itself can't delete it.
+func (a *Auth) DeleteApp(ctx context.Context, admin models.AdminScope, appID int32) error {
+	const op = "auth.DeleteApp"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)))
+	log.Info("deleting app")
+
+	if _, err := a.scopedApp(ctx, log, admin, appID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkManagesOrg(log, admin); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.AppProvider.DeleteApp(ctx, int(appID)); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrInvalidAppId)
+		}
+		log.Error("failed to delete app", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditAppDeleted, Detail: appAuditDetail(int(appID))})
+	return nil
+}
+
+// checkAppSettings validates the settings an admin may change and fills in defaults.
+func checkAppSettings(app *models.App) error {
+	app.Name = strings.TrimSpace(app.Name)
+	if app.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAppSettings)
+	}
+	if app.SigningAlg == "" {
+		app.SigningAlg = jwt.AlgHS256
+	}
+	switch app.SigningAlg {
+	case jwt.AlgHS256, jwt.AlgRS256, jwt.AlgES256, jwt.AlgEdDSA:
+	default:
+		return fmt.Errorf("%w: unsupported signing algorithm %q", ErrInvalidAppSettings, app.SigningAlg)
+	}
+	for _, scope := range app.ClientScopes {
+		if scope == "" || strings.ContainsAny(scope, " \t\r\n") {
+			return fmt.Errorf("%w: invalid client scope %q", ErrInvalidAppSettings, scope)
+		}
+	}
+	for _, redirectURI := range app.RedirectURIs {
+		// Redirect URIs must be absolute and carry no fragment (RFC 6749 3.1.2).
+		u, err := url.Parse(redirectURI)
+		if err != nil || u.Scheme == "" || u.Host == "" || u.Fragment != "" {
+			return fmt.Errorf("%w: invalid redirect uri %q", ErrInvalidAppSettings, redirectURI)
+		}
+	}
+	return nil
+}
+
+func appAuditDetail(appID int) string {
+	return "app_id=" + strconv.Itoa(appID)
+}
+
+// SetAppAdmin makes the user an admin of the app or takes it back. App admins
+// manage the app, its roles and its policy, but nothing else of their org.
+func (a *Auth) SetAppAdmin(ctx context.Context, admin models.AdminScope, userID int64, appID int32, isAdmin bool) error {
+	const op = "auth.SetAppAdmin"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID),
+		slog.Int("appID", int(appID)),
+		slog.Bool("isAdmin", isAdmin))
+	log.Info("changing app admin rights")
+
+	if err := checkManagesOrg(log, admin); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	user, err := a.scopedUser(ctx, log, admin, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	app, err := a.scopedApp(ctx, log, admin, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if user.OrgID != app.OrgID {
+		log.Warn("user belongs to another org", slog.Int64("userOrgID", user.OrgID), slog.Int64("appOrgID", app.OrgID))
+		return fmt.Errorf("%s: %w", op, ErrOrgMismatch)
+	}
+	if err := a.members.SetAppAdmin(ctx, userID, app.ID, isAdmin); err != nil {
+		log.Error("failed to change app admin rights", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	event := models.AuditAppAdminRevoked
+	if isAdmin {
+		event = models.AuditAppAdminGranted
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: event, Detail: userAuditDetail(userID) + " " + appAuditDetail(app.ID)})
+	return nil
+}
+
+// SetAppAccess grants the user access to the app or revokes it. Only restricted
+// apps check access; the grants of other apps are kept but have no effect.
+func (a *Auth) SetAppAccess(ctx context.Context, admin models.AdminScope, userID int64, appID int32, hasAccess bool) error {
+	const op = "auth.SetAppAccess"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID),
+		slog.Int("appID", int(appID)),
+		slog.Bool("hasAccess", hasAccess))
+	log.Info("changing app access")
+
+	user, err := a.scopedUser(ctx, log, admin, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	app, err := a.scopedApp(ctx, log, admin, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if user.OrgID != app.OrgID {
+		log.Warn("user belongs to another org", slog.Int64("userOrgID", user.OrgID), slog.Int64("appOrgID", app.OrgID))
+		return fmt.Errorf("%s: %w", op, ErrOrgMismatch)
+	}
+	if err := a.members.SetAppAccess(ctx, userID, app.ID, hasAccess); err != nil {
+		log.Error("failed to change app access", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	event := models.AuditAppAccessRevoked
+	if hasAccess {
+		event = models.AuditAppAccessGranted
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: event, Detail: userAuditDetail(userID) + " " + appAuditDetail(app.ID)})
+	return nil
+}
+
+// checkAppAccess refuses users who may not log into a restricted app.
+func (a *Auth) checkAppAccess(ctx context.Context, log *slog.Logger, user models.User, app models.App) error {
+	if !app.Restricted {
+		return nil
+	}
+	hasAccess, err := a.members.HasAppAccess(ctx, user.ID, app.ID)
+	if err != nil {
+		log.Error("failed to check app access", sl.Err(err))
+		return err
+	}
+	if !hasAccess {
+		log.Warn("user has no access to the app", slog.Int64("userID", user.ID), slog.Int("appID", app.ID))
+		return ErrAppAccessDenied
+	}
+	return nil
+}
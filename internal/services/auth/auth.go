@@ -2,106 +2,383 @@ package auth
 
 import (
 	"AuthGRPC/internal/domain/models"
-	"AuthGRPC/internal/lib/jwt"
 	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/mailer"
+	"AuthGRPC/internal/lib/policy"
 	"AuthGRPC/internal/storage"
 	"context"
 	"errors"
 	"fmt"
-	"golang.org/x/crypto/bcrypt"
 	"log/slog"
 	"time"
 )
 
 type Auth struct {
-	log         *slog.Logger
-	usrSaver    UserSaver
-	usrProvider UserProvider
-	AppProvider AppProvider
-	tokenTTL    time.Duration
+	log             *slog.Logger
+	usrSaver        UserSaver
+	usrProvider     UserProvider
+	hasher          PasswordHasher
+	AppProvider     AppProvider
+	tokenStorage    RefreshTokenStorage
+	revokedStorage  RevokedTokenStorage
+	keyStorage      KeyStorage
+	attempts        LoginAttemptStorage
+	lockout         LockoutPolicy
+	emailTokens     EmailTokenStorage
+	mailer          Mailer
+	verification    EmailVerification
+	passwordReset   PasswordReset
+	passwordPolicy  PasswordPolicy
+	mfaStorage      MFAStorage
+	recoveryCodes   RecoveryCodeStorage
+	auditLog        AuditLog
+	secrets         SecretCipher
+	mfa             MFAPolicy
+	oauthCodes      OAuthCodeStorage
+	oauth           OAuthPolicy
+	roles           RoleStorage
+	policies        PolicyStorage
+	policyEngine    PolicyEngine
+	orgs            OrgStorage
+	members         AppMemberStorage
+	tokenTTL        time.Duration
+	refreshTokenTTL time.Duration
+	// keyRotationInterval of zero disables scheduled signing key rotation.
+	keyRotationInterval time.Duration
 }
 type Storage interface {
 	UserSaver
 	UserProvider
 	AppProvider
+	RefreshTokenStorage
+	RevokedTokenStorage
+	KeyStorage
+	LoginAttemptStorage
+	EmailTokenStorage
+	MFAStorage
+	RecoveryCodeStorage
+	AuditLog
+	OAuthCodeStorage
+	RoleStorage
+	PolicyStorage
+	OrgStorage
+	AppMemberStorage
 }
 type UserSaver interface {
-	SaveUser(ctx context.Context, email string, passHash []byte) (uid int64, err error)
+	SaveUser(ctx context.Context, orgID int64, email string, passHash []byte) (uid int64, err error)
+	UpdatePassHash(ctx context.Context, userID int64, passHash []byte) error
+	ChangePassHash(ctx context.Context, userID int64, oldHash []byte, newHash []byte) error
+	SetEmailVerified(ctx context.Context, userID int64) error
+	SetAdmin(ctx context.Context, userID int64, isAdmin bool) error
+	SetUserDisabled(ctx context.Context, userID int64, disabled bool) error
+	DeleteUser(ctx context.Context, userID int64) error
+}
+
+type PasswordHasher interface {
+	Hash(password string) ([]byte, error)
+	Compare(hash []byte, password string) (needsRehash bool, err error)
 }
 
 type UserProvider interface {
-	User(ctx context.Context, email string) (user models.User, err error)
+	User(ctx context.Context, orgID int64, email string) (user models.User, err error)
+	UserByID(ctx context.Context, userID int64) (user models.User, err error)
 	IsAdmin(ctx context.Context, userID int64) (isAdmin bool, err error)
+	Users(ctx context.Context, filter models.UserFilter) (users []models.User, err error)
 }
 
 type AppProvider interface {
 	App(ctx context.Context, appId int32) (app models.App, err error)
+	Apps(ctx context.Context) (apps []models.App, err error)
+	SaveApp(ctx context.Context, app models.App) (appID int, err error)
+	UpdateApp(ctx context.Context, app models.App) error
+	UpdateAppSecret(ctx context.Context, appID int, secret string) error
+	DeleteApp(ctx context.Context, appID int) error
+}
+
+type RefreshTokenStorage interface {
+	SaveRefreshToken(ctx context.Context, token models.RefreshToken) error
+	RefreshToken(ctx context.Context, tokenHash string) (token models.RefreshToken, err error)
+	UseRefreshToken(ctx context.Context, id int64) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	DeleteExpiredRefreshTokens(ctx context.Context, now time.Time) (deleted int64, err error)
+}
+
+type RevokedTokenStorage interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (revoked bool, err error)
+	DeleteExpiredRevokedTokens(ctx context.Context, now time.Time) (deleted int64, err error)
+	RevokeUserTokens(ctx context.Context, userID int64, at time.Time) error
+}
+
+type KeyStorage interface {
+	RotateSigningKey(ctx context.Context, key models.SigningKey, retireAt time.Time) error
+	SigningKeys(ctx context.Context, appID int) (keys []models.SigningKey, err error)
+	AllSigningKeys(ctx context.Context) (keys []models.SigningKey, err error)
+	RetireExpiredSigningKeys(ctx context.Context, now time.Time) (retired int64, err error)
+	SealSigningKey(ctx context.Context, kid string, sealed []byte) error
+}
+
+type LoginAttemptStorage interface {
+	LoginAttempts(ctx context.Context, key string) (attempts models.LoginAttempts, err error)
+	AddLoginFailure(ctx context.Context, key string, now time.Time, windowStart time.Time) (failures int, err error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	DeleteLoginAttempts(ctx context.Context, key string) error
+	DeleteStaleLoginAttempts(ctx context.Context, now time.Time, windowStart time.Time) (deleted int64, err error)
+}
+
+type EmailTokenStorage interface {
+	SaveEmailToken(ctx context.Context, token models.EmailToken) error
+	UseEmailToken(ctx context.Context, tokenHash string, purpose string, now time.Time) (token models.EmailToken, err error)
+	DeleteEmailTokens(ctx context.Context, userID int64, purpose string) error
+	DeleteExpiredEmailTokens(ctx context.Context, now time.Time) (deleted int64, err error)
+}
+
+type MFAStorage interface {
+	SaveMFASecret(ctx context.Context, userID int64, secret []byte) error
+	MFA(ctx context.Context, userID int64) (mfa models.MFA, err error)
+	EnableMFA(ctx context.Context, userID int64) error
+	UseMFAStep(ctx context.Context, userID int64, step int64) error
+	DeleteMFA(ctx context.Context, userID int64) error
+	SaveMFAChallenge(ctx context.Context, challenge models.MFAChallenge) error
+	MFAChallenge(ctx context.Context, tokenHash string) (challenge models.MFAChallenge, err error)
+	AddMFAChallengeFailure(ctx context.Context, tokenHash string) (attempts int, err error)
+	DeleteMFAChallenge(ctx context.Context, tokenHash string) error
+	DeleteExpiredMFAChallenges(ctx context.Context, now time.Time) (deleted int64, err error)
+}
+
+type RecoveryCodeStorage interface {
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes [][]byte) error
+	RecoveryCodes(ctx context.Context, userID int64) (codes []models.RecoveryCode, err error)
+	UseRecoveryCode(ctx context.Context, id int64, now time.Time) error
+}
+
+type OAuthCodeStorage interface {
+	RedirectURIs(ctx context.Context, appID int) (redirectURIs []string, err error)
+	SaveAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error
+	UseAuthorizationCode(ctx context.Context, codeHash string, now time.Time) (code models.AuthorizationCode, err error)
+	DeleteExpiredAuthorizationCodes(ctx context.Context, now time.Time) (deleted int64, err error)
+}
+
+type RoleStorage interface {
+	SaveRole(ctx context.Context, role models.Role) (roleID int64, err error)
+	UpdateRole(ctx context.Context, role models.Role) error
+	DeleteRole(ctx context.Context, roleID int64) error
+	Role(ctx context.Context, roleID int64) (role models.Role, err error)
+	Roles(ctx context.Context, appID int) (roles []models.Role, err error)
+	UserRoles(ctx context.Context, userID int64, appID int) (roles []models.Role, err error)
+	AssignRole(ctx context.Context, userID int64, roleID int64) error
+	UnassignRole(ctx context.Context, userID int64, roleID int64) error
+}
+
+type PolicyStorage interface {
+	SavePolicy(ctx context.Context, policy models.Policy) (version int, err error)
+	Policy(ctx context.Context, appID int, version int) (policy models.Policy, err error)
+	PolicyVersions(ctx context.Context, appID int) (policies []models.Policy, err error)
+}
+
+type OrgStorage interface {
+	SaveOrg(ctx context.Context, org models.Org) (orgID int64, err error)
+	Org(ctx context.Context, orgID int64) (org models.Org, err error)
+	Orgs(ctx context.Context) (orgs []models.Org, err error)
+}
+
+type AppMemberStorage interface {
+	SetAppAdmin(ctx context.Context, userID int64, appID int, isAdmin bool) error
+	AdminApps(ctx context.Context, userID int64) (appIDs []int, err error)
+	SetAppAccess(ctx context.Context, userID int64, appID int, hasAccess bool) error
+	HasAppAccess(ctx context.Context, userID int64, appID int) (hasAccess bool, err error)
+}
+
+type PolicyEngine interface {
+	Check(rules []models.PolicyRule) error
+	Evaluate(policy models.Policy, input policy.Input) (models.Decision, error)
+}
+
+type AuditLog interface {
+	SaveAuditEvent(ctx context.Context, event models.AuditEvent) error
+}
+
+// SecretCipher encrypts secrets that have to be stored in a recoverable form, like
+// TOTP secrets and private signing keys.
+// MAC hashes the ones that only have to be recognized, like recovery codes.
+type SecretCipher interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(ciphertext []byte) ([]byte, error)
+	MAC(message []byte) []byte
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg mailer.Message) error
 }
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrInvalidAppId       = errors.New("invalid app id")
-	ErrUserExists         = errors.New("user already exists")
-	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrInvalidAppId        = errors.New("invalid app id")
+	ErrUserExists          = errors.New("user already exists")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+	ErrTokenMalformed      = errors.New("token is malformed")
+	ErrTokenExpired        = errors.New("token is expired")
+	ErrTokenSignature      = errors.New("token signature is invalid")
+	ErrTokenAppMismatch    = errors.New("token was issued for another app")
+	ErrTokenRevoked        = errors.New("token is revoked")
+	ErrTooManyAttempts     = errors.New("too many failed login attempts")
+	ErrEmailNotVerified    = errors.New("email is not verified")
+	ErrInvalidEmailToken   = errors.New("invalid or expired email token")
+	ErrWeakPassword        = errors.New("password does not meet the policy")
+	ErrPasswordChanged     = errors.New("password was changed concurrently")
+	ErrMFAAlreadyEnabled   = errors.New("mfa is already enabled")
+	ErrMFANotEnrolled      = errors.New("mfa is not enrolled")
+	ErrInvalidMFACode      = errors.New("invalid mfa code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired mfa challenge")
+	ErrInvalidRedirectURI  = errors.New("redirect uri is not registered for the app")
+	ErrInvalidGrant        = errors.New("invalid or expired authorization grant")
+	ErrInvalidClient       = errors.New("invalid client credentials")
+	ErrInvalidScope        = errors.New("scope is not allowed for the client")
+	ErrClientToken         = errors.New("token was issued to a client, not a user")
+	ErrAppExists           = errors.New("app already exists")
+	ErrInvalidAppSettings  = errors.New("invalid app settings")
+	ErrUserDisabled        = errors.New("user is disabled")
+	ErrCannotModifySelf    = errors.New("admins can't demote, disable or delete themselves")
+	ErrInvalidPageToken    = errors.New("invalid page token")
+	ErrRoleNotFound        = errors.New("role not found")
+	ErrRoleExists          = errors.New("role already exists")
+	ErrInvalidRole         = errors.New("invalid role")
+	ErrInvalidPolicy       = errors.New("invalid policy")
+	ErrPolicyNotFound      = errors.New("policy not found")
+	ErrNotAdmin            = errors.New("user is not an admin")
+	ErrAdminToken          = errors.New("admin calls need a token signed with a managed key by an app of the admin's org")
+	ErrOutOfScope          = errors.New("outside of the admin's org")
+	ErrOrgNotFound         = errors.New("org not found")
+	ErrOrgExists           = errors.New("org already exists")
+	ErrInvalidOrg          = errors.New("invalid org")
+	ErrOrgMismatch         = errors.New("user and app belong to different orgs")
+	ErrAppAccessDenied     = errors.New("user has no access to the app")
 )
 
-func New(log *slog.Logger, storage Storage, tokenTTl time.Duration) *Auth {
+func New(
+	log *slog.Logger,
+	storage Storage,
+	hasher PasswordHasher,
+	tokenTTl time.Duration,
+	refreshTokenTTL time.Duration,
+	keyRotationInterval time.Duration,
+	lockout LockoutPolicy,
+	mailer Mailer,
+	verification EmailVerification,
+	passwordReset PasswordReset,
+	passwordPolicy PasswordPolicy,
+	secrets SecretCipher,
+	mfa MFAPolicy,
+	oauth OAuthPolicy,
+	policyEngine PolicyEngine,
+) *Auth {
 	return &Auth{
-		log:         log,
-		usrSaver:    storage,
-		usrProvider: storage,
-		AppProvider: storage,
-		tokenTTL:    tokenTTl,
+		log:             log,
+		usrSaver:        storage,
+		usrProvider:     storage,
+		hasher:          hasher,
+		AppProvider:     storage,
+		tokenStorage:    storage,
+		revokedStorage:  storage,
+		keyStorage:      storage,
+		attempts:        storage,
+		lockout:         lockout,
+		emailTokens:     storage,
+		mailer:          mailer,
+		verification:    verification,
+		passwordReset:   passwordReset,
+		passwordPolicy:  passwordPolicy,
+		mfaStorage:      storage,
+		recoveryCodes:   storage,
+		auditLog:        storage,
+		secrets:         secrets,
+		mfa:             mfa,
+		oauthCodes:      storage,
+		oauth:           oauth,
+		roles:           storage,
+		policies:        storage,
+		policyEngine:    policyEngine,
+		orgs:            storage,
+		members:         storage,
+		tokenTTL:        tokenTTl,
+		refreshTokenTTL: refreshTokenTTL,
+
+		keyRotationInterval: keyRotationInterval,
 	}
 }
 
-func (a *Auth) Login(ctx context.Context, email, password string, appId int32) (token string, err error) {
+// Login checks the credentials of a user of the app's org and issues tokens.
+// Users with MFA get no tokens but an mfaToken to pass to VerifyMFA together
+// with a TOTP code.
+func (a *Auth) Login(ctx context.Context, email, password string, appId int32, clientIP string) (tokens models.TokenPair, mfaToken string, err error) {
 	const op = "auth.Login"
 	log := a.log.With(
 		slog.String("op", op),
-		slog.String("email", email))
+		slog.String("email", email),
+		slog.String("ip", clientIP))
 	log.Info("attempting to login")
 
-	user, err := a.usrProvider.User(ctx, email)
+	app, err := a.AppProvider.App(ctx, appId)
 	if err != nil {
-		if errors.Is(err, storage.ErrUserNotFound) {
-			a.log.Warn("user not found", sl.Err(err))
-			return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
 		}
-		a.log.Warn("failed to login", sl.Err(err))
-		return "", fmt.Errorf("%s: %w", op, err)
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, ErrInvalidAppId)
 	}
-	if err := bcrypt.CompareHashAndPassword(user.PassHash, []byte(password)); err != nil {
-		a.log.Warn("invalid credentials", sl.Err(err))
-		return "", fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	user, err := a.checkCredentials(ctx, log, app.OrgID, email, password, clientIP)
+	if err != nil {
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, err)
 	}
-	app, err := a.AppProvider.App(ctx, appId)
+	if app.RequireVerifiedEmail && !user.EmailVerified {
+		log.Warn("email is not verified", slog.Int("appID", app.ID))
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, ErrEmailNotVerified)
+	}
+	if err := a.checkAppAccess(ctx, log, user, app); err != nil {
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	mfaToken, err = a.mfaChallenge(ctx, user, app)
 	if err != nil {
-		if errors.Is(err, storage.ErrAppNotFound) {
-		}
-		return "", fmt.Errorf("%s: %w", op, ErrInvalidAppId)
+		log.Error("failed to create mfa challenge", sl.Err(err))
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if mfaToken != "" {
+		log.Info("password accepted, mfa required")
+		return models.TokenPair{}, mfaToken, nil
 	}
 	log.Info("successfully logged in")
-	token, err = jwt.NewToken(user, app, a.tokenTTL)
+	tokens, err = a.issueTokens(ctx, user, app, "")
 	if err != nil {
 		a.log.Error("failed to generate token", sl.Err(err))
-		return "", fmt.Errorf("%s: %w", op, err)
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, err)
 	}
-	return token, nil
+	return tokens, "", nil
 }
 
-func (a *Auth) Register(ctx context.Context, email string, password string) (userID int64, err error) {
+// Register creates a user in the org of the app. An appID of zero registers
+// the user in the default org.
+func (a *Auth) Register(ctx context.Context, email string, password string, appID int32) (userID int64, err error) {
 	const op = "auth.Register"
 	log := a.log.With(
 		slog.String("op", op),
-		slog.String("email", email))
+		slog.String("email", email),
+		slog.Int("appID", int(appID)))
 	log.Info("registering user")
-	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	orgID, err := a.appOrg(ctx, log, appID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.passwordPolicy.check(password); err != nil {
+		log.Warn("password rejected", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	passHash, err := a.hasher.Hash(password)
 	if err != nil {
 		log.Error("failed to generate password hash", sl.Err(err))
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
-	id, err := a.usrSaver.SaveUser(ctx, email, passHash)
+	id, err := a.usrSaver.SaveUser(ctx, orgID, email, passHash)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserAlreadyExists) {
 			a.log.Warn("user already exists", sl.Err(err))
@@ -111,23 +388,124 @@ func (a *Auth) Register(ctx context.Context, email string, password string) (use
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 	log.Info("saved user")
+	// The user can ask for another email, so a failed delivery doesn't fail registration.
+	if err := a.sendVerificationEmail(ctx, models.User{ID: id, OrgID: orgID, Email: email}); err != nil {
+		log.Error("failed to send verification email", sl.Err(err))
+	}
 	return id, nil
 }
 
-func (a *Auth) IsAdmin(ctx context.Context, userID int64) (isAdmin bool, err error) {
+// IsAdmin reports whether the user may administer the app: admins of the app's
+// org and admins of the app itself may. An appID of zero reports the users.is_admin
+// flag, which makes the user an admin of every app in their org.
+func (a *Auth) IsAdmin(ctx context.Context, userID int64, appID int32) (isAdmin bool, err error) {
 	const op = "auth.IsAdmin"
 	log := a.log.With(
 		slog.String("op", op),
-		slog.String("userID", fmt.Sprintf("%v", userID)))
+		slog.String("userID", fmt.Sprintf("%v", userID)),
+		slog.Int("appID", int(appID)))
 	log.Info("checking if user is admin")
-	isAdmin, err = a.usrProvider.IsAdmin(ctx, userID)
+	if appID == 0 {
+		isAdmin, err = a.usrProvider.IsAdmin(ctx, userID)
+		if err != nil {
+			if errors.Is(err, storage.ErrUserNotFound) {
+				log.Warn("user not found", sl.Err(err))
+				return false, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+			}
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		return isAdmin, nil
+	}
+	user, err := a.usrProvider.UserByID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			log.Warn("user not found", sl.Err(err))
 			return false, fmt.Errorf("%s: %w", op, ErrUserNotFound)
 		}
+		log.Error("failed to get user", sl.Err(err))
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
-	log.Info("checking if user is admin")
-	return isAdmin, nil
+	app, err := a.AppProvider.App(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
+			return false, fmt.Errorf("%s: %w", op, ErrInvalidAppId)
+		}
+		log.Error("failed to get app", sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	scope, ok, err := a.adminScope(ctx, user)
+	if err != nil {
+		log.Error("failed to get admin scope", sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok && scope.CoversApp(app), nil
+}
+
+// rehashPassword upgrades a hash made with an outdated algorithm or weaker
+// parameters. Login doesn't depend on it, so failures are only logged.
+// The hash is only replaced if the password wasn't changed in the meantime.
+func (a *Auth) rehashPassword(ctx context.Context, log *slog.Logger, user models.User, password string) {
+	passHash, err := a.hasher.Hash(password)
+	if err != nil {
+		log.Error("failed to rehash password", sl.Err(err))
+		return
+	}
+	if err := a.usrSaver.ChangePassHash(ctx, user.ID, user.PassHash, passHash); err != nil {
+		log.Error("failed to save rehashed password", sl.Err(err))
+		return
+	}
+	log.Info("password rehashed")
+}
+
+// checkCredentials verifies email and password of a user of the org, enforcing
+// the login lockout.
+func (a *Auth) checkCredentials(ctx context.Context, log *slog.Logger, orgID int64, email string, password string, clientIP string) (models.User, error) {
+	if err := a.checkLockout(ctx, orgID, email, clientIP); err != nil {
+		log.Warn("login is locked", sl.Err(err))
+		return models.User{}, err
+	}
+	user, err := a.usrProvider.User(ctx, orgID, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			a.log.Warn("user not found", sl.Err(err))
+			a.registerLoginFailure(ctx, log, orgID, email, clientIP)
+			return models.User{}, ErrInvalidCredentials
+		}
+		a.log.Warn("failed to login", sl.Err(err))
+		return models.User{}, err
+	}
+	needsRehash, err := a.hasher.Compare(user.PassHash, password)
+	if err != nil {
+		a.log.Warn("invalid credentials", sl.Err(err))
+		a.registerLoginFailure(ctx, log, orgID, email, clientIP)
+		return models.User{}, ErrInvalidCredentials
+	}
+	a.resetLoginFailures(ctx, log, orgID, email)
+	if needsRehash {
+		a.rehashPassword(ctx, log, user, password)
+	}
+	if user.Disabled {
+		log.Warn("user is disabled", slog.Int64("userID", user.ID))
+		return models.User{}, ErrUserDisabled
+	}
+	return user, nil
+}
+
+// appOrg returns the org of the app users register with or recover their
+// accounts through. An appID of zero stands for the default org.
+func (a *Auth) appOrg(ctx context.Context, log *slog.Logger, appID int32) (int64, error) {
+	if appID == 0 {
+		return models.DefaultOrgID, nil
+	}
+	app, err := a.AppProvider.App(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
+			return 0, ErrInvalidAppId
+		}
+		log.Error("failed to get app", sl.Err(err))
+		return 0, err
+	}
+	return app.OrgID, nil
 }
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/jwt"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/storage"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// ClientCredentials authenticates an app with its secret and issues an access
+// token for the app itself. Without requested scopes the token gets every scope
+// the app is allowed; asking for any other scope fails with ErrInvalidScope.
+// There is no refresh token, the app simply asks again.
+func (a *Auth) ClientCredentials(ctx context.Context, appID int32, secret string, scopes []string) (tokens models.TokenPair, granted []string, err error) {
+	const op = "auth.ClientCredentials"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)))
+	log.Info("authenticating client")
+
+	app, err := a.AppProvider.App(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
+			return models.TokenPair{}, nil, fmt.Errorf("%s: %w", op, ErrInvalidClient)
+		}
+		log.Error("failed to get app", sl.Err(err))
+		return models.TokenPair{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(app.Secret), []byte(secret)) != 1 {
+		log.Warn("invalid client secret")
+		return models.TokenPair{}, nil, fmt.Errorf("%s: %w", op, ErrInvalidClient)
+	}
+	granted = app.ClientScopes
+	if len(scopes) > 0 {
+		for _, scope := range scopes {
+			if !slices.Contains(app.ClientScopes, scope) {
+				log.Warn("scope is not allowed", slog.String("scope", scope))
+				return models.TokenPair{}, nil, fmt.Errorf("%s: %w", op, ErrInvalidScope)
+			}
+		}
+		granted = slices.Clone(scopes)
+		slices.Sort(granted)
+		granted = slices.Compact(granted)
+	}
+	key, err := a.signingKey(ctx, app)
+	if err != nil {
+		log.Error("failed to get signing key", sl.Err(err))
+		return models.TokenPair{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	token, err := jwt.NewClientToken(app, key, a.oauth.Issuer, granted, a.tokenTTL)
+	if err != nil {
+		log.Error("failed to generate token", sl.Err(err))
+		return models.TokenPair{}, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("client authenticated")
+	return models.TokenPair{AccessToken: token, ExpiresIn: a.tokenTTL}, granted, nil
+}
@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/jwt"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/opaque"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// JWKS returns the public keys used to sign tokens of the given app,
+// or of every app when appID is zero. HS256 keys are never published.
+func (a *Auth) JWKS(ctx context.Context, appID int32) (keySet []jwt.JWK, err error) {
+	const op = "auth.JWKS"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)))
+	log.Info("building jwks")
+
+	var keys []models.SigningKey
+	if appID == 0 {
+		keys, err = a.allSigningKeys(ctx)
+	} else {
+		if _, err = a.AppProvider.App(ctx, appID); err != nil {
+			if errors.Is(err, storage.ErrAppNotFound) {
+				log.Warn("app not found", sl.Err(err))
+				return nil, fmt.Errorf("%s: %w", op, ErrInvalidAppId)
+			}
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keys, err = a.signingKeys(ctx, int(appID))
+	}
+	if err != nil {
+		log.Error("failed to get signing keys", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	keySet = make([]jwt.JWK, 0, len(keys))
+	for _, key := range keys {
+		if !jwt.IsAsymmetric(key.Alg) || !usableForVerification(key) {
+			continue
+		}
+		jwk, err := jwt.PublicJWK(key)
+		if err != nil {
+			log.Error("failed to convert signing key", slog.String("kid", key.ID), sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keySet = append(keySet, jwk)
+	}
+	return keySet, nil
+}
+
+// rotateDueSigningKeys rotates the keys of every app whose active key is older
+// than the configured rotation interval.
+func (a *Auth) rotateDueSigningKeys(ctx context.Context) error {
+	apps, err := a.AppProvider.Apps(ctx)
+	if err != nil {
+		return err
+	}
+	for _, app := range apps {
+		keys, err := a.keyStorage.SigningKeys(ctx, app.ID)
+		if err != nil {
+			return err
+		}
+		active, ok := activeKey(keys, signingAlg(app))
+		if ok && time.Since(active.CreatedAt) < a.keyRotationInterval {
+			continue
+		}
+		if _, err := a.newSigningKey(ctx, app); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signingKey picks the key new tokens of the app are signed with. Apps that
+// sign with their secret keep doing so until they were rotated once; for the
+// others a key is generated on first use.
+func (a *Auth) signingKey(ctx context.Context, app models.App) (models.SigningKey, error) {
+	keys, err := a.signingKeys(ctx, app.ID)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	if key, ok := activeKey(keys, signingAlg(app)); ok {
+		return key, nil
+	}
+	if signsWithSecret(app) && len(keys) == 0 {
+		return legacyKey(app), nil
+	}
+	return a.newSigningKey(ctx, app)
+}
+
+// verificationKeys returns every key a still valid token of the app may be signed with.
+// Tokens signed with the app secret carry no kid. Only apps that sign with their
+// secret accept them, until one token TTL has passed since their first managed key.
+func (a *Auth) verificationKeys(ctx context.Context, app models.App) ([]models.SigningKey, error) {
+	keys, err := a.signingKeys(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+	usable := make([]models.SigningKey, 0, len(keys)+1)
+	withLegacy := signsWithSecret(app)
+	for _, key := range keys {
+		if time.Since(key.CreatedAt) > a.tokenTTL {
+			withLegacy = false
+		}
+		if usableForVerification(key) {
+			usable = append(usable, key)
+		}
+	}
+	if withLegacy {
+		usable = append(usable, legacyKey(app))
+	}
+	return usable, nil
+}
+
+func (a *Auth) newSigningKey(ctx context.Context, app models.App) (models.SigningKey, error) {
+	alg := signingAlg(app)
+	secret, err := jwt.GenerateKey(alg)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	kid, err := opaque.New()
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	sealed, err := a.secrets.Seal(secret)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	key := models.SigningKey{
+		ID:        kid,
+		AppID:     app.ID,
+		Alg:       alg,
+		Key:       sealed,
+		Sealed:    true,
+		Status:    models.KeyStatusActive,
+		CreatedAt: time.Now(),
+	}
+	// Concurrent first signings may both get here; the key saved last stays
+	// active and the other one keeps verifying its tokens while retiring.
+	if err := a.keyStorage.RotateSigningKey(ctx, key, key.CreatedAt.Add(a.tokenTTL)); err != nil {
+		return models.SigningKey{}, err
+	}
+	key.Key, key.Sealed = secret, false
+	a.log.Info("generated signing key",
+		slog.Int("appID", app.ID),
+		slog.String("alg", key.Alg),
+		slog.String("kid", key.ID))
+	return key, nil
+}
+
+// signingKeys returns the keys of the app, newest first, with their private keys opened.
+func (a *Auth) signingKeys(ctx context.Context, appID int) ([]models.SigningKey, error) {
+	keys, err := a.keyStorage.SigningKeys(ctx, appID)
+	if err != nil {
+		return nil, err
+	}
+	return a.openSigningKeys(keys)
+}
+
+func (a *Auth) allSigningKeys(ctx context.Context) ([]models.SigningKey, error) {
+	keys, err := a.keyStorage.AllSigningKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.openSigningKeys(keys)
+}
+
+// openSigningKeys decrypts the sealed private keys in place. Keys saved before
+// keys were sealed are used as they are until sealPlainSigningKeys gets to them.
+func (a *Auth) openSigningKeys(keys []models.SigningKey) ([]models.SigningKey, error) {
+	for i, key := range keys {
+		if !key.Sealed {
+			continue
+		}
+		opened, err := a.secrets.Open(key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open signing key %s: %w", key.ID, err)
+		}
+		keys[i].Key, keys[i].Sealed = opened, false
+	}
+	return keys, nil
+}
+
+// sealPlainSigningKeys seals the private keys that were saved in plain, so a
+// copy of the database doesn't leak them.
+func (a *Auth) sealPlainSigningKeys(ctx context.Context) (sealed int64, err error) {
+	keys, err := a.keyStorage.AllSigningKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		if key.Sealed {
+			continue
+		}
+		ciphertext, err := a.secrets.Seal(key.Key)
+		if err != nil {
+			return sealed, err
+		}
+		if err := a.keyStorage.SealSigningKey(ctx, key.ID, ciphertext); err != nil {
+			return sealed, err
+		}
+		sealed++
+	}
+	return sealed, nil
+}
+
+// activeKey returns the newest active key for alg. keys must be sorted newest first.
+func activeKey(keys []models.SigningKey, alg string) (models.SigningKey, bool) {
+	for _, key := range keys {
+		if key.Status == models.KeyStatusActive && key.Alg == alg {
+			return key, true
+		}
+	}
+	return models.SigningKey{}, false
+}
+
+func usableForVerification(key models.SigningKey) bool {
+	switch key.Status {
+	case models.KeyStatusActive:
+		return true
+	case models.KeyStatusRetiring:
+		return time.Now().Before(key.RetireAt)
+	}
+	return false
+}
+
+func legacyKey(app models.App) models.SigningKey {
+	return models.SigningKey{AppID: app.ID, Alg: jwt.AlgHS256, Key: []byte(app.Secret)}
+}
+
+// signsWithSecret reports whether the app predates managed keys and still signs
+// HS256 tokens with its secret.
+func signsWithSecret(app models.App) bool {
+	return app.SecretSigning && signingAlg(app) == jwt.AlgHS256
+}
+
+func signingAlg(app models.App) string {
+	if app.SigningAlg == "" {
+		return jwt.AlgHS256
+	}
+	return app.SigningAlg
+}
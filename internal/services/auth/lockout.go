@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LockoutPolicy configures brute-force protection of Login. Zero thresholds
+// disable tracking for the respective key.
+type LockoutPolicy struct {
+	MaxAttempts   int
+	IPMaxAttempts int
+	// Window is how long failures are remembered.
+	Window time.Duration
+	// BaseDuration is the first lockout; every further failure doubles it up to MaxDuration.
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+}
+
+// LockedError is returned while logins for an email or client IP, or the MFA
+// codes of a user, are locked.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// UnlockAccount lifts a lockout early and forgets the failed attempts.
+// Either email or clientIP may be empty. The email is looked up in the org,
+// which defaults to the admin's own. Client IPs are shared by every org, so
+// only admins of the default org may unlock them.
+func (a *Auth) UnlockAccount(ctx context.Context, admin models.AdminScope, orgID int64, email string, clientIP string) error {
+	const op = "auth.UnlockAccount"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("email", email),
+		slog.String("ip", clientIP))
+	log.Info("unlocking account")
+
+	orgID = ownOrg(admin, orgID)
+	if err := checkManagesOrg(log, admin); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !admin.CoversOrg(orgID) || (clientIP != "" && admin.OrgID != 0) {
+		log.Warn("unlock is outside of the admin's org", slog.Int64("orgID", orgID))
+		return fmt.Errorf("%s: %w", op, ErrOutOfScope)
+	}
+	for _, key := range a.lockoutKeys(orgID, email, clientIP) {
+		if err := a.attempts.DeleteLoginAttempts(ctx, key.key); err != nil {
+			log.Error("failed to unlock", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	log.Info("account unlocked")
+	return nil
+}
+
+// checkLockout fails with a *LockedError when the email or client IP is locked.
+func (a *Auth) checkLockout(ctx context.Context, orgID int64, email string, clientIP string) error {
+	now := time.Now()
+	var retryAfter time.Duration
+	for _, key := range a.lockoutKeys(orgID, email, clientIP) {
+		attempts, err := a.attempts.LoginAttempts(ctx, key.key)
+		if err != nil {
+			return err
+		}
+		if wait := attempts.LockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return &LockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// registerLoginFailure counts the failure for the email and client IP
+// and locks whichever crossed its threshold.
+func (a *Auth) registerLoginFailure(ctx context.Context, log *slog.Logger, orgID int64, email string, clientIP string) {
+	now := time.Now()
+	for _, key := range a.lockoutKeys(orgID, email, clientIP) {
+		if key.threshold <= 0 {
+			continue
+		}
+		failures, err := a.attempts.AddLoginFailure(ctx, key.key, now, now.Add(-a.lockout.Window))
+		if err != nil {
+			log.Error("failed to count login failure", sl.Err(err))
+			continue
+		}
+		if failures < key.threshold {
+			continue
+		}
+		duration := a.lockoutDuration(failures - key.threshold)
+		if err := a.attempts.LockLogin(ctx, key.key, now.Add(duration)); err != nil {
+			log.Error("failed to lock login", sl.Err(err))
+			continue
+		}
+		log.Warn("login locked", slog.String("key", key.key), slog.Duration("duration", duration))
+	}
+}
+
+// resetLoginFailures forgets the failures of an email after a successful login.
+// The client IP counter is left alone, otherwise one known account would let
+// an attacker reset it at will.
+func (a *Auth) resetLoginFailures(ctx context.Context, log *slog.Logger, orgID int64, email string) {
+	if a.lockout.MaxAttempts <= 0 {
+		return
+	}
+	if err := a.attempts.DeleteLoginAttempts(ctx, emailLockoutKey(orgID, email)); err != nil {
+		log.Error("failed to reset login failures", sl.Err(err))
+	}
+}
+
+// checkMFALockout fails with a *LockedError while the user's MFA codes are locked.
+func (a *Auth) checkMFALockout(ctx context.Context, userID int64) error {
+	attempts, err := a.attempts.LoginAttempts(ctx, mfaLockoutKey(userID))
+	if err != nil {
+		return err
+	}
+	if retryAfter := time.Until(attempts.LockedUntil); retryAfter > 0 {
+		return &LockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// registerMFACodeFailure counts a wrong code of the user, whichever challenge
+// or call it was entered for, and locks the user's MFA codes after
+// MFAPolicy.MaxAttempts failures.
+func (a *Auth) registerMFACodeFailure(ctx context.Context, log *slog.Logger, userID int64) {
+	if a.mfa.MaxAttempts <= 0 {
+		return
+	}
+	now := time.Now()
+	key := mfaLockoutKey(userID)
+	failures, err := a.attempts.AddLoginFailure(ctx, key, now, now.Add(-a.lockout.Window))
+	if err != nil {
+		log.Error("failed to count mfa failure", sl.Err(err))
+		return
+	}
+	if failures < a.mfa.MaxAttempts {
+		return
+	}
+	duration := a.lockoutDuration(failures - a.mfa.MaxAttempts)
+	if err := a.attempts.LockLogin(ctx, key, now.Add(duration)); err != nil {
+		log.Error("failed to lock mfa codes", sl.Err(err))
+		return
+	}
+	log.Warn("mfa codes locked", slog.Duration("duration", duration))
+}
+
+// resetMFACodeFailures forgets the failures once the user entered a valid code.
+func (a *Auth) resetMFACodeFailures(ctx context.Context, log *slog.Logger, userID int64) {
+	if a.mfa.MaxAttempts <= 0 {
+		return
+	}
+	if err := a.attempts.DeleteLoginAttempts(ctx, mfaLockoutKey(userID)); err != nil {
+		log.Error("failed to reset mfa failures", sl.Err(err))
+	}
+}
+
+func (a *Auth) lockoutDuration(exceeded int) time.Duration {
+	duration := a.lockout.BaseDuration
+	for i := 0; i < exceeded && duration < a.lockout.MaxDuration; i++ {
+		duration *= 2
+	}
+	if duration > a.lockout.MaxDuration {
+		return a.lockout.MaxDuration
+	}
+	return duration
+}
+
+type lockoutKey struct {
+	key       string
+	threshold int
+}
+
+func (a *Auth) lockoutKeys(orgID int64, email string, clientIP string) []lockoutKey {
+	keys := make([]lockoutKey, 0, 2)
+	if email != "" {
+		keys = append(keys, lockoutKey{key: emailLockoutKey(orgID, email), threshold: a.lockout.MaxAttempts})
+	}
+	if clientIP != "" {
+		keys = append(keys, lockoutKey{key: "ip:" + clientIP, threshold: a.lockout.IPMaxAttempts})
+	}
+	return keys
+}
+
+// emailLockoutKey keeps the key of the default org as it was before orgs,
+// so lockouts survive the upgrade.
+func emailLockoutKey(orgID int64, email string) string {
+	if orgID == models.DefaultOrgID {
+		return "email:" + strings.ToLower(email)
+	}
+	return "email:" + strconv.FormatInt(orgID, 10) + ":" + strings.ToLower(email)
+}
+
+func mfaLockoutKey(userID int64) string {
+	return "mfa:" + strconv.FormatInt(userID, 10)
+}
@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/opaque"
+	"AuthGRPC/internal/lib/totp"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// MFAPolicy configures TOTP multi-factor authentication.
+type MFAPolicy struct {
+	// Issuer names the service in authenticator apps.
+	Issuer string
+	// ChallengeTTL is how long the user has to enter a code after the password.
+	ChallengeTTL time.Duration
+	// MaxAttempts wrong codes invalidate a challenge.
+	MaxAttempts int
+	// Skew is the number of time steps around the current one that are accepted.
+	Skew int
+	// RecoveryCodes is the size of the recovery code set generated on enrollment.
+	RecoveryCodes int
+}
+
+// EnrollMFA creates a new TOTP secret for the user. It only takes effect once
+// confirmed with ConfirmMFA; until then enrolling again replaces it.
+func (a *Auth) EnrollMFA(ctx context.Context, userID int64) (secret string, uri string, err error) {
+	const op = "auth.EnrollMFA"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+	log.Info("enrolling mfa")
+
+	user, err := a.usrProvider.UserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return "", "", fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	mfa, err := a.mfaStorage.MFA(ctx, userID)
+	if err != nil && !errors.Is(err, storage.ErrMFANotFound) {
+		log.Error("failed to get mfa", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if mfa.Enabled {
+		log.Warn("mfa already enabled")
+		return "", "", fmt.Errorf("%s: %w", op, ErrMFAAlreadyEnabled)
+	}
+	secret, err = totp.GenerateSecret()
+	if err != nil {
+		log.Error("failed to generate secret", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	sealed, err := a.secrets.Seal([]byte(secret))
+	if err != nil {
+		log.Error("failed to encrypt secret", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.mfaStorage.SaveMFASecret(ctx, userID, sealed); err != nil {
+		log.Error("failed to save secret", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("mfa enrollment started")
+	return secret, totp.URI(a.mfa.Issuer, user.Email, secret), nil
+}
+
+// ConfirmMFA enables MFA once the user proved their authenticator produces valid codes.
+// It returns the recovery codes, which are shown to the user only this once.
+func (a *Auth) ConfirmMFA(ctx context.Context, userID int64, code string) (recoveryCodes []string, err error) {
+	const op = "auth.ConfirmMFA"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+	log.Info("confirming mfa")
+
+	mfa, err := a.mfaStorage.MFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			log.Warn("mfa not enrolled", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrMFANotEnrolled)
+		}
+		log.Error("failed to get mfa", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if mfa.Enabled {
+		log.Warn("mfa already enabled")
+		return nil, fmt.Errorf("%s: %w", op, ErrMFAAlreadyEnabled)
+	}
+	if err := a.verifyTOTP(ctx, mfa, code); err != nil {
+		log.Warn("invalid mfa code", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	recoveryCodes, err = a.newRecoveryCodes(ctx, userID)
+	if err != nil {
+		log.Error("failed to generate recovery codes", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.mfaStorage.EnableMFA(ctx, userID); err != nil {
+		log.Error("failed to enable mfa", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("mfa enabled")
+	return recoveryCodes, nil
+}
+
+// DisableMFA removes the enrollment. A valid TOTP or recovery code is required,
+// so a stolen access token alone can't turn MFA off, and too many wrong codes
+// lock the user's codes like failed logins do.
+func (a *Auth) DisableMFA(ctx context.Context, userID int64, code string, clientIP string) error {
+	const op = "auth.DisableMFA"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+	log.Info("disabling mfa")
+
+	mfa, err := a.mfaStorage.MFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			log.Warn("mfa not enrolled", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrMFANotEnrolled)
+		}
+		log.Error("failed to get mfa", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if mfa.Enabled {
+		if err := a.checkMFALockout(ctx, userID); err != nil {
+			log.Warn("mfa codes are locked", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := a.verifyMFACode(ctx, log, mfa, code, clientIP); err != nil {
+			log.Warn("invalid mfa code", sl.Err(err))
+			if errors.Is(err, ErrInvalidMFACode) {
+				a.registerMFACodeFailure(ctx, log, userID)
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		a.resetMFACodeFailures(ctx, log, userID)
+	}
+	if err := a.mfaStorage.DeleteMFA(ctx, userID); err != nil {
+		log.Error("failed to delete mfa", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if mfa.Enabled {
+		a.audit(ctx, log, models.AuditEvent{
+			UserID: userID,
+			Event:  models.AuditMFADisabled,
+			IP:     clientIP,
+		})
+	}
+	log.Info("mfa disabled")
+	return nil
+}
+
+// VerifyMFA completes a login that was answered with an MFA challenge. code is
+// either a TOTP code or one of the user's recovery codes.
+func (a *Auth) VerifyMFA(ctx context.Context, mfaToken string, code string, clientIP string) (tokens models.TokenPair, err error) {
+	const op = "auth.VerifyMFA"
+	log := a.log.With(slog.String("op", op))
+	log.Info("verifying mfa")
+
+	user, app, err := a.completeMFA(ctx, log, mfaToken, code, clientIP)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	tokens, err = a.issueTokens(ctx, user, app, "")
+	if err != nil {
+		log.Error("failed to generate tokens", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("mfa verified, logged in")
+	return tokens, nil
+}
+
+// completeMFA checks the code for the challenge and consumes the challenge.
+// It returns the user and app the challenge was issued for. Wrong codes also
+// count against the user, since logging in again hands out a fresh challenge.
+func (a *Auth) completeMFA(ctx context.Context, log *slog.Logger, mfaToken string, code string, clientIP string) (models.User, models.App, error) {
+	tokenHash := opaque.Hash(mfaToken)
+	challenge, err := a.mfaStorage.MFAChallenge(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFAChallengeNotFound) {
+			log.Warn("mfa challenge not found", sl.Err(err))
+			return models.User{}, models.App{}, ErrInvalidMFAChallenge
+		}
+		log.Error("failed to get mfa challenge", sl.Err(err))
+		return models.User{}, models.App{}, err
+	}
+	log = log.With(slog.Int64("userID", challenge.UserID))
+	if time.Now().After(challenge.ExpiresAt) {
+		log.Warn("mfa challenge expired")
+		return models.User{}, models.App{}, ErrInvalidMFAChallenge
+	}
+	mfa, err := a.mfaStorage.MFA(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			log.Warn("mfa was disabled", sl.Err(err))
+			return models.User{}, models.App{}, ErrInvalidMFAChallenge
+		}
+		log.Error("failed to get mfa", sl.Err(err))
+		return models.User{}, models.App{}, err
+	}
+	if err := a.checkMFALockout(ctx, challenge.UserID); err != nil {
+		log.Warn("mfa codes are locked", sl.Err(err))
+		return models.User{}, models.App{}, err
+	}
+	if err := a.verifyMFACode(ctx, log, mfa, code, clientIP); err != nil {
+		if !errors.Is(err, ErrInvalidMFACode) {
+			log.Error("failed to verify mfa code", sl.Err(err))
+			return models.User{}, models.App{}, err
+		}
+		log.Warn("invalid mfa code")
+		a.registerMFAFailure(ctx, log, tokenHash)
+		a.registerMFACodeFailure(ctx, log, challenge.UserID)
+		return models.User{}, models.App{}, ErrInvalidMFACode
+	}
+	a.resetMFACodeFailures(ctx, log, challenge.UserID)
+	if err := a.mfaStorage.DeleteMFAChallenge(ctx, tokenHash); err != nil {
+		if errors.Is(err, storage.ErrMFAChallengeNotFound) {
+			log.Warn("mfa challenge already used", sl.Err(err))
+			return models.User{}, models.App{}, ErrInvalidMFAChallenge
+		}
+		log.Error("failed to delete mfa challenge", sl.Err(err))
+		return models.User{}, models.App{}, err
+	}
+
+	user, err := a.usrProvider.UserByID(ctx, challenge.UserID)
+	if err != nil {
+		log.Error("failed to get user", sl.Err(err))
+		return models.User{}, models.App{}, err
+	}
+	if user.Disabled {
+		log.Warn("user is disabled", slog.Int64("userID", user.ID))
+		return models.User{}, models.App{}, ErrUserDisabled
+	}
+	app, err := a.AppProvider.App(ctx, int32(challenge.AppID))
+	if err != nil {
+		log.Error("failed to get app", sl.Err(err))
+		return models.User{}, models.App{}, err
+	}
+	return user, app, nil
+}
+
+// mfaChallenge returns a challenge token when the user has MFA enabled, or an
+// empty string when the password alone is enough.
+func (a *Auth) mfaChallenge(ctx context.Context, user models.User, app models.App) (string, error) {
+	mfa, err := a.mfaStorage.MFA(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if !mfa.Enabled {
+		return "", nil
+	}
+	token, err := opaque.New()
+	if err != nil {
+		return "", err
+	}
+	err = a.mfaStorage.SaveMFAChallenge(ctx, models.MFAChallenge{
+		TokenHash: opaque.Hash(token),
+		UserID:    user.ID,
+		AppID:     app.ID,
+		ExpiresAt: time.Now().Add(a.mfa.ChallengeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// registerMFAFailure counts a wrong code and drops the challenge after too many,
+// so the user has to start over with the password.
+func (a *Auth) registerMFAFailure(ctx context.Context, log *slog.Logger, tokenHash string) {
+	attempts, err := a.mfaStorage.AddMFAChallengeFailure(ctx, tokenHash)
+	if err != nil {
+		log.Error("failed to count mfa failure", sl.Err(err))
+		return
+	}
+	if a.mfa.MaxAttempts <= 0 || attempts < a.mfa.MaxAttempts {
+		return
+	}
+	if err := a.mfaStorage.DeleteMFAChallenge(ctx, tokenHash); err != nil {
+		log.Error("failed to delete mfa challenge", sl.Err(err))
+		return
+	}
+	log.Warn("mfa challenge dropped after too many failures", slog.Int("attempts", attempts))
+}
+
+// verifyTOTP checks code against the user's secret and burns its time step.
+func (a *Auth) verifyTOTP(ctx context.Context, mfa models.MFA, code string) error {
+	secret, err := a.secrets.Open(mfa.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Validate(string(secret), code, time.Now(), a.mfa.Skew)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	if err := a.mfaStorage.UseMFAStep(ctx, mfa.UserID, step); err != nil {
+		if errors.Is(err, storage.ErrMFAStepUsed) {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/opaque"
+	"AuthGRPC/internal/storage"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// OAuthPolicy configures the OAuth 2.0 authorization code flow.
+type OAuthPolicy struct {
+	// Issuer is the public base URL of the service, used as the iss claim.
+	Issuer  string
+	CodeTTL time.Duration
+}
+
+// ValidateAuthorizationRequest checks that the app exists and redirectURI is
+// registered for it. Until both hold, errors must not be sent to redirectURI.
+func (a *Auth) ValidateAuthorizationRequest(ctx context.Context, appID int32, redirectURI string) error {
+	const op = "auth.ValidateAuthorizationRequest"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)))
+
+	if _, err := a.AppProvider.App(ctx, appID); err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrInvalidAppId)
+		}
+		log.Error("failed to get app", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	redirectURIs, err := a.oauthCodes.RedirectURIs(ctx, int(appID))
+	if err != nil {
+		log.Error("failed to get redirect uris", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	// Redirect URIs are compared verbatim, as required by OAuth 2.0 Security BCP.
+	if !slices.Contains(redirectURIs, redirectURI) {
+		log.Warn("redirect uri is not registered", slog.String("redirectURI", redirectURI))
+		return fmt.Errorf("%s: %w", op, ErrInvalidRedirectURI)
+	}
+	return nil
+}
+
+// AuthorizeWithPassword signs the user in on the authorize page and issues an
+// authorization code. Users with MFA get an mfaToken for AuthorizeWithMFA instead.
+func (a *Auth) AuthorizeWithPassword(ctx context.Context, req models.AuthorizationRequest, email string, password string, clientIP string) (code string, mfaToken string, err error) {
+	const op = "auth.AuthorizeWithPassword"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("email", email),
+		slog.Int("appID", int(req.AppID)),
+		slog.String("ip", clientIP))
+	log.Info("authorizing")
+
+	if err := a.ValidateAuthorizationRequest(ctx, req.AppID, req.RedirectURI); err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	app, err := a.AppProvider.App(ctx, req.AppID)
+	if err != nil {
+		log.Error("failed to get app", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	user, err := a.checkCredentials(ctx, log, app.OrgID, email, password, clientIP)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if app.RequireVerifiedEmail && !user.EmailVerified {
+		log.Warn("email is not verified")
+		return "", "", fmt.Errorf("%s: %w", op, ErrEmailNotVerified)
+	}
+	if err := a.checkAppAccess(ctx, log, user, app); err != nil {
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	mfaToken, err = a.mfaChallenge(ctx, user, app)
+	if err != nil {
+		log.Error("failed to create mfa challenge", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	if mfaToken != "" {
+		log.Info("password accepted, mfa required")
+		return "", mfaToken, nil
+	}
+	code, err = a.newAuthorizationCode(ctx, req, user)
+	if err != nil {
+		log.Error("failed to create authorization code", sl.Err(err))
+		return "", "", fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("authorization code issued")
+	return code, "", nil
+}
+
+// AuthorizeWithMFA finishes AuthorizeWithPassword for users with MFA.
+func (a *Auth) AuthorizeWithMFA(ctx context.Context, req models.AuthorizationRequest, mfaToken string, mfaCode string, clientIP string) (code string, err error) {
+	const op = "auth.AuthorizeWithMFA"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(req.AppID)),
+		slog.String("ip", clientIP))
+	log.Info("verifying mfa for authorization")
+
+	if err := a.ValidateAuthorizationRequest(ctx, req.AppID, req.RedirectURI); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	user, app, err := a.completeMFA(ctx, log, mfaToken, mfaCode, clientIP)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if app.ID != int(req.AppID) {
+		log.Warn("mfa challenge was issued for another app", slog.Int("challengeAppID", app.ID))
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidMFAChallenge)
+	}
+	code, err = a.newAuthorizationCode(ctx, req, user)
+	if err != nil {
+		log.Error("failed to create authorization code", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("authorization code issued")
+	return code, nil
+}
+
+// ExchangeAuthorizationCode redeems a code for tokens. The code is consumed even if
+// the PKCE verifier or redirect URI turn out to be wrong, so it can't be guessed at.
+// The user is checked again like on login, since they may have been disabled or
+// lost access to the app since the code was issued.
+func (a *Auth) ExchangeAuthorizationCode(ctx context.Context, code string, appID int32, redirectURI string, codeVerifier string) (tokens models.TokenPair, scope string, err error) {
+	const op = "auth.ExchangeAuthorizationCode"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)))
+	log.Info("exchanging authorization code")
+
+	stored, err := a.oauthCodes.UseAuthorizationCode(ctx, opaque.Hash(code), time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrAuthCodeNotFound) {
+			log.Warn("authorization code not found", sl.Err(err))
+			return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, ErrInvalidGrant)
+		}
+		log.Error("failed to use authorization code", sl.Err(err))
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.Int64("userID", stored.UserID))
+	if stored.AppID != int(appID) || stored.RedirectURI != redirectURI {
+		log.Warn("authorization code was issued for another client or redirect uri")
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, ErrInvalidGrant)
+	}
+	if !verifyCodeChallenge(stored.CodeChallenge, codeVerifier) {
+		log.Warn("pkce verification failed")
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, ErrInvalidGrant)
+	}
+	user, err := a.usrProvider.UserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, ErrInvalidGrant)
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if user.Disabled {
+		log.Warn("user is disabled")
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, ErrInvalidGrant)
+	}
+	app, err := a.AppProvider.App(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
+			return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, ErrInvalidGrant)
+		}
+		log.Error("failed to get app", sl.Err(err))
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if app.RequireVerifiedEmail && !user.EmailVerified {
+		log.Warn("email is not verified")
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, ErrInvalidGrant)
+	}
+	if err := a.checkAppAccess(ctx, log, user, app); err != nil {
+		if errors.Is(err, ErrAppAccessDenied) {
+			return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, ErrInvalidGrant)
+		}
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	tokens, err = a.issueTokens(ctx, user, app, "")
+	if err != nil {
+		log.Error("failed to generate tokens", sl.Err(err))
+		return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if hasScope(stored.Scope, scopeOpenID) {
+		tokens.IDToken, err = a.newIDToken(ctx, user, app, stored.Nonce, stored.AuthTime)
+		if err != nil {
+			log.Error("failed to generate id token", sl.Err(err))
+			return models.TokenPair{}, "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	log.Info("authorization code exchanged")
+	return tokens, stored.Scope, nil
+}
+
+func (a *Auth) newAuthorizationCode(ctx context.Context, req models.AuthorizationRequest, user models.User) (string, error) {
+	code, err := opaque.New()
+	if err != nil {
+		return "", err
+	}
+	err = a.oauthCodes.SaveAuthorizationCode(ctx, models.AuthorizationCode{
+		CodeHash:      opaque.Hash(code),
+		AppID:         int(req.AppID),
+		UserID:        user.ID,
+		RedirectURI:   req.RedirectURI,
+		CodeChallenge: req.CodeChallenge,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		AuthTime:      time.Now(),
+		ExpiresAt:     time.Now().Add(a.oauth.CodeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// verifyCodeChallenge checks a PKCE verifier against an S256 challenge (RFC 7636).
+func verifyCodeChallenge(challenge string, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/jwt"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// scopeOpenID turns an OAuth request into an OpenID Connect one.
+const scopeOpenID = "openid"
+
+// UserInfo returns the owner of an access token for the OpenID Connect userinfo endpoint.
+func (a *Auth) UserInfo(ctx context.Context, accessToken string) (user models.User, err error) {
+	const op = "auth.UserInfo"
+	log := a.log.With(slog.String("op", op))
+
+	claims, err := a.ValidateToken(ctx, accessToken, 0)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if claims.Client {
+		log.Warn("userinfo requested with a client token")
+		return models.User{}, fmt.Errorf("%s: %w", op, ErrClientToken)
+	}
+	user, err = a.usrProvider.UserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return models.User{}, fmt.Errorf("%s: %w", op, ErrTokenRevoked)
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, nil
+}
+
+// Issuer is the iss claim of every token and the base URL of the OpenID Connect endpoints.
+func (a *Auth) Issuer() string {
+	return a.oauth.Issuer
+}
+
+// newIDToken signs an ID token for the app. It lives as long as an access token.
+// Apps with asymmetric keys get it signed with the same key as their access
+// tokens, so clients verify both against the app's JWKS. Managed HS256 keys never
+// leave the server, so HS256 ID tokens are signed with the client secret instead,
+// as OpenID Connect Core 10.1 asks.
+func (a *Auth) newIDToken(ctx context.Context, user models.User, app models.App, nonce string, authTime time.Time) (string, error) {
+	key := legacyKey(app)
+	if jwt.IsAsymmetric(signingAlg(app)) {
+		var err error
+		if key, err = a.signingKey(ctx, app); err != nil {
+			return "", err
+		}
+	}
+	return jwt.NewIDToken(user, app, key, a.oauth.Issuer, nonce, authTime, a.tokenTTL)
+}
+
+// hasScope reports whether the space-delimited scope list contains scope.
+func hasScope(scopes string, scope string) bool {
+	return slices.Contains(strings.Fields(scopes), scope)
+}
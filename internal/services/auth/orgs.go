@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AdminScope returns what the owner of the access token may manage as an admin.
+// Admins of the default org manage every org, the others only their own. Users
+// who are only admins of single apps manage just these apps. Disabled users
+// aren't admins. Tokens signed with an app secret, or issued by an app of another
+// org, are refused: anyone who knows the secret could have minted them.
+func (a *Auth) AdminScope(ctx context.Context, claims models.TokenClaims) (scope models.AdminScope, err error) {
+	const op = "auth.AdminScope"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", claims.UserID),
+		slog.Int("appID", claims.AppID))
+
+	if claims.KeyID == "" {
+		log.Warn("admin token is not signed with a managed key")
+		return models.AdminScope{}, fmt.Errorf("%s: %w", op, ErrAdminToken)
+	}
+	user, err := a.usrProvider.UserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return models.AdminScope{}, fmt.Errorf("%s: %w", op, ErrNotAdmin)
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return models.AdminScope{}, fmt.Errorf("%s: %w", op, err)
+	}
+	app, err := a.AppProvider.App(ctx, int32(claims.AppID))
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("token app not found", sl.Err(err))
+			return models.AdminScope{}, fmt.Errorf("%s: %w", op, ErrAdminToken)
+		}
+		log.Error("failed to get app", sl.Err(err))
+		return models.AdminScope{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if app.OrgID != user.OrgID {
+		log.Warn("admin token was issued by an app of another org", slog.Int64("appOrgID", app.OrgID))
+		return models.AdminScope{}, fmt.Errorf("%s: %w", op, ErrAdminToken)
+	}
+	scope, ok, err := a.adminScope(ctx, user)
+	if err != nil {
+		log.Error("failed to get admin apps", sl.Err(err))
+		return models.AdminScope{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		return models.AdminScope{}, fmt.Errorf("%s: %w", op, ErrNotAdmin)
+	}
+	return scope, nil
+}
+
+// adminScope is AdminScope for a user that was already looked up; ok is false
+// if the user isn't an admin at all.
+func (a *Auth) adminScope(ctx context.Context, user models.User) (scope models.AdminScope, ok bool, err error) {
+	if user.Disabled {
+		return models.AdminScope{}, false, nil
+	}
+	if user.IsAdmin {
+		scope = models.AdminScope{UserID: user.ID, OrgID: user.OrgID}
+		if user.OrgID == models.DefaultOrgID {
+			scope.OrgID = 0
+		}
+		return scope, true, nil
+	}
+	appIDs, err := a.members.AdminApps(ctx, user.ID)
+	if err != nil || len(appIDs) == 0 {
+		return models.AdminScope{}, false, err
+	}
+	return models.AdminScope{UserID: user.ID, OrgID: user.OrgID, AppIDs: appIDs}, true, nil
+}
+
+// CreateOrg creates an empty org. Only admins of the default org may create orgs.
+func (a *Auth) CreateOrg(ctx context.Context, admin models.AdminScope, name string) (org models.Org, err error) {
+	const op = "auth.CreateOrg"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("name", name))
+	log.Info("creating org")
+
+	if admin.OrgID != 0 {
+		log.Warn("org admin tried to create an org")
+		return models.Org{}, fmt.Errorf("%s: %w", op, ErrOutOfScope)
+	}
+	org = models.Org{Name: strings.TrimSpace(name), CreatedAt: time.Now()}
+	if org.Name == "" {
+		return models.Org{}, fmt.Errorf("%s: %w: name is required", op, ErrInvalidOrg)
+	}
+	org.ID, err = a.orgs.SaveOrg(ctx, org)
+	if err != nil {
+		if errors.Is(err, storage.ErrOrgAlreadyExists) {
+			log.Warn("org already exists", sl.Err(err))
+			return models.Org{}, fmt.Errorf("%s: %w", op, ErrOrgExists)
+		}
+		log.Error("failed to save org", sl.Err(err))
+		return models.Org{}, fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditOrgCreated, Detail: orgAuditDetail(org.ID)})
+	return org, nil
+}
+
+// ListOrgs returns the orgs the admin manages.
+func (a *Auth) ListOrgs(ctx context.Context, admin models.AdminScope) (orgs []models.Org, err error) {
+	const op = "auth.ListOrgs"
+	log := a.log.With(slog.String("op", op))
+
+	if admin.OrgID != 0 {
+		org, err := a.orgs.Org(ctx, admin.OrgID)
+		if err != nil {
+			log.Error("failed to get org", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return []models.Org{org}, nil
+	}
+	orgs, err = a.orgs.Orgs(ctx)
+	if err != nil {
+		log.Error("failed to get orgs", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return orgs, nil
+}
+
+// scopedApp returns the app if the admin manages its org. Apps of other orgs
+// are reported as not found, so they can't be probed.
+func (a *Auth) scopedApp(ctx context.Context, log *slog.Logger, admin models.AdminScope, appID int32) (models.App, error) {
+	app, err := a.AppProvider.App(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
+			return models.App{}, ErrInvalidAppId
+		}
+		log.Error("failed to get app", sl.Err(err))
+		return models.App{}, err
+	}
+	if !admin.CoversApp(app) {
+		log.Warn("app is outside of the admin's scope", slog.Int64("orgID", app.OrgID))
+		return models.App{}, ErrInvalidAppId
+	}
+	return app, nil
+}
+
+// scopedUser is scopedApp for users.
+func (a *Auth) scopedUser(ctx context.Context, log *slog.Logger, admin models.AdminScope, userID int64) (models.User, error) {
+	user, err := a.usrProvider.UserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return models.User{}, ErrUserNotFound
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return models.User{}, err
+	}
+	if !admin.CoversOrg(user.OrgID) {
+		log.Warn("user is outside of the admin's org", slog.Int64("orgID", user.OrgID))
+		return models.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// checkOrg makes sure the admin may create things in the org and that it exists.
+func (a *Auth) checkOrg(ctx context.Context, log *slog.Logger, admin models.AdminScope, orgID int64) error {
+	if err := checkManagesOrg(log, admin); err != nil {
+		return err
+	}
+	if !admin.CoversOrg(orgID) {
+		log.Warn("org is outside of the admin's org", slog.Int64("orgID", orgID))
+		return ErrOutOfScope
+	}
+	if _, err := a.orgs.Org(ctx, orgID); err != nil {
+		if errors.Is(err, storage.ErrOrgNotFound) {
+			log.Warn("org not found", sl.Err(err))
+			return ErrOrgNotFound
+		}
+		log.Error("failed to get org", sl.Err(err))
+		return err
+	}
+	return nil
+}
+
+// checkManagesOrg refuses admins of single apps, who may only read their org's
+// users and manage their apps.
+func checkManagesOrg(log *slog.Logger, admin models.AdminScope) error {
+	if !admin.ManagesOrg() {
+		log.Warn("app admin tried to manage the org")
+		return ErrOutOfScope
+	}
+	return nil
+}
+
+// ownOrg fills in the admin's own org when orgID is zero.
+func ownOrg(admin models.AdminScope, orgID int64) int64 {
+	switch {
+	case orgID != 0:
+		return orgID
+	case admin.OrgID != 0:
+		return admin.OrgID
+	}
+	return models.DefaultOrgID
+}
+
+func orgAuditDetail(orgID int64) string {
+	return "org_id=" + strconv.FormatInt(orgID, 10)
+}
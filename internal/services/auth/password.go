@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy is enforced whenever a password is set. Zero values disable the respective rule.
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// ChangePassword replaces the password of a user who proved to know the current one.
+// With revokeOtherSessions every refresh and access token of the user is revoked and
+// a fresh pair for appID is returned, so the caller stays logged in.
+func (a *Auth) ChangePassword(
+	ctx context.Context,
+	userID int64,
+	appID int32,
+	currentPassword string,
+	newPassword string,
+	revokeOtherSessions bool,
+) (tokens models.TokenPair, err error) {
+	const op = "auth.ChangePassword"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+	log.Info("changing password")
+
+	user, err := a.usrProvider.UserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := a.hasher.Compare(user.PassHash, currentPassword); err != nil {
+		log.Warn("invalid current password", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+	if newPassword == currentPassword {
+		return models.TokenPair{}, fmt.Errorf("%s: %w: must differ from the current password", op, ErrWeakPassword)
+	}
+	if err := a.passwordPolicy.check(newPassword); err != nil {
+		log.Warn("new password rejected", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	passHash, err := a.hasher.Hash(newPassword)
+	if err != nil {
+		log.Error("failed to generate password hash", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.usrSaver.ChangePassHash(ctx, user.ID, user.PassHash, passHash); err != nil {
+		if errors.Is(err, storage.ErrPassHashChanged) {
+			log.Warn("password was changed concurrently", sl.Err(err))
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrPasswordChanged)
+		}
+		log.Error("failed to save password", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("password changed")
+	if !revokeOtherSessions {
+		return models.TokenPair{}, nil
+	}
+
+	if err := a.revokedStorage.RevokeUserTokens(ctx, user.ID, time.Now()); err != nil {
+		log.Error("failed to revoke user tokens", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	app, err := a.AppProvider.App(ctx, appID)
+	if err != nil {
+		log.Error("failed to get app", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	tokens, err = a.issueTokens(ctx, user, app, "")
+	if err != nil {
+		log.Error("failed to generate tokens", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("other sessions revoked")
+	return tokens, nil
+}
+
+// check returns an error wrapping ErrWeakPassword that names the first violated rule.
+func (p PasswordPolicy) check(password string) error {
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters long", ErrWeakPassword, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("%w: must be at most %d characters long", ErrWeakPassword, p.MaxLength)
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	switch {
+	case p.RequireUpper && !upper:
+		return fmt.Errorf("%w: must contain an upper case letter", ErrWeakPassword)
+	case p.RequireLower && !lower:
+		return fmt.Errorf("%w: must contain a lower case letter", ErrWeakPassword)
+	case p.RequireDigit && !digit:
+		return fmt.Errorf("%w: must contain a digit", ErrWeakPassword)
+	case p.RequireSymbol && !symbol:
+		return fmt.Errorf("%w: must contain a symbol", ErrWeakPassword)
+	}
+	return nil
+}
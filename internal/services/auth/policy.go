@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/policy"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strconv"
+	"time"
+)
+
+// PutPolicy saves the rules as the next version of the app's policy. The new
+// version takes effect at once; older ones stay readable.
+func (a *Auth) PutPolicy(ctx context.Context, admin models.AdminScope, appID int32, rules []models.PolicyRule) (saved models.Policy, err error) {
+	const op = "auth.PutPolicy"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)))
+	log.Info("saving policy")
+
+	if err := a.checkPolicyRules(rules); err != nil {
+		log.Warn("invalid policy", sl.Err(err))
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := a.scopedApp(ctx, log, admin, appID); err != nil {
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+	saved = models.Policy{
+		AppID:     int(appID),
+		Rules:     rules,
+		CreatedBy: admin.UserID,
+		CreatedAt: time.Now(),
+	}
+	saved.Version, err = a.policies.SavePolicy(ctx, saved)
+	if err != nil {
+		log.Error("failed to save policy", sl.Err(err))
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{
+		UserID: admin.UserID,
+		Event:  models.AuditPolicyUpdated,
+		Detail: appAuditDetail(saved.AppID) + " version=" + strconv.Itoa(saved.Version),
+	})
+	return saved, nil
+}
+
+// GetPolicy returns the given version of the app's policy, or the one in effect for version 0.
+func (a *Auth) GetPolicy(ctx context.Context, admin models.AdminScope, appID int32, version int) (models.Policy, error) {
+	const op = "auth.GetPolicy"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)),
+		slog.Int("version", version))
+
+	if _, err := a.scopedApp(ctx, log, admin, appID); err != nil {
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+	p, err := a.policies.Policy(ctx, int(appID), version)
+	if err != nil {
+		if errors.Is(err, storage.ErrPolicyNotFound) {
+			log.Warn("policy not found", sl.Err(err))
+			return models.Policy{}, fmt.Errorf("%s: %w", op, ErrPolicyNotFound)
+		}
+		log.Error("failed to get policy", sl.Err(err))
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return p, nil
+}
+
+// ListPolicyVersions returns every version of the app's policy, newest first.
+func (a *Auth) ListPolicyVersions(ctx context.Context, admin models.AdminScope, appID int32) (policies []models.Policy, err error) {
+	const op = "auth.ListPolicyVersions"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)))
+
+	if _, err := a.scopedApp(ctx, log, admin, appID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	policies, err = a.policies.PolicyVersions(ctx, int(appID))
+	if err != nil {
+		log.Error("failed to get policy versions", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return policies, nil
+}
+
+// Authorize evaluates the policy of the app the token was issued for. The
+// subject is built from the token's user and claims; context.time is always
+// the server's clock. Apps without a policy deny everything.
+func (a *Auth) Authorize(
+	ctx context.Context,
+	token string,
+	action string,
+	resource map[string]any,
+	requestContext map[string]any,
+) (decision models.Decision, err error) {
+	const op = "auth.Authorize"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("action", action))
+
+	claims, err := a.ValidateToken(ctx, token, 0)
+	if err != nil {
+		return models.Decision{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if claims.Client {
+		log.Warn("client token can't be authorized", slog.Int("appID", claims.AppID))
+		return models.Decision{}, fmt.Errorf("%s: %w", op, ErrClientToken)
+	}
+	log = log.With(
+		slog.Int64("userID", claims.UserID),
+		slog.Int("appID", claims.AppID))
+	user, err := a.usrProvider.UserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("token owner not found", sl.Err(err))
+			return models.Decision{}, fmt.Errorf("%s: %w", op, ErrTokenRevoked)
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return models.Decision{}, fmt.Errorf("%s: %w", op, err)
+	}
+	p, err := a.policies.Policy(ctx, claims.AppID, 0)
+	if err != nil {
+		if errors.Is(err, storage.ErrPolicyNotFound) {
+			log.Info("app has no policy, denying")
+			return models.Decision{Reason: "app has no policy"}, nil
+		}
+		log.Error("failed to get policy", sl.Err(err))
+		return models.Decision{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	input := policy.Input{
+		Subject: map[string]any{
+			"id":             user.ID,
+			"org_id":         user.OrgID,
+			"email":          user.Email,
+			"email_verified": user.EmailVerified,
+			"is_admin":       user.IsAdmin,
+			"created_at":     user.CreatedAt,
+			"roles":          orEmptyStrings(claims.Roles),
+			"permissions":    orEmptyStrings(claims.Permissions),
+		},
+		Action:   action,
+		Resource: resource,
+		Context:  maps.Clone(requestContext),
+	}
+	if input.Context == nil {
+		input.Context = make(map[string]any)
+	}
+	input.Context["time"] = time.Now()
+	decision, err = a.policyEngine.Evaluate(p, input)
+	if err != nil {
+		log.Error("failed to evaluate policy", sl.Err(err))
+		return models.Decision{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("policy evaluated",
+		slog.Bool("allowed", decision.Allowed),
+		slog.String("rule", decision.Rule),
+		slog.Int("version", decision.PolicyVersion),
+		slog.String("reason", decision.Reason))
+	return decision, nil
+}
+
+// checkPolicyRules requires unique rule names, a known effect and conditions that compile.
+func (a *Auth) checkPolicyRules(rules []models.PolicyRule) error {
+	if len(rules) == 0 {
+		return fmt.Errorf("%w: no rules", ErrInvalidPolicy)
+	}
+	names := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if rule.Name == "" || names[rule.Name] {
+			return fmt.Errorf("%w: rule names must be unique and not empty", ErrInvalidPolicy)
+		}
+		names[rule.Name] = true
+		if rule.Effect != models.PolicyAllow && rule.Effect != models.PolicyDeny {
+			return fmt.Errorf("%w: rule %q has unknown effect %q", ErrInvalidPolicy, rule.Name, rule.Effect)
+		}
+	}
+	if err := a.policyEngine.Check(rules); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPolicy, err)
+	}
+	return nil
+}
+
+// orEmptyStrings keeps list attributes lists in policies when a claim is absent.
+func orEmptyStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
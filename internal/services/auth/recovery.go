@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/totp"
+	"AuthGRPC/internal/storage"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recoveryCodeLength is the number of base32 characters; they encode 48 random bits.
+const recoveryCodeLength = 10
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// RegenerateRecoveryCodes replaces every recovery code of the user with a new set.
+// A valid TOTP code is required, and too many wrong ones lock the user's MFA codes.
+func (a *Auth) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string, clientIP string) (recoveryCodes []string, err error) {
+	const op = "auth.RegenerateRecoveryCodes"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+	log.Info("regenerating recovery codes")
+
+	mfa, err := a.mfaStorage.MFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			log.Warn("mfa not enrolled", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, ErrMFANotEnrolled)
+		}
+		log.Error("failed to get mfa", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !mfa.Enabled {
+		log.Warn("mfa not enabled")
+		return nil, fmt.Errorf("%s: %w", op, ErrMFANotEnrolled)
+	}
+	if err := a.checkMFALockout(ctx, userID); err != nil {
+		log.Warn("mfa codes are locked", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.verifyTOTP(ctx, mfa, code); err != nil {
+		log.Warn("invalid mfa code", sl.Err(err))
+		if errors.Is(err, ErrInvalidMFACode) {
+			a.registerMFACodeFailure(ctx, log, userID)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	a.resetMFACodeFailures(ctx, log, userID)
+	recoveryCodes, err = a.newRecoveryCodes(ctx, userID)
+	if err != nil {
+		log.Error("failed to generate recovery codes", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{
+		UserID: userID,
+		Event:  models.AuditRecoveryCodesRegenerated,
+		IP:     clientIP,
+	})
+	log.Info("recovery codes regenerated")
+	return recoveryCodes, nil
+}
+
+// RecoveryCodesRemaining returns how many unused recovery codes the user has left.
+func (a *Auth) RecoveryCodesRemaining(ctx context.Context, userID int64) (remaining int, err error) {
+	const op = "auth.RecoveryCodesRemaining"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+
+	codes, err := a.recoveryCodes.RecoveryCodes(ctx, userID)
+	if err != nil {
+		log.Error("failed to get recovery codes", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return len(codes), nil
+}
+
+// newRecoveryCodes generates a new set, stores its MACs and returns the codes in
+// the form they are shown to the user.
+func (a *Auth) newRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes := make([]string, 0, a.mfa.RecoveryCodes)
+	hashes := make([][]byte, 0, a.mfa.RecoveryCodes)
+	for i := 0; i < a.mfa.RecoveryCodes; i++ {
+		raw := make([]byte, recoveryCodeLength*5/8)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(recoveryEncoding.EncodeToString(raw))
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		hashes = append(hashes, a.recoveryCodeHash(userID, code))
+	}
+	if err := a.recoveryCodes.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifyMFACode accepts either a TOTP code or an unused recovery code.
+func (a *Auth) verifyMFACode(ctx context.Context, log *slog.Logger, mfa models.MFA, code string, clientIP string) error {
+	if isTOTPCode(code) {
+		return a.verifyTOTP(ctx, mfa, code)
+	}
+	return a.useRecoveryCode(ctx, log, mfa.UserID, code, clientIP)
+}
+
+func (a *Auth) useRecoveryCode(ctx context.Context, log *slog.Logger, userID int64, code string, clientIP string) error {
+	code = normalizeRecoveryCode(code)
+	if len(code) != recoveryCodeLength {
+		return ErrInvalidMFACode
+	}
+	codes, err := a.recoveryCodes.RecoveryCodes(ctx, userID)
+	if err != nil {
+		return err
+	}
+	hash := a.recoveryCodeHash(userID, code)
+	for _, stored := range codes {
+		if !a.matchesRecoveryCode(stored.CodeHash, hash, code) {
+			continue
+		}
+		if err := a.recoveryCodes.UseRecoveryCode(ctx, stored.ID, time.Now()); err != nil {
+			if errors.Is(err, storage.ErrRecoveryCodeUsed) {
+				return ErrInvalidMFACode
+			}
+			return err
+		}
+		a.audit(ctx, log, models.AuditEvent{
+			UserID: userID,
+			Event:  models.AuditRecoveryCodeUsed,
+			IP:     clientIP,
+			Detail: "remaining=" + strconv.Itoa(len(codes)-1),
+		})
+		return nil
+	}
+	return ErrInvalidMFACode
+}
+
+// recoveryCodeHash binds the MAC to the user, so equal codes of two users
+// don't have equal hashes.
+func (a *Auth) recoveryCodeHash(userID int64, code string) []byte {
+	return a.secrets.MAC([]byte(strconv.FormatInt(userID, 10) + ":" + code))
+}
+
+// matchesRecoveryCode compares the code with a stored hash. Sets generated
+// before recovery codes were MACed hold password hashes; they keep working
+// until the user regenerates them.
+func (a *Auth) matchesRecoveryCode(stored []byte, hash []byte, code string) bool {
+	if bytes.HasPrefix(stored, []byte("$")) {
+		_, err := a.hasher.Compare(stored, code)
+		return err == nil
+	}
+	return hmac.Equal(stored, hash)
+}
+
+// audit records the event. The action it describes already happened, so failures are only logged.
+func (a *Auth) audit(ctx context.Context, log *slog.Logger, event models.AuditEvent) {
+	event.CreatedAt = time.Now()
+	log.Info("audit",
+		slog.String("event", event.Event),
+		slog.Int64("userID", event.UserID),
+		slog.String("ip", event.IP),
+		slog.String("detail", event.Detail))
+	if err := a.auditLog.SaveAuditEvent(ctx, event); err != nil {
+		log.Error("failed to save audit event", sl.Err(err))
+	}
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+	return strings.ToLower(code)
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/jwt"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/opaque"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Refresh exchanges a refresh token for a new access/refresh pair. Every refresh
+// token can be used once; presenting a used one again revokes its whole family.
+func (a *Auth) Refresh(ctx context.Context, refreshToken string) (tokens models.TokenPair, err error) {
+	const op = "auth.Refresh"
+	log := a.log.With(slog.String("op", op))
+	log.Info("refreshing tokens")
+
+	stored, err := a.tokenStorage.RefreshToken(ctx, opaque.Hash(refreshToken))
+	if err != nil {
+		if errors.Is(err, storage.ErrRefreshTokenNotFound) {
+			log.Warn("refresh token not found", sl.Err(err))
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+		log.Error("failed to get refresh token", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.Int64("userID", stored.UserID), slog.String("family", stored.FamilyID))
+	if stored.Revoked {
+		log.Warn("refresh token revoked")
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+	}
+	if stored.Used {
+		return models.TokenPair{}, a.revokeReusedFamily(ctx, log, op, stored.FamilyID)
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		log.Warn("refresh token expired")
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+	}
+	if err := a.tokenStorage.UseRefreshToken(ctx, stored.ID); err != nil {
+		if errors.Is(err, storage.ErrRefreshTokenUsed) {
+			return models.TokenPair{}, a.revokeReusedFamily(ctx, log, op, stored.FamilyID)
+		}
+		log.Error("failed to mark refresh token as used", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := a.usrProvider.UserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.Disabled {
+		log.Warn("user is disabled", slog.Int64("userID", user.ID))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+	}
+	app, err := a.AppProvider.App(ctx, int32(stored.AppID))
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.checkAppAccess(ctx, log, user, app); err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	tokens, err = a.issueTokens(ctx, user, app, stored.FamilyID)
+	if err != nil {
+		log.Error("failed to generate tokens", sl.Err(err))
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("tokens refreshed")
+	return tokens, nil
+}
+
+func (a *Auth) revokeReusedFamily(ctx context.Context, log *slog.Logger, op string, familyID string) error {
+	log.Warn("refresh token reuse detected, revoking token family")
+	if err := a.tokenStorage.RevokeRefreshTokenFamily(ctx, familyID); err != nil {
+		log.Error("failed to revoke token family", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return fmt.Errorf("%s: %w", op, ErrRefreshTokenReused)
+}
+
+// issueTokens mints an access token and a refresh token. An empty familyID
+// starts a new refresh token family, e.g. on login.
+func (a *Auth) issueTokens(ctx context.Context, user models.User, app models.App, familyID string) (models.TokenPair, error) {
+	key, err := a.signingKey(ctx, app)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	roles, permissions, err := a.userAccess(ctx, user.ID, app.ID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	accessToken, err := jwt.NewToken(user, app, key, a.oauth.Issuer, roles, permissions, a.tokenTTL)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	if familyID == "" {
+		if familyID, err = opaque.New(); err != nil {
+			return models.TokenPair{}, err
+		}
+	}
+	refreshToken, err := opaque.New()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	err = a.tokenStorage.SaveRefreshToken(ctx, models.RefreshToken{
+		TokenHash: opaque.Hash(refreshToken),
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		AppID:     app.ID,
+		ExpiresAt: time.Now().Add(a.refreshTokenTTL),
+	})
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	return models.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresIn: a.tokenTTL}, nil
+}
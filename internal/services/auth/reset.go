@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/mailer"
+	"AuthGRPC/internal/lib/opaque"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// PasswordReset configures the password reset emails.
+type PasswordReset struct {
+	TokenTTL time.Duration
+	// URL is the page users open to choose a new password; the token is appended
+	// as the "token" query parameter. When empty the email only holds the code.
+	URL string
+}
+
+// RequestPasswordReset emails a reset token to the user and invalidates the previous
+// ones. It succeeds for unknown emails too, so it can't be used to probe which
+// addresses are registered. The email is looked up in the org of the app,
+// or the default org for an appID of zero.
+func (a *Auth) RequestPasswordReset(ctx context.Context, email string, appID int32) error {
+	const op = "auth.RequestPasswordReset"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("email", email),
+		slog.Int("appID", int(appID)))
+	log.Info("requesting password reset")
+
+	orgID, err := a.appOrg(ctx, log, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	user, err := a.usrProvider.User(ctx, orgID, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return nil
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.emailTokens.DeleteEmailTokens(ctx, user.ID, models.EmailTokenResetPassword); err != nil {
+		log.Error("failed to invalidate reset tokens", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	token, err := a.newEmailToken(ctx, user.ID, models.EmailTokenResetPassword, a.passwordReset.TokenTTL)
+	if err != nil {
+		log.Error("failed to create reset token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	body := "Use this code to reset your password: " + token + "\n"
+	if link := linkWithToken(a.passwordReset.URL, token); link != "" {
+		body += "\nOr open this link: " + link + "\n"
+	}
+	body += fmt.Sprintf("\nThe code expires in %s. If you didn't ask to reset your password, ignore this email.\n",
+		a.passwordReset.TokenTTL)
+	err = a.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    body,
+	})
+	if err != nil {
+		log.Error("failed to send reset email", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("password reset email sent")
+	return nil
+}
+
+// ConfirmPasswordReset sets a new password for the owner of the reset token and
+// revokes every refresh and access token the user holds.
+func (a *Auth) ConfirmPasswordReset(ctx context.Context, token string, newPassword string) error {
+	const op = "auth.ConfirmPasswordReset"
+	log := a.log.With(slog.String("op", op))
+	log.Info("resetting password")
+	if err := a.passwordPolicy.check(newPassword); err != nil {
+		log.Warn("new password rejected", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	stored, err := a.emailTokens.UseEmailToken(ctx, opaque.Hash(token), models.EmailTokenResetPassword, time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmailTokenNotFound) {
+			log.Warn("reset token not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+		}
+		log.Error("failed to use reset token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.Int64("userID", stored.UserID))
+	user, err := a.usrProvider.UserByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	passHash, err := a.hasher.Hash(newPassword)
+	if err != nil {
+		log.Error("failed to generate password hash", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.usrSaver.UpdatePassHash(ctx, user.ID, passHash); err != nil {
+		log.Error("failed to save password", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.revokedStorage.RevokeUserTokens(ctx, user.ID, time.Now()); err != nil {
+		log.Error("failed to revoke user tokens", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	// Whoever reset the password may still be locked out by the attempts that
+	// made them reset it.
+	a.resetLoginFailures(ctx, log, user.OrgID, user.Email)
+	log.Info("password reset")
+	return nil
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/opaque"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// RevokeToken puts the access token on the denylist until it expires.
+// Revoking an expired or already revoked token is a no-op.
+func (a *Auth) RevokeToken(ctx context.Context, token string) error {
+	const op = "auth.RevokeToken"
+	log := a.log.With(slog.String("op", op))
+	log.Info("revoking token")
+
+	claims, err := a.parseToken(ctx, log, token, 0)
+	if err != nil {
+		if errors.Is(err, ErrTokenExpired) {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.revokeClaims(ctx, log, claims); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("token revoked")
+	return nil
+}
+
+// Logout revokes the access token and, when given, the refresh token family
+// it was issued with.
+func (a *Auth) Logout(ctx context.Context, token string, refreshToken string) error {
+	const op = "auth.Logout"
+	log := a.log.With(slog.String("op", op))
+	log.Info("logging out")
+
+	claims, err := a.parseToken(ctx, log, token, 0)
+	switch {
+	case errors.Is(err, ErrTokenExpired):
+		// Nothing to revoke, but the session may still be ended via the refresh token.
+	case err != nil:
+		return fmt.Errorf("%s: %w", op, err)
+	default:
+		if err := a.revokeClaims(ctx, log, claims); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if refreshToken != "" {
+		stored, err := a.tokenStorage.RefreshToken(ctx, opaque.Hash(refreshToken))
+		if err != nil {
+			if errors.Is(err, storage.ErrRefreshTokenNotFound) {
+				log.Warn("refresh token not found", sl.Err(err))
+				return fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if claims.UserID != 0 && stored.UserID != claims.UserID {
+			log.Warn("refresh token belongs to another user")
+			return fmt.Errorf("%s: %w", op, ErrInvalidRefreshToken)
+		}
+		if err := a.tokenStorage.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+			log.Error("failed to revoke refresh tokens", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	log.Info("logged out")
+	return nil
+}
+
+func (a *Auth) revokeClaims(ctx context.Context, log *slog.Logger, claims models.TokenClaims) error {
+	if claims.ID == "" {
+		log.Warn("token has no jti and can't be revoked")
+		return ErrTokenMalformed
+	}
+	if err := a.revokedStorage.RevokeToken(ctx, claims.ID, claims.ExpiresAt); err != nil {
+		log.Error("failed to revoke token", sl.Err(err))
+		return err
+	}
+	return nil
+}
+
+// Cleanup removes data that is no longer needed once the tokens it refers to have
+// expired, seals signing keys saved in plain and rotates the ones that are due.
+// The signing keys are taken care of even when removing data failed.
+func (a *Auth) Cleanup(ctx context.Context) error {
+	const op = "auth.Cleanup"
+	log := a.log.With(slog.String("op", op))
+
+	err := a.deleteExpired(ctx, log)
+	if sealed, sealErr := a.sealPlainSigningKeys(ctx); sealErr != nil {
+		log.Error("failed to seal signing keys", sl.Err(sealErr))
+		err = errors.Join(err, sealErr)
+	} else if sealed > 0 {
+		log.Info("sealed signing keys", slog.Int64("keys", sealed))
+	}
+	if a.keyRotationInterval > 0 {
+		if rotateErr := a.rotateDueSigningKeys(ctx); rotateErr != nil {
+			log.Error("failed to rotate signing keys", sl.Err(rotateErr))
+			err = errors.Join(err, rotateErr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (a *Auth) deleteExpired(ctx context.Context, log *slog.Logger) error {
+	deleted, err := a.revokedStorage.DeleteExpiredRevokedTokens(ctx, time.Now())
+	if err != nil {
+		log.Error("failed to delete expired revoked tokens", sl.Err(err))
+		return err
+	}
+	retired, err := a.keyStorage.RetireExpiredSigningKeys(ctx, time.Now())
+	if err != nil {
+		log.Error("failed to retire signing keys", sl.Err(err))
+		return err
+	}
+	now := time.Now()
+	staleAttempts, err := a.attempts.DeleteStaleLoginAttempts(ctx, now, now.Add(-a.lockout.Window))
+	if err != nil {
+		log.Error("failed to delete stale login attempts", sl.Err(err))
+		return err
+	}
+	emailTokens, err := a.emailTokens.DeleteExpiredEmailTokens(ctx, now)
+	if err != nil {
+		log.Error("failed to delete expired email tokens", sl.Err(err))
+		return err
+	}
+	mfaChallenges, err := a.mfaStorage.DeleteExpiredMFAChallenges(ctx, now)
+	if err != nil {
+		log.Error("failed to delete expired mfa challenges", sl.Err(err))
+		return err
+	}
+	authCodes, err := a.oauthCodes.DeleteExpiredAuthorizationCodes(ctx, now)
+	if err != nil {
+		log.Error("failed to delete expired authorization codes", sl.Err(err))
+		return err
+	}
+	refreshTokens, err := a.tokenStorage.DeleteExpiredRefreshTokens(ctx, now)
+	if err != nil {
+		log.Error("failed to delete expired refresh tokens", sl.Err(err))
+		return err
+	}
+	log.Debug("cleanup finished",
+		slog.Int64("revokedTokens", deleted),
+		slog.Int64("retiredKeys", retired),
+		slog.Int64("loginAttempts", staleAttempts),
+		slog.Int64("emailTokens", emailTokens),
+		slog.Int64("mfaChallenges", mfaChallenges),
+		slog.Int64("authCodes", authCodes),
+		slog.Int64("refreshTokens", refreshTokens))
+	return nil
+}
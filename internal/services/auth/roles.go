@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PermissionAdmin is the only permission outside of apps. CheckPermission with
+// app id 0 and PermissionAdmin answers the same as IsAdmin.
+const PermissionAdmin = "admin"
+
+// CheckPermission reports whether one of the user's roles in the app grants the
+// permission. Disabled users and users of another org than the app's have no
+// permissions.
+func (a *Auth) CheckPermission(ctx context.Context, userID int64, appID int32, permission string) (allowed bool, err error) {
+	const op = "auth.CheckPermission"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID),
+		slog.Int("appID", int(appID)),
+		slog.String("permission", permission))
+	log.Info("checking permission")
+
+	user, err := a.usrProvider.UserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return false, fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.Disabled {
+		return false, nil
+	}
+	if appID == 0 {
+		return permission == PermissionAdmin && user.IsAdmin, nil
+	}
+	app, err := a.AppProvider.App(ctx, appID)
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
+			return false, fmt.Errorf("%s: %w", op, ErrInvalidAppId)
+		}
+		log.Error("failed to get app", sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if app.OrgID != user.OrgID {
+		return false, nil
+	}
+	_, permissions, err := a.userAccess(ctx, userID, int(appID))
+	if err != nil {
+		log.Error("failed to get user roles", sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return slices.Contains(permissions, permission), nil
+}
+
+// CreateRole defines a role for the app.
+func (a *Auth) CreateRole(ctx context.Context, admin models.AdminScope, role models.Role) (created models.Role, err error) {
+	const op = "auth.CreateRole"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", role.AppID),
+		slog.String("name", role.Name))
+	log.Info("creating role")
+
+	if err := checkRole(&role); err != nil {
+		log.Warn("invalid role", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := a.scopedApp(ctx, log, admin, int32(role.AppID)); err != nil {
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	role.ID, err = a.roles.SaveRole(ctx, role)
+	if err != nil {
+		if errors.Is(err, storage.ErrRoleAlreadyExists) {
+			log.Warn("role already exists", sl.Err(err))
+			return models.Role{}, fmt.Errorf("%s: %w", op, ErrRoleExists)
+		}
+		log.Error("failed to save role", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditRoleCreated, Detail: roleAuditDetail(role.ID)})
+	return role, nil
+}
+
+// ListRoles returns the roles defined by the app.
+func (a *Auth) ListRoles(ctx context.Context, admin models.AdminScope, appID int32) (roles []models.Role, err error) {
+	const op = "auth.ListRoles"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int("appID", int(appID)))
+
+	if _, err := a.scopedApp(ctx, log, admin, appID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	roles, err = a.roles.Roles(ctx, int(appID))
+	if err != nil {
+		log.Error("failed to get roles", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return roles, nil
+}
+
+// UpdateRole renames the role and replaces its permissions. Tokens issued
+// before keep the old claims until they are refreshed.
+func (a *Auth) UpdateRole(ctx context.Context, admin models.AdminScope, role models.Role) (updated models.Role, err error) {
+	const op = "auth.UpdateRole"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("roleID", role.ID))
+	log.Info("updating role")
+
+	if err := checkRole(&role); err != nil {
+		log.Warn("invalid role", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, _, err := a.scopedRole(ctx, log, admin, role.ID); err != nil {
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.roles.UpdateRole(ctx, role); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRoleNotFound):
+			log.Warn("role not found", sl.Err(err))
+			return models.Role{}, fmt.Errorf("%s: %w", op, ErrRoleNotFound)
+		case errors.Is(err, storage.ErrRoleAlreadyExists):
+			log.Warn("role name is taken", sl.Err(err))
+			return models.Role{}, fmt.Errorf("%s: %w", op, ErrRoleExists)
+		}
+		log.Error("failed to update role", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	updated, err = a.roles.Role(ctx, role.ID)
+	if err != nil {
+		log.Error("failed to get role", sl.Err(err))
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditRoleUpdated, Detail: roleAuditDetail(role.ID)})
+	return updated, nil
+}
+
+// DeleteRole removes the role and takes it away from every user.
+func (a *Auth) DeleteRole(ctx context.Context, admin models.AdminScope, roleID int64) error {
+	const op = "auth.DeleteRole"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("roleID", roleID))
+	log.Info("deleting role")
+
+	if _, _, err := a.scopedRole(ctx, log, admin, roleID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.roles.DeleteRole(ctx, roleID); err != nil {
+		if errors.Is(err, storage.ErrRoleNotFound) {
+			log.Warn("role not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrRoleNotFound)
+		}
+		log.Error("failed to delete role", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditRoleDeleted, Detail: roleAuditDetail(roleID)})
+	return nil
+}
+
+// AssignRole gives the role to the user. It shows up in their tokens for the
+// role's app from the next login or refresh on. The user has to belong to the
+// org of the role's app.
+func (a *Auth) AssignRole(ctx context.Context, admin models.AdminScope, userID int64, roleID int64) error {
+	const op = "auth.AssignRole"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID),
+		slog.Int64("roleID", roleID))
+	log.Info("assigning role")
+
+	if err := a.checkRoleAssignment(ctx, log, admin, userID, roleID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.roles.AssignRole(ctx, userID, roleID); err != nil {
+		log.Error("failed to assign role", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditRoleAssigned, Detail: roleAssignmentAuditDetail(userID, roleID)})
+	return nil
+}
+
+// UnassignRole takes the role away from the user. Tokens issued before keep
+// it until they are refreshed.
+func (a *Auth) UnassignRole(ctx context.Context, admin models.AdminScope, userID int64, roleID int64) error {
+	const op = "auth.UnassignRole"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID),
+		slog.Int64("roleID", roleID))
+	log.Info("unassigning role")
+
+	if err := a.checkRoleAssignment(ctx, log, admin, userID, roleID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.roles.UnassignRole(ctx, userID, roleID); err != nil {
+		log.Error("failed to unassign role", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditRoleUnassigned, Detail: roleAssignmentAuditDetail(userID, roleID)})
+	return nil
+}
+
+// checkRoleAssignment makes sure the admin manages both the user and the role
+// and that they belong to the same org.
+func (a *Auth) checkRoleAssignment(ctx context.Context, log *slog.Logger, admin models.AdminScope, userID int64, roleID int64) error {
+	user, err := a.scopedUser(ctx, log, admin, userID)
+	if err != nil {
+		return err
+	}
+	_, app, err := a.scopedRole(ctx, log, admin, roleID)
+	if err != nil {
+		return err
+	}
+	if user.OrgID != app.OrgID {
+		log.Warn("role belongs to another org", slog.Int64("userOrgID", user.OrgID), slog.Int64("appOrgID", app.OrgID))
+		return fmt.Errorf("%w: role belongs to another org than the user", ErrInvalidRole)
+	}
+	return nil
+}
+
+// scopedRole returns the role and its app if the admin manages the app's org.
+// Roles of other orgs are reported as not found.
+func (a *Auth) scopedRole(ctx context.Context, log *slog.Logger, admin models.AdminScope, roleID int64) (models.Role, models.App, error) {
+	role, err := a.roles.Role(ctx, roleID)
+	if err != nil {
+		if errors.Is(err, storage.ErrRoleNotFound) {
+			log.Warn("role not found", sl.Err(err))
+			return models.Role{}, models.App{}, ErrRoleNotFound
+		}
+		log.Error("failed to get role", sl.Err(err))
+		return models.Role{}, models.App{}, err
+	}
+	app, err := a.scopedApp(ctx, log, admin, int32(role.AppID))
+	if err != nil {
+		if errors.Is(err, ErrInvalidAppId) {
+			return models.Role{}, models.App{}, ErrRoleNotFound
+		}
+		return models.Role{}, models.App{}, err
+	}
+	return role, app, nil
+}
+
+// userAccess returns the names of the user's roles in the app and the sorted
+// union of the permissions they grant.
+func (a *Auth) userAccess(ctx context.Context, userID int64, appID int) (roles []string, permissions []string, err error) {
+	userRoles, err := a.roles.UserRoles(ctx, userID, appID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, role := range userRoles {
+		roles = append(roles, role.Name)
+		permissions = append(permissions, role.Permissions...)
+	}
+	slices.Sort(permissions)
+	return roles, slices.Compact(permissions), nil
+}
+
+// checkRole validates the role name and permissions and drops duplicate permissions.
+func checkRole(role *models.Role) error {
+	role.Name = strings.TrimSpace(role.Name)
+	if role.Name == "" || strings.ContainsAny(role.Name, " \t\r\n") {
+		return fmt.Errorf("%w: invalid name %q", ErrInvalidRole, role.Name)
+	}
+	for _, permission := range role.Permissions {
+		if permission == "" || strings.ContainsAny(permission, " \t\r\n") {
+			return fmt.Errorf("%w: invalid permission %q", ErrInvalidRole, permission)
+		}
+	}
+	role.Permissions = slices.Clone(role.Permissions)
+	slices.Sort(role.Permissions)
+	role.Permissions = slices.Compact(role.Permissions)
+	return nil
+}
+
+func roleAuditDetail(roleID int64) string {
+	return "role_id=" + strconv.FormatInt(roleID, 10)
+}
+
+func roleAssignmentAuditDetail(userID int64, roleID int64) string {
+	return userAuditDetail(userID) + " " + roleAuditDetail(roleID)
+}
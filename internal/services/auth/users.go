@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultUsersPageSize = 50
+	maxUsersPageSize     = 500
+)
+
+// ListUsers returns one page of the users matching the filter. filter.AfterID and
+// filter.Limit are set from pageToken and pageSize. nextPageToken is empty on the
+// last page. Admins of an org only see its users.
+func (a *Auth) ListUsers(ctx context.Context, admin models.AdminScope, filter models.UserFilter, pageSize int, pageToken string) (users []models.User, nextPageToken string, err error) {
+	const op = "auth.ListUsers"
+	log := a.log.With(slog.String("op", op))
+
+	if admin.OrgID != 0 {
+		if filter.OrgID != 0 && filter.OrgID != admin.OrgID {
+			log.Warn("org is outside of the admin's org", slog.Int64("orgID", filter.OrgID))
+			return nil, "", fmt.Errorf("%s: %w", op, ErrOutOfScope)
+		}
+		filter.OrgID = admin.OrgID
+	}
+	filter.AfterID = 0
+	if pageToken != "" {
+		filter.AfterID, err = strconv.ParseInt(pageToken, 10, 64)
+		if err != nil || filter.AfterID < 0 {
+			log.Warn("invalid page token", slog.String("pageToken", pageToken))
+			return nil, "", fmt.Errorf("%s: %w", op, ErrInvalidPageToken)
+		}
+	}
+	if pageSize <= 0 {
+		pageSize = defaultUsersPageSize
+	}
+	pageSize = min(pageSize, maxUsersPageSize)
+	// One extra row tells whether there is a next page.
+	filter.Limit = pageSize + 1
+	users, err = a.usrProvider.Users(ctx, filter)
+	if err != nil {
+		log.Error("failed to get users", sl.Err(err))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		nextPageToken = strconv.FormatInt(users[pageSize-1].ID, 10)
+	}
+	for i := range users {
+		users[i].PassHash = nil
+	}
+	return users, nextPageToken, nil
+}
+
+// GetUser returns the user without their password hash.
+func (a *Auth) GetUser(ctx context.Context, admin models.AdminScope, userID int64) (user models.User, err error) {
+	const op = "auth.GetUser"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+
+	user, err = a.scopedUser(ctx, log, admin, userID)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	user.PassHash = nil
+	return user, nil
+}
+
+// SetAdmin grants or revokes admin rights. Admins can't revoke their own.
+// The user becomes an admin of their own org.
+func (a *Auth) SetAdmin(ctx context.Context, admin models.AdminScope, userID int64, isAdmin bool) error {
+	const op = "auth.SetAdmin"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID),
+		slog.Bool("isAdmin", isAdmin))
+	log.Info("changing admin rights")
+
+	if err := checkManagesOrg(log, admin); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if userID == admin.UserID && !isAdmin {
+		log.Warn("admin tried to revoke their own rights")
+		return fmt.Errorf("%s: %w", op, ErrCannotModifySelf)
+	}
+	if _, err := a.scopedUser(ctx, log, admin, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.usrSaver.SetAdmin(ctx, userID, isAdmin); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.Error("failed to change admin rights", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	event := models.AuditUserAdminRevoked
+	if isAdmin {
+		event = models.AuditUserAdminGranted
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: event, Detail: userAuditDetail(userID)})
+	return nil
+}
+
+// SetUserDisabled disables or enables the user. Disabling also revokes every
+// token of the user, so enabling them again doesn't bring old sessions back.
+func (a *Auth) SetUserDisabled(ctx context.Context, admin models.AdminScope, userID int64, disabled bool) error {
+	const op = "auth.SetUserDisabled"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID),
+		slog.Bool("disabled", disabled))
+	log.Info("changing user status")
+
+	if err := checkManagesOrg(log, admin); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if userID == admin.UserID && disabled {
+		log.Warn("admin tried to disable themselves")
+		return fmt.Errorf("%s: %w", op, ErrCannotModifySelf)
+	}
+	if _, err := a.scopedUser(ctx, log, admin, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.usrSaver.SetUserDisabled(ctx, userID, disabled); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.Error("failed to change user status", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	event := models.AuditUserEnabled
+	if disabled {
+		event = models.AuditUserDisabled
+		if err := a.revokedStorage.RevokeUserTokens(ctx, userID, time.Now()); err != nil {
+			log.Error("failed to revoke user tokens", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: event, Detail: userAuditDetail(userID)})
+	return nil
+}
+
+// DeleteUser removes the user with their sessions and MFA enrollment. Their
+// tokens stop validating at once.
+func (a *Auth) DeleteUser(ctx context.Context, admin models.AdminScope, userID int64) error {
+	const op = "auth.DeleteUser"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.Int64("userID", userID))
+	log.Info("deleting user")
+
+	if err := checkManagesOrg(log, admin); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if userID == admin.UserID {
+		log.Warn("admin tried to delete themselves")
+		return fmt.Errorf("%s: %w", op, ErrCannotModifySelf)
+	}
+	if _, err := a.scopedUser(ctx, log, admin, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.usrSaver.DeleteUser(ctx, userID); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, ErrUserNotFound)
+		}
+		log.Error("failed to delete user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	a.audit(ctx, log, models.AuditEvent{UserID: admin.UserID, Event: models.AuditUserDeleted, Detail: userAuditDetail(userID)})
+	return nil
+}
+
+func userAuditDetail(userID int64) string {
+	return "user_id=" + strconv.FormatInt(userID, 10)
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/jwt"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ValidateToken verifies an access token against the app it was issued for and
+// returns its claims. A non-zero appID additionally requires the token to belong to that app.
+func (a *Auth) ValidateToken(ctx context.Context, token string, appID int32) (claims models.TokenClaims, err error) {
+	const op = "auth.ValidateToken"
+	log := a.log.With(slog.String("op", op))
+	log.Info("validating token")
+
+	claims, err = a.parseToken(ctx, log, token, appID)
+	if err != nil {
+		return models.TokenClaims{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if claims.ID != "" {
+		revoked, err := a.revokedStorage.IsTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			log.Error("failed to check token revocation", sl.Err(err))
+			return models.TokenClaims{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if revoked {
+			log.Warn("token is revoked", slog.String("jti", claims.ID))
+			return models.TokenClaims{}, fmt.Errorf("%s: %w", op, ErrTokenRevoked)
+		}
+	}
+	if claims.Client {
+		log.Info("client token is valid", slog.Int("appID", claims.AppID))
+		return claims, nil
+	}
+	user, err := a.usrProvider.UserByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("token owner not found", slog.Int64("userID", claims.UserID))
+			return models.TokenClaims{}, fmt.Errorf("%s: %w", op, ErrTokenRevoked)
+		}
+		log.Error("failed to get token owner", sl.Err(err))
+		return models.TokenClaims{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.Disabled {
+		log.Warn("token owner is disabled", slog.Int64("userID", claims.UserID))
+		return models.TokenClaims{}, fmt.Errorf("%s: %w", op, ErrTokenRevoked)
+	}
+	if claims.IssuedAt.Before(user.CreatedAt) {
+		log.Warn("token was issued before its owner was created", slog.Int64("userID", claims.UserID))
+		return models.TokenClaims{}, fmt.Errorf("%s: %w", op, ErrTokenRevoked)
+	}
+	if claims.IssuedAt.Before(user.TokensRevokedAt) {
+		log.Warn("token was issued before the user's tokens were revoked", slog.Int64("userID", claims.UserID))
+		return models.TokenClaims{}, fmt.Errorf("%s: %w", op, ErrTokenRevoked)
+	}
+	log.Info("token is valid", slog.Int64("userID", claims.UserID))
+	return claims, nil
+}
+
+// parseToken checks the token signature and expiry without consulting the denylist.
+func (a *Auth) parseToken(ctx context.Context, log *slog.Logger, token string, appID int32) (models.TokenClaims, error) {
+	tokenAppID, err := jwt.AppID(token)
+	if err != nil {
+		log.Warn("failed to read token app", sl.Err(err))
+		return models.TokenClaims{}, ErrTokenMalformed
+	}
+	if appID != 0 && int(appID) != tokenAppID {
+		log.Warn("token app mismatch", slog.Int("tokenAppID", tokenAppID))
+		return models.TokenClaims{}, ErrTokenAppMismatch
+	}
+	app, err := a.AppProvider.App(ctx, int32(tokenAppID))
+	if err != nil {
+		if errors.Is(err, storage.ErrAppNotFound) {
+			log.Warn("app not found", sl.Err(err))
+			return models.TokenClaims{}, ErrInvalidAppId
+		}
+		return models.TokenClaims{}, err
+	}
+	keys, err := a.verificationKeys(ctx, app)
+	if err != nil {
+		return models.TokenClaims{}, err
+	}
+	claims, err := jwt.Parse(token, signingAlg(app), keys)
+	if err != nil {
+		log.Warn("invalid token", sl.Err(err))
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
+			return models.TokenClaims{}, ErrTokenExpired
+		case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+			return models.TokenClaims{}, ErrTokenSignature
+		default:
+			return models.TokenClaims{}, ErrTokenMalformed
+		}
+	}
+	return claims, nil
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/lib/logger/sl"
+	"AuthGRPC/internal/lib/mailer"
+	"AuthGRPC/internal/lib/opaque"
+	"AuthGRPC/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// EmailVerification configures the verification emails sent on registration.
+type EmailVerification struct {
+	TokenTTL time.Duration
+	// URL is the page users open to verify their email; the token is appended
+	// as the "token" query parameter. When empty the email only holds the code.
+	URL string
+}
+
+// VerifyEmail marks the email of the token's owner as verified. Tokens are single-use.
+func (a *Auth) VerifyEmail(ctx context.Context, token string) (userID int64, err error) {
+	const op = "auth.VerifyEmail"
+	log := a.log.With(slog.String("op", op))
+	log.Info("verifying email")
+
+	stored, err := a.emailTokens.UseEmailToken(ctx, opaque.Hash(token), models.EmailTokenVerifyEmail, time.Now())
+	if err != nil {
+		if errors.Is(err, storage.ErrEmailTokenNotFound) {
+			log.Warn("verification token not found", sl.Err(err))
+			return 0, fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+		}
+		log.Error("failed to use verification token", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.Int64("userID", stored.UserID))
+	if err := a.usrSaver.SetEmailVerified(ctx, stored.UserID); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return 0, fmt.Errorf("%s: %w", op, ErrInvalidEmailToken)
+		}
+		log.Error("failed to mark email as verified", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("email verified")
+	return stored.UserID, nil
+}
+
+// ResendVerification sends a new verification email and invalidates the previous ones.
+// Unknown and already verified emails are silently ignored so the call can't be used
+// to probe which addresses are registered. The email is looked up like in
+// RequestPasswordReset.
+func (a *Auth) ResendVerification(ctx context.Context, email string, appID int32) error {
+	const op = "auth.ResendVerification"
+	log := a.log.With(
+		slog.String("op", op),
+		slog.String("email", email),
+		slog.Int("appID", int(appID)))
+	log.Info("resending verification email")
+
+	orgID, err := a.appOrg(ctx, log, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	user, err := a.usrProvider.User(ctx, orgID, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Warn("user not found", sl.Err(err))
+			return nil
+		}
+		log.Error("failed to get user", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if user.EmailVerified {
+		log.Info("email already verified")
+		return nil
+	}
+	if err := a.emailTokens.DeleteEmailTokens(ctx, user.ID, models.EmailTokenVerifyEmail); err != nil {
+		log.Error("failed to invalidate verification tokens", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := a.sendVerificationEmail(ctx, user); err != nil {
+		log.Error("failed to send verification email", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log.Info("verification email sent")
+	return nil
+}
+
+func (a *Auth) sendVerificationEmail(ctx context.Context, user models.User) error {
+	token, err := a.newEmailToken(ctx, user.ID, models.EmailTokenVerifyEmail, a.verification.TokenTTL)
+	if err != nil {
+		return err
+	}
+	body := "Use this code to verify your email address: " + token + "\n"
+	if link := linkWithToken(a.verification.URL, token); link != "" {
+		body += "\nOr open this link: " + link + "\n"
+	}
+	body += fmt.Sprintf("\nThe code expires in %s.\n", a.verification.TokenTTL)
+	return a.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body:    body,
+	})
+}
+
+func (a *Auth) newEmailToken(ctx context.Context, userID int64, purpose string, ttl time.Duration) (string, error) {
+	token, err := opaque.New()
+	if err != nil {
+		return "", err
+	}
+	err = a.emailTokens.SaveEmailToken(ctx, models.EmailToken{
+		TokenHash: opaque.Hash(token),
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// linkWithToken appends token to base as the "token" query parameter.
+func linkWithToken(base string, token string) string {
+	if base == "" {
+		return ""
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
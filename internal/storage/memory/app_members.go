@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"slices"
+)
+
+type appMember struct {
+	userID int64
+	appID  int
+}
+
+type membership struct {
+	isAdmin   bool
+	hasAccess bool
+}
+
+// SetAppAdmin makes the user an admin of the app, or takes it back, adding
+// them as a member of the app if they aren't one yet.
+func (s *Storage) SetAppAdmin(ctx context.Context, userID int64, appID int, isAdmin bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	member := appMember{userID: userID, appID: appID}
+	m := s.members[member]
+	m.isAdmin = isAdmin
+	s.members[member] = m
+	return nil
+}
+
+// AdminApps returns the IDs of the apps the user is an admin of.
+func (s *Storage) AdminApps(ctx context.Context, userID int64) (appIDs []int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for member, m := range s.members {
+		if member.userID == userID && m.isAdmin {
+			appIDs = append(appIDs, member.appID)
+		}
+	}
+	slices.Sort(appIDs)
+	return appIDs, nil
+}
+
+// SetAppAccess grants the user access to the app, or revokes it, adding them
+// as a member of the app if they aren't one yet.
+func (s *Storage) SetAppAccess(ctx context.Context, userID int64, appID int, hasAccess bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	member := appMember{userID: userID, appID: appID}
+	m := s.members[member]
+	m.hasAccess = hasAccess
+	s.members[member] = m
+	return nil
+}
+
+// HasAppAccess reports whether the user was granted access to the app or is
+// one of its admins.
+func (s *Storage) HasAppAccess(ctx context.Context, userID int64, appID int) (hasAccess bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.members[appMember{userID: userID, appID: appID}]
+	return m.hasAccess || m.isAdmin, nil
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SaveApp creates the app together with its redirect URIs. app.ID is ignored.
+func (s *Storage) SaveApp(ctx context.Context, app models.App) (appID int, err error) {
+	const op = "storage.memory.SaveApp"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.appTaken(app) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrAppAlreadyExists)
+	}
+	s.lastAppID++
+	app.ID = s.lastAppID
+	app.SecretSigning = false
+	s.apps[app.ID] = storedApp(app)
+	s.redirectURIs[app.ID] = sortedUnique(app.RedirectURIs)
+	return app.ID, nil
+}
+
+func (s *Storage) App(ctx context.Context, appId int32) (app models.App, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.apps[int(appId)]
+	if !ok {
+		return models.App{}, storage.ErrAppNotFound
+	}
+	return cloneApp(app), nil
+}
+
+func (s *Storage) Apps(ctx context.Context) (apps []models.App, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, app := range s.apps {
+		apps = append(apps, cloneApp(app))
+	}
+	slices.SortFunc(apps, func(a, b models.App) int { return cmp.Compare(a.ID, b.ID) })
+	return apps, nil
+}
+
+// UpdateApp replaces the settings and redirect URIs of the app. The secret is
+// left alone, it is only changed by UpdateAppSecret, and apps never change orgs.
+func (s *Storage) UpdateApp(ctx context.Context, app models.App) error {
+	const op = "storage.memory.UpdateApp"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.apps[app.ID]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	app.OrgID = current.OrgID
+	app.Secret = current.Secret
+	app.SecretSigning = current.SecretSigning
+	if s.appTaken(app) {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppAlreadyExists)
+	}
+	s.apps[app.ID] = storedApp(app)
+	s.redirectURIs[app.ID] = sortedUnique(app.RedirectURIs)
+	return nil
+}
+
+func (s *Storage) UpdateAppSecret(ctx context.Context, appID int, secret string) error {
+	const op = "storage.memory.UpdateAppSecret"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.apps[appID]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	app.Secret = secret
+	if s.appTaken(app) {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppAlreadyExists)
+	}
+	s.apps[appID] = app
+	return nil
+}
+
+// DeleteApp removes the app and everything issued for it.
+func (s *Storage) DeleteApp(ctx context.Context, appID int) error {
+	const op = "storage.memory.DeleteApp"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.apps[appID]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrAppNotFound)
+	}
+	for roleID, role := range s.roles {
+		if role.AppID == appID {
+			s.deleteRole(roleID)
+		}
+	}
+	delete(s.policies, appID)
+	for id, token := range s.refreshTokens {
+		if token.AppID == appID {
+			delete(s.refreshTokens, id)
+		}
+	}
+	s.signingKeys = slices.DeleteFunc(s.signingKeys, func(key models.SigningKey) bool { return key.AppID == appID })
+	for hash, challenge := range s.mfaChallenges {
+		if challenge.AppID == appID {
+			delete(s.mfaChallenges, hash)
+		}
+	}
+	delete(s.redirectURIs, appID)
+	for hash, code := range s.authCodes {
+		if code.AppID == appID {
+			delete(s.authCodes, hash)
+		}
+	}
+	for member := range s.members {
+		if member.appID == appID {
+			delete(s.members, member)
+		}
+	}
+	delete(s.apps, appID)
+	return nil
+}
+
+// appTaken reports whether another app uses the name in the app's org or the secret.
+func (s *Storage) appTaken(app models.App) bool {
+	for _, other := range s.apps {
+		if other.ID != app.ID && (other.OrgID == app.OrgID && other.Name == app.Name || other.Secret == app.Secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// storedApp is the app as the storage keeps it: redirect URIs are kept apart
+// and client scopes are normalized the way the sqlite storage stores them.
+func storedApp(app models.App) models.App {
+	app.RedirectURIs = nil
+	app.ClientScopes = strings.Fields(strings.Join(app.ClientScopes, " "))
+	return app
+}
+
+func cloneApp(app models.App) models.App {
+	app.ClientScopes = slices.Clone(app.ClientScopes)
+	return app
+}
+
+func sortedUnique(values []string) []string {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return slices.Compact(values)
+}
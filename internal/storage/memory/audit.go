@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"context"
+)
+
+func (s *Storage) SaveAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event.ID = int64(len(s.auditLog)) + 1
+	event.CreatedAt = unixTime(event.CreatedAt)
+	s.auditLog = append(s.auditLog, event)
+	return nil
+}
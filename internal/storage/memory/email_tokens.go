@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"fmt"
+	"time"
+)
+
+type emailToken struct {
+	models.EmailToken
+	used bool
+}
+
+func (s *Storage) SaveEmailToken(ctx context.Context, token models.EmailToken) error {
+	const op = "storage.memory.SaveEmailToken"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.emailTokens[token.TokenHash]; ok {
+		return fmt.Errorf("%s: email token already exists", op)
+	}
+	token.ExpiresAt = unixTime(token.ExpiresAt)
+	s.emailTokens[token.TokenHash] = emailToken{EmailToken: token}
+	return nil
+}
+
+// UseEmailToken marks an unused, unexpired token as used and returns it. Unknown,
+// used and expired tokens all fail with storage.ErrEmailTokenNotFound.
+func (s *Storage) UseEmailToken(ctx context.Context, tokenHash string, purpose string, now time.Time) (token models.EmailToken, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.emailTokens[tokenHash]
+	if !ok || stored.Purpose != purpose || stored.used || !stored.ExpiresAt.After(unixTime(now)) {
+		return models.EmailToken{}, storage.ErrEmailTokenNotFound
+	}
+	stored.used = true
+	s.emailTokens[tokenHash] = stored
+	return stored.EmailToken, nil
+}
+
+// DeleteEmailTokens invalidates every token of the user issued for purpose.
+func (s *Storage) DeleteEmailTokens(ctx context.Context, userID int64, purpose string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, token := range s.emailTokens {
+		if token.UserID == userID && token.Purpose == purpose {
+			delete(s.emailTokens, hash)
+		}
+	}
+	return nil
+}
+
+func (s *Storage) DeleteExpiredEmailTokens(ctx context.Context, now time.Time) (deleted int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, token := range s.emailTokens {
+		if token.used || !token.ExpiresAt.After(unixTime(now)) {
+			delete(s.emailTokens, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"context"
+	"time"
+)
+
+// LoginAttempts returns the failed login counter for key. Keys without failures
+// yield a zero counter rather than an error.
+func (s *Storage) LoginAttempts(ctx context.Context, key string) (attempts models.LoginAttempts, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts, ok := s.loginAttempts[key]
+	if !ok {
+		return models.LoginAttempts{Key: key}, nil
+	}
+	return attempts, nil
+}
+
+// AddLoginFailure atomically counts a failed login. Failures older than windowStart
+// are forgotten and the counter starts over.
+func (s *Storage) AddLoginFailure(ctx context.Context, key string, now time.Time, windowStart time.Time) (failures int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts, ok := s.loginAttempts[key]
+	if !ok || attempts.LastFailure.Before(unixTime(windowStart)) {
+		attempts.Key = key
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailure = unixTime(now)
+	s.loginAttempts[key] = attempts
+	return attempts.Failures, nil
+}
+
+func (s *Storage) LockLogin(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if attempts, ok := s.loginAttempts[key]; ok {
+		attempts.LockedUntil = unixTime(until)
+		s.loginAttempts[key] = attempts
+	}
+	return nil
+}
+
+func (s *Storage) DeleteLoginAttempts(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.loginAttempts, key)
+	return nil
+}
+
+// DeleteStaleLoginAttempts drops counters that are neither locked nor inside the failure window.
+func (s *Storage) DeleteStaleLoginAttempts(ctx context.Context, now time.Time, windowStart time.Time) (deleted int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, attempts := range s.loginAttempts {
+		if attempts.LastFailure.Before(unixTime(windowStart)) && attempts.LockedUntil.Before(unixTime(now)) {
+			delete(s.loginAttempts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,121 @@
+// Package memory implements the storage in the process memory. It behaves like
+// the sqlite storage, including its errors, but loses everything on restart,
+// which makes it suited for local demos and tests.
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+)
+
+type Storage struct {
+	mu sync.Mutex
+
+	orgs          map[int64]models.Org
+	users         map[int64]models.User
+	apps          map[int]models.App
+	redirectURIs  map[int][]string
+	refreshTokens map[int64]models.RefreshToken
+	revokedTokens map[string]time.Time
+	// signingKeys are kept in insertion order, which breaks ties of created_at.
+	signingKeys   []models.SigningKey
+	loginAttempts map[string]models.LoginAttempts
+	emailTokens   map[string]emailToken
+	mfa           map[int64]models.MFA
+	mfaChallenges map[string]models.MFAChallenge
+	recoveryCodes map[int64]recoveryCode
+	authCodes     map[string]authCode
+	roles         map[int64]models.Role
+	userRoles     map[userRole]struct{}
+	policies      map[int][]models.Policy
+	members       map[appMember]membership
+	auditLog      []models.AuditEvent
+
+	lastOrgID          int64
+	lastUserID         int64
+	lastAppID          int
+	lastRefreshTokenID int64
+	lastRecoveryCodeID int64
+	lastRoleID         int64
+}
+
+// Options seeds a new storage, which is empty otherwise. AppSecret is the secret
+// of the seeded app, random when empty. An admin of the default org is created
+// when AdminEmail is set, so the admin API can be used at all.
+type Options struct {
+	AppSecret     string
+	AdminEmail    string
+	AdminPassHash []byte
+}
+
+// New returns a storage holding what the migrations seed: the default org and
+// the test app, plus the admin of opts. Unlike after the migrations, the test
+// app doesn't sign tokens with its secret: there are no older tokens to keep
+// valid, and admin tokens have to be signed with a managed key.
+func New(opts Options) *Storage {
+	s := &Storage{
+		orgs:          make(map[int64]models.Org),
+		users:         make(map[int64]models.User),
+		apps:          make(map[int]models.App),
+		redirectURIs:  make(map[int][]string),
+		refreshTokens: make(map[int64]models.RefreshToken),
+		revokedTokens: make(map[string]time.Time),
+		loginAttempts: make(map[string]models.LoginAttempts),
+		emailTokens:   make(map[string]emailToken),
+		mfa:           make(map[int64]models.MFA),
+		mfaChallenges: make(map[string]models.MFAChallenge),
+		recoveryCodes: make(map[int64]recoveryCode),
+		authCodes:     make(map[string]authCode),
+		roles:         make(map[int64]models.Role),
+		userRoles:     make(map[userRole]struct{}),
+		policies:      make(map[int][]models.Policy),
+		members:       make(map[appMember]membership),
+	}
+	s.orgs[models.DefaultOrgID] = models.Org{ID: models.DefaultOrgID, Name: "default"}
+	s.lastOrgID = models.DefaultOrgID
+	secret := opts.AppSecret
+	if secret == "" {
+		secret = randomSecret()
+	}
+	s.apps[1] = models.App{ID: 1, OrgID: models.DefaultOrgID, Name: "test", Secret: secret, SigningAlg: "HS256"}
+	s.lastAppID = 1
+	if opts.AdminEmail != "" {
+		s.lastUserID = 1
+		s.users[1] = models.User{
+			ID:            1,
+			OrgID:         models.DefaultOrgID,
+			Email:         opts.AdminEmail,
+			PassHash:      slices.Clone(opts.AdminPassHash),
+			IsAdmin:       true,
+			EmailVerified: true,
+			CreatedAt:     unixTime(time.Now()),
+		}
+	}
+	return s
+}
+
+// Close is a no-op, there is nothing to release.
+func (s *Storage) Close() error {
+	return nil
+}
+
+// unixTime drops what the sqlite storage doesn't keep of timestamps stored in
+// unix seconds, so both storages compare times the same way.
+func unixTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	return time.Unix(t.Unix(), 0)
+}
+
+func randomSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/services/auth"
+	"AuthGRPC/internal/storage"
+	"AuthGRPC/internal/storage/storagetest"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestContract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) auth.Storage { return New(Options{}) })
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	s := New(Options{AppSecret: "seeded-secret", AdminEmail: "admin@example.com", AdminPassHash: []byte("hash")})
+
+	app, err := s.App(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "seeded-secret", app.Secret)
+	assert.False(t, app.SecretSigning)
+	admin, err := s.User(ctx, models.DefaultOrgID, "admin@example.com")
+	require.NoError(t, err)
+	assert.True(t, admin.IsAdmin)
+	assert.Equal(t, []byte("hash"), admin.PassHash)
+	uid, err := s.SaveUser(ctx, models.DefaultOrgID, "user@example.com", []byte("hash"))
+	require.NoError(t, err)
+	assert.Greater(t, uid, admin.ID)
+}
+
+func TestUsers(t *testing.T) {
+	ctx := context.Background()
+	s := New(Options{})
+
+	uid, err := s.SaveUser(ctx, models.DefaultOrgID, "user@example.com", []byte("hash"))
+	require.NoError(t, err)
+	_, err = s.SaveUser(ctx, models.DefaultOrgID, "user@example.com", []byte("hash"))
+	assert.ErrorIs(t, err, storage.ErrUserAlreadyExists)
+
+	user, err := s.User(ctx, models.DefaultOrgID, "user@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, uid, user.ID)
+	_, err = s.User(ctx, models.DefaultOrgID, "missing@example.com")
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+
+	assert.ErrorIs(t, s.ChangePassHash(ctx, uid, []byte("stale"), []byte("new")), storage.ErrPassHashChanged)
+	require.NoError(t, s.ChangePassHash(ctx, uid, []byte("hash"), []byte("new")))
+
+	users, err := s.Users(ctx, models.UserFilter{EmailPrefix: "USER"})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, []byte("new"), users[0].PassHash)
+
+	require.NoError(t, s.DeleteUser(ctx, uid))
+	_, err = s.UserByID(ctx, uid)
+	assert.ErrorIs(t, err, storage.ErrUserNotFound)
+	assert.ErrorIs(t, s.DeleteUser(ctx, uid), storage.ErrUserNotFound)
+}
+
+func TestApps(t *testing.T) {
+	ctx := context.Background()
+	s := New(Options{})
+
+	seeded, err := s.App(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "test", seeded.Name)
+	assert.NotEqual(t, "test-secret", seeded.Secret)
+
+	appID, err := s.SaveApp(ctx, models.App{OrgID: models.DefaultOrgID, Name: "demo", Secret: "demo-secret", RedirectURIs: []string{"http://b", "http://a", "http://a"}})
+	require.NoError(t, err)
+	_, err = s.SaveApp(ctx, models.App{OrgID: models.DefaultOrgID, Name: "demo", Secret: "other-secret"})
+	assert.ErrorIs(t, err, storage.ErrAppAlreadyExists)
+
+	uris, err := s.RedirectURIs(ctx, appID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://a", "http://b"}, uris)
+
+	roleID, err := s.SaveRole(ctx, models.Role{AppID: appID, Name: "editor", Permissions: []string{"docs.edit"}})
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteApp(ctx, appID))
+	_, err = s.App(ctx, int32(appID))
+	assert.ErrorIs(t, err, storage.ErrAppNotFound)
+	_, err = s.Role(ctx, roleID)
+	assert.ErrorIs(t, err, storage.ErrRoleNotFound)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	ctx := context.Background()
+	s := New(Options{})
+
+	require.NoError(t, s.ReplaceRecoveryCodes(ctx, 1, [][]byte{[]byte("a"), []byte("b")}))
+	codes, err := s.RecoveryCodes(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, codes, 2)
+
+	require.NoError(t, s.UseRecoveryCode(ctx, codes[0].ID, time.Now()))
+	assert.ErrorIs(t, s.UseRecoveryCode(ctx, codes[0].ID, time.Now()), storage.ErrRecoveryCodeUsed)
+	codes, err = s.RecoveryCodes(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, codes, 1)
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// SaveMFASecret stores a new, not yet confirmed enrollment, replacing a previous one.
+func (s *Storage) SaveMFASecret(ctx context.Context, userID int64, secret []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mfa[userID] = models.MFA{UserID: userID, Secret: slices.Clone(secret)}
+	return nil
+}
+
+func (s *Storage) MFA(ctx context.Context, userID int64) (mfa models.MFA, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mfa, ok := s.mfa[userID]
+	if !ok {
+		return models.MFA{}, storage.ErrMFANotFound
+	}
+	mfa.Secret = slices.Clone(mfa.Secret)
+	return mfa, nil
+}
+
+func (s *Storage) EnableMFA(ctx context.Context, userID int64) error {
+	const op = "storage.memory.EnableMFA"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mfa, ok := s.mfa[userID]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrMFANotFound)
+	}
+	mfa.Enabled = true
+	s.mfa[userID] = mfa
+	return nil
+}
+
+// UseMFAStep records that the code of step was accepted. It fails with
+// storage.ErrMFAStepUsed when a code of this or a later step was accepted before.
+func (s *Storage) UseMFAStep(ctx context.Context, userID int64, step int64) error {
+	const op = "storage.memory.UseMFAStep"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mfa, ok := s.mfa[userID]
+	if !ok || mfa.LastUsedStep >= step {
+		return fmt.Errorf("%s: %w", op, storage.ErrMFAStepUsed)
+	}
+	mfa.LastUsedStep = step
+	s.mfa[userID] = mfa
+	return nil
+}
+
+// DeleteMFA removes the enrollment together with its recovery codes.
+func (s *Storage) DeleteMFA(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.mfa, userID)
+	s.deleteRecoveryCodes(userID)
+	return nil
+}
+
+func (s *Storage) SaveMFAChallenge(ctx context.Context, challenge models.MFAChallenge) error {
+	const op = "storage.memory.SaveMFAChallenge"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.mfaChallenges[challenge.TokenHash]; ok {
+		return fmt.Errorf("%s: mfa challenge already exists", op)
+	}
+	challenge.ExpiresAt = unixTime(challenge.ExpiresAt)
+	challenge.Attempts = 0
+	s.mfaChallenges[challenge.TokenHash] = challenge
+	return nil
+}
+
+func (s *Storage) MFAChallenge(ctx context.Context, tokenHash string) (challenge models.MFAChallenge, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	challenge, ok := s.mfaChallenges[tokenHash]
+	if !ok {
+		return models.MFAChallenge{}, storage.ErrMFAChallengeNotFound
+	}
+	return challenge, nil
+}
+
+// AddMFAChallengeFailure counts a wrong code and returns the number of failures so far.
+func (s *Storage) AddMFAChallengeFailure(ctx context.Context, tokenHash string) (attempts int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	challenge, ok := s.mfaChallenges[tokenHash]
+	if !ok {
+		return 0, storage.ErrMFAChallengeNotFound
+	}
+	challenge.Attempts++
+	s.mfaChallenges[tokenHash] = challenge
+	return challenge.Attempts, nil
+}
+
+// DeleteMFAChallenge fails with storage.ErrMFAChallengeNotFound when the challenge
+// is already gone, so only one caller can complete it.
+func (s *Storage) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	const op = "storage.memory.DeleteMFAChallenge"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.mfaChallenges[tokenHash]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrMFAChallengeNotFound)
+	}
+	delete(s.mfaChallenges, tokenHash)
+	return nil
+}
+
+func (s *Storage) DeleteExpiredMFAChallenges(ctx context.Context, now time.Time) (deleted int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, challenge := range s.mfaChallenges {
+		if !challenge.ExpiresAt.After(unixTime(now)) {
+			delete(s.mfaChallenges, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
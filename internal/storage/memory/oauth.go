@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type authCode struct {
+	models.AuthorizationCode
+	used bool
+}
+
+func (s *Storage) RedirectURIs(ctx context.Context, appID int) (redirectURIs []string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.redirectURIs[appID]), nil
+}
+
+func (s *Storage) SaveAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error {
+	const op = "storage.memory.SaveAuthorizationCode"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.authCodes[code.CodeHash]; ok {
+		return fmt.Errorf("%s: authorization code already exists", op)
+	}
+	code.AuthTime = unixTime(code.AuthTime)
+	code.ExpiresAt = unixTime(code.ExpiresAt)
+	s.authCodes[code.CodeHash] = authCode{AuthorizationCode: code}
+	return nil
+}
+
+// UseAuthorizationCode marks an unused, unexpired code as used and returns it. Unknown,
+// used and expired codes all fail with storage.ErrAuthCodeNotFound.
+func (s *Storage) UseAuthorizationCode(ctx context.Context, codeHash string, now time.Time) (code models.AuthorizationCode, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.authCodes[codeHash]
+	if !ok || stored.used || !stored.ExpiresAt.After(unixTime(now)) {
+		return models.AuthorizationCode{}, storage.ErrAuthCodeNotFound
+	}
+	stored.used = true
+	s.authCodes[codeHash] = stored
+	return stored.AuthorizationCode, nil
+}
+
+func (s *Storage) DeleteExpiredAuthorizationCodes(ctx context.Context, now time.Time) (deleted int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, code := range s.authCodes {
+		if code.used || !code.ExpiresAt.After(unixTime(now)) {
+			delete(s.authCodes, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
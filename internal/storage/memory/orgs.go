@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+)
+
+func (s *Storage) SaveOrg(ctx context.Context, org models.Org) (orgID int64, err error) {
+	const op = "storage.memory.SaveOrg"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, other := range s.orgs {
+		if other.Name == org.Name {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrOrgAlreadyExists)
+		}
+	}
+	s.lastOrgID++
+	s.orgs[s.lastOrgID] = models.Org{ID: s.lastOrgID, Name: org.Name, CreatedAt: unixTime(org.CreatedAt)}
+	return s.lastOrgID, nil
+}
+
+func (s *Storage) Org(ctx context.Context, orgID int64) (org models.Org, err error) {
+	const op = "storage.memory.Org"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	org, ok := s.orgs[orgID]
+	if !ok {
+		return models.Org{}, fmt.Errorf("%s: %w", op, storage.ErrOrgNotFound)
+	}
+	return org, nil
+}
+
+func (s *Storage) Orgs(ctx context.Context) (orgs []models.Org, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, org := range s.orgs {
+		orgs = append(orgs, org)
+	}
+	slices.SortFunc(orgs, func(a, b models.Org) int { return cmp.Compare(a.ID, b.ID) })
+	return orgs, nil
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// SavePolicy stores the rules as the next version of the app's policy and
+// returns that version. policy.Version is ignored.
+func (s *Storage) SavePolicy(ctx context.Context, policy models.Policy) (version int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	policy.Version = len(s.policies[policy.AppID]) + 1
+	policy.Rules = slices.Clone(policy.Rules)
+	policy.CreatedAt = unixTime(policy.CreatedAt)
+	s.policies[policy.AppID] = append(s.policies[policy.AppID], policy)
+	return policy.Version, nil
+}
+
+// Policy returns the given version of the app's policy, or the newest one for version 0.
+func (s *Storage) Policy(ctx context.Context, appID int, version int) (policy models.Policy, err error) {
+	const op = "storage.memory.Policy"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions := s.policies[appID]
+	if version == 0 {
+		version = len(versions)
+	}
+	if version < 1 || version > len(versions) {
+		return models.Policy{}, fmt.Errorf("%s: %w", op, storage.ErrPolicyNotFound)
+	}
+	policy = versions[version-1]
+	policy.Rules = slices.Clone(policy.Rules)
+	return policy, nil
+}
+
+// PolicyVersions returns every version of the app's policy, newest first.
+func (s *Storage) PolicyVersions(ctx context.Context, appID int) (policies []models.Policy, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions := s.policies[appID]
+	for i := len(versions) - 1; i >= 0; i-- {
+		policy := versions[i]
+		policy.Rules = slices.Clone(policy.Rules)
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
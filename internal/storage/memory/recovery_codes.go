@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type recoveryCode struct {
+	models.RecoveryCode
+	usedAt time.Time
+}
+
+// ReplaceRecoveryCodes drops every recovery code of the user, used or not, and stores the new ones.
+func (s *Storage) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes [][]byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteRecoveryCodes(userID)
+	for _, codeHash := range codeHashes {
+		s.lastRecoveryCodeID++
+		s.recoveryCodes[s.lastRecoveryCodeID] = recoveryCode{RecoveryCode: models.RecoveryCode{
+			ID:       s.lastRecoveryCodeID,
+			UserID:   userID,
+			CodeHash: slices.Clone(codeHash),
+		}}
+	}
+	return nil
+}
+
+// RecoveryCodes returns the unused recovery codes of the user.
+func (s *Storage) RecoveryCodes(ctx context.Context, userID int64) (codes []models.RecoveryCode, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, code := range s.recoveryCodes {
+		if code.UserID == userID && code.usedAt.IsZero() {
+			codes = append(codes, models.RecoveryCode{ID: code.ID, UserID: code.UserID, CodeHash: slices.Clone(code.CodeHash)})
+		}
+	}
+	slices.SortFunc(codes, func(a, b models.RecoveryCode) int { return cmp.Compare(a.ID, b.ID) })
+	return codes, nil
+}
+
+// UseRecoveryCode marks the code as used. It fails with storage.ErrRecoveryCodeUsed
+// when it was used already, so one code can't complete two logins.
+func (s *Storage) UseRecoveryCode(ctx context.Context, id int64, now time.Time) error {
+	const op = "storage.memory.UseRecoveryCode"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.recoveryCodes[id]
+	if !ok || !code.usedAt.IsZero() {
+		return fmt.Errorf("%s: %w", op, storage.ErrRecoveryCodeUsed)
+	}
+	code.usedAt = now
+	s.recoveryCodes[id] = code
+	return nil
+}
+
+func (s *Storage) deleteRecoveryCodes(userID int64) {
+	for id, code := range s.recoveryCodes {
+		if code.UserID == userID {
+			delete(s.recoveryCodes, id)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	const op = "storage.memory.SaveRefreshToken"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, other := range s.refreshTokens {
+		if other.TokenHash == token.TokenHash {
+			return fmt.Errorf("%s: refresh token already exists", op)
+		}
+	}
+	s.lastRefreshTokenID++
+	s.refreshTokens[s.lastRefreshTokenID] = models.RefreshToken{
+		ID:        s.lastRefreshTokenID,
+		TokenHash: token.TokenHash,
+		FamilyID:  token.FamilyID,
+		UserID:    token.UserID,
+		AppID:     token.AppID,
+		ExpiresAt: unixTime(token.ExpiresAt),
+	}
+	return nil
+}
+
+func (s *Storage) RefreshToken(ctx context.Context, tokenHash string) (token models.RefreshToken, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, token := range s.refreshTokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return models.RefreshToken{}, storage.ErrRefreshTokenNotFound
+}
+
+// UseRefreshToken marks the token as used. It fails with storage.ErrRefreshTokenUsed
+// when the token has already been used or revoked, so concurrent refreshes
+// with the same token can't both succeed.
+func (s *Storage) UseRefreshToken(ctx context.Context, id int64) error {
+	const op = "storage.memory.UseRefreshToken"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.refreshTokens[id]
+	if !ok || token.Used || token.Revoked {
+		return fmt.Errorf("%s: %w", op, storage.ErrRefreshTokenUsed)
+	}
+	token.Used = true
+	s.refreshTokens[id] = token
+	return nil
+}
+
+func (s *Storage) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, token := range s.refreshTokens {
+		if token.FamilyID == familyID {
+			token.Revoked = true
+			s.refreshTokens[id] = token
+		}
+	}
+	return nil
+}
+
+// DeleteExpiredRefreshTokens removes refresh tokens that expired or were revoked.
+// Used tokens are kept until they expire, so their reuse is still detected.
+func (s *Storage) DeleteExpiredRefreshTokens(ctx context.Context, now time.Time) (deleted int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, token := range s.refreshTokens {
+		if token.ExpiresAt.Before(unixTime(now)) || token.Revoked {
+			delete(s.refreshTokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
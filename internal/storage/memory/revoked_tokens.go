@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+func (s *Storage) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.revokedTokens[jti]; !ok {
+		s.revokedTokens[jti] = unixTime(expiresAt)
+	}
+	return nil
+}
+
+func (s *Storage) IsTokenRevoked(ctx context.Context, jti string) (revoked bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, revoked = s.revokedTokens[jti]
+	return revoked, nil
+}
+
+// DeleteExpiredRevokedTokens drops denylist entries for tokens that would be rejected
+// as expired anyway.
+func (s *Storage) DeleteExpiredRevokedTokens(ctx context.Context, now time.Time) (deleted int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for jti, expiresAt := range s.revokedTokens {
+		if expiresAt.Before(unixTime(now)) {
+			delete(s.revokedTokens, jti)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
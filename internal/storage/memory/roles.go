@@ -0,0 +1,147 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+)
+
+type userRole struct {
+	userID int64
+	roleID int64
+}
+
+// SaveRole creates the role together with its permissions. role.ID is ignored.
+func (s *Storage) SaveRole(ctx context.Context, role models.Role) (roleID int64, err error) {
+	const op = "storage.memory.SaveRole"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	role.ID = 0
+	if s.roleTaken(role) {
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrRoleAlreadyExists)
+	}
+	s.lastRoleID++
+	role.ID = s.lastRoleID
+	role.Permissions = sortedUnique(role.Permissions)
+	s.roles[role.ID] = role
+	return role.ID, nil
+}
+
+// UpdateRole replaces the name and permissions of the role. Its app can't change.
+func (s *Storage) UpdateRole(ctx context.Context, role models.Role) error {
+	const op = "storage.memory.UpdateRole"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.roles[role.ID]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrRoleNotFound)
+	}
+	role.AppID = current.AppID
+	if s.roleTaken(role) {
+		return fmt.Errorf("%s: %w", op, storage.ErrRoleAlreadyExists)
+	}
+	role.Permissions = sortedUnique(role.Permissions)
+	s.roles[role.ID] = role
+	return nil
+}
+
+// DeleteRole removes the role and its assignments.
+func (s *Storage) DeleteRole(ctx context.Context, roleID int64) error {
+	const op = "storage.memory.DeleteRole"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.roles[roleID]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrRoleNotFound)
+	}
+	s.deleteRole(roleID)
+	return nil
+}
+
+func (s *Storage) Role(ctx context.Context, roleID int64) (role models.Role, err error) {
+	const op = "storage.memory.Role"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	role, ok := s.roles[roleID]
+	if !ok {
+		return models.Role{}, fmt.Errorf("%s: %w", op, storage.ErrRoleNotFound)
+	}
+	return cloneRole(role), nil
+}
+
+// Roles returns the roles defined by the app ordered by id.
+func (s *Storage) Roles(ctx context.Context, appID int) (roles []models.Role, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedRoles(func(role models.Role) bool { return role.AppID == appID }), nil
+}
+
+// UserRoles returns the roles of the app assigned to the user.
+func (s *Storage) UserRoles(ctx context.Context, userID int64, appID int) (roles []models.Role, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedRoles(func(role models.Role) bool {
+		_, assigned := s.userRoles[userRole{userID: userID, roleID: role.ID}]
+		return assigned && role.AppID == appID
+	}), nil
+}
+
+// AssignRole gives the role to the user. Assigning it twice is not an error.
+func (s *Storage) AssignRole(ctx context.Context, userID int64, roleID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userRoles[userRole{userID: userID, roleID: roleID}] = struct{}{}
+	return nil
+}
+
+// UnassignRole takes the role away from the user. Unassigning a role the user
+// doesn't have is not an error.
+func (s *Storage) UnassignRole(ctx context.Context, userID int64, roleID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.userRoles, userRole{userID: userID, roleID: roleID})
+	return nil
+}
+
+// roleTaken reports whether another role of the app has the role's name.
+func (s *Storage) roleTaken(role models.Role) bool {
+	for _, other := range s.roles {
+		if other.ID != role.ID && other.AppID == role.AppID && other.Name == role.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteRole removes the role and its assignments; the caller holds the lock.
+func (s *Storage) deleteRole(roleID int64) {
+	for assignment := range s.userRoles {
+		if assignment.roleID == roleID {
+			delete(s.userRoles, assignment)
+		}
+	}
+	delete(s.roles, roleID)
+}
+
+func (s *Storage) sortedRoles(match func(role models.Role) bool) []models.Role {
+	var roles []models.Role
+	for _, role := range s.roles {
+		if match(role) {
+			roles = append(roles, cloneRole(role))
+		}
+	}
+	slices.SortFunc(roles, func(a, b models.Role) int { return cmp.Compare(a.ID, b.ID) })
+	return roles
+}
+
+// cloneRole copies the role; roles without permissions have nil Permissions,
+// as they do in the sqlite storage.
+func cloneRole(role models.Role) models.Role {
+	if len(role.Permissions) == 0 {
+		role.Permissions = nil
+	}
+	role.Permissions = slices.Clone(role.Permissions)
+	return role
+}
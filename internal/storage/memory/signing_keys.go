@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// RotateSigningKey saves key as the active key of its app and moves the app's
+// other active keys to the retiring status until retireAt.
+func (s *Storage) RotateSigningKey(ctx context.Context, key models.SigningKey, retireAt time.Time) error {
+	const op = "storage.memory.RotateSigningKey"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, other := range s.signingKeys {
+		if other.ID == key.ID {
+			return fmt.Errorf("%s: signing key %q already exists", op, key.ID)
+		}
+	}
+	for i, other := range s.signingKeys {
+		if other.AppID == key.AppID && other.Status == models.KeyStatusActive {
+			s.signingKeys[i].Status = models.KeyStatusRetiring
+			s.signingKeys[i].RetireAt = unixTime(retireAt)
+		}
+	}
+	s.signingKeys = append(s.signingKeys, models.SigningKey{
+		ID:        key.ID,
+		AppID:     key.AppID,
+		Alg:       key.Alg,
+		Key:       slices.Clone(key.Key),
+		Sealed:    key.Sealed,
+		Status:    models.KeyStatusActive,
+		CreatedAt: unixTime(key.CreatedAt),
+	})
+	return nil
+}
+
+// SigningKeys returns the keys of an app in every status, newest first.
+func (s *Storage) SigningKeys(ctx context.Context, appID int) (keys []models.SigningKey, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedSigningKeys(func(key models.SigningKey) bool { return key.AppID == appID }), nil
+}
+
+func (s *Storage) AllSigningKeys(ctx context.Context) (keys []models.SigningKey, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedSigningKeys(func(models.SigningKey) bool { return true }), nil
+}
+
+// RetireExpiredSigningKeys finishes the overlap window of retiring keys.
+func (s *Storage) RetireExpiredSigningKeys(ctx context.Context, now time.Time) (retired int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, key := range s.signingKeys {
+		if key.Status == models.KeyStatusRetiring && !key.RetireAt.After(unixTime(now)) {
+			s.signingKeys[i].Status = models.KeyStatusRetired
+			retired++
+		}
+	}
+	return retired, nil
+}
+
+// SealSigningKey replaces a key saved before keys were sealed with its sealed form.
+func (s *Storage) SealSigningKey(ctx context.Context, kid string, sealed []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, key := range s.signingKeys {
+		if key.ID == kid && !key.Sealed {
+			s.signingKeys[i].Key = slices.Clone(sealed)
+			s.signingKeys[i].Sealed = true
+		}
+	}
+	return nil
+}
+
+// sortedSigningKeys returns copies of the matching keys ordered by app and
+// newest first, later saved keys first among keys created in the same second.
+func (s *Storage) sortedSigningKeys(match func(key models.SigningKey) bool) []models.SigningKey {
+	var keys []models.SigningKey
+	for i := len(s.signingKeys) - 1; i >= 0; i-- {
+		if key := s.signingKeys[i]; match(key) {
+			key.Key = slices.Clone(key.Key)
+			keys = append(keys, key)
+		}
+	}
+	slices.SortStableFunc(keys, func(a, b models.SigningKey) int {
+		if c := cmp.Compare(a.AppID, b.AppID); c != 0 {
+			return c
+		}
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return keys
+}
@@ -0,0 +1,224 @@
+package memory
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+func (s *Storage) SaveUser(ctx context.Context, orgID int64, email string, passHash []byte) (uid int64, err error) {
+	const op = "storage.memory.SaveUser"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.OrgID == orgID && user.Email == email {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserAlreadyExists)
+		}
+	}
+	s.lastUserID++
+	s.users[s.lastUserID] = models.User{
+		ID:        s.lastUserID,
+		OrgID:     orgID,
+		Email:     email,
+		PassHash:  slices.Clone(passHash),
+		CreatedAt: unixTime(time.Now()),
+	}
+	return s.lastUserID, nil
+}
+
+func (s *Storage) User(ctx context.Context, orgID int64, email string) (user models.User, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.OrgID == orgID && user.Email == email {
+			return cloneUser(user), nil
+		}
+	}
+	return models.User{}, storage.ErrUserNotFound
+}
+
+func (s *Storage) UserByID(ctx context.Context, userID int64) (user models.User, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return models.User{}, storage.ErrUserNotFound
+	}
+	return cloneUser(user), nil
+}
+
+func (s *Storage) IsAdmin(ctx context.Context, userID int64) (isAdmin bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return false, storage.ErrUserNotFound
+	}
+	return user.IsAdmin, nil
+}
+
+// Users returns the users matching the filter ordered by id. Like LIKE in
+// sqlite, the email prefix matches ASCII letters case-insensitively.
+func (s *Storage) Users(ctx context.Context, filter models.UserFilter) (users []models.User, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := asciiLower(filter.EmailPrefix)
+	for _, user := range s.users {
+		switch {
+		case user.ID <= filter.AfterID,
+			filter.OrgID != 0 && user.OrgID != filter.OrgID,
+			!strings.HasPrefix(asciiLower(user.Email), prefix),
+			filter.IsAdmin != nil && user.IsAdmin != *filter.IsAdmin,
+			!filter.CreatedAfter.IsZero() && user.CreatedAt.Unix() < filter.CreatedAfter.Unix(),
+			!filter.CreatedBefore.IsZero() && user.CreatedAt.Unix() >= filter.CreatedBefore.Unix():
+			continue
+		}
+		users = append(users, cloneUser(user))
+	}
+	slices.SortFunc(users, func(a, b models.User) int { return cmp.Compare(a.ID, b.ID) })
+	if filter.Limit > 0 && len(users) > filter.Limit {
+		users = users[:filter.Limit]
+	}
+	return users, nil
+}
+
+func (s *Storage) UpdatePassHash(ctx context.Context, userID int64, passHash []byte) error {
+	const op = "storage.memory.UpdatePassHash"
+	return s.updateUser(op, userID, func(user *models.User) { user.PassHash = slices.Clone(passHash) })
+}
+
+// ChangePassHash replaces the password hash only if it still equals oldHash. It fails
+// with storage.ErrPassHashChanged when another update got there first.
+func (s *Storage) ChangePassHash(ctx context.Context, userID int64, oldHash []byte, newHash []byte) error {
+	const op = "storage.memory.ChangePassHash"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	if !bytes.Equal(user.PassHash, oldHash) {
+		return fmt.Errorf("%s: %w", op, storage.ErrPassHashChanged)
+	}
+	user.PassHash = slices.Clone(newHash)
+	s.users[userID] = user
+	return nil
+}
+
+func (s *Storage) SetEmailVerified(ctx context.Context, userID int64) error {
+	const op = "storage.memory.SetEmailVerified"
+	return s.updateUser(op, userID, func(user *models.User) { user.EmailVerified = true })
+}
+
+func (s *Storage) SetAdmin(ctx context.Context, userID int64, isAdmin bool) error {
+	const op = "storage.memory.SetAdmin"
+	return s.updateUser(op, userID, func(user *models.User) { user.IsAdmin = isAdmin })
+}
+
+func (s *Storage) SetUserDisabled(ctx context.Context, userID int64, disabled bool) error {
+	const op = "storage.memory.SetUserDisabled"
+	return s.updateUser(op, userID, func(user *models.User) { user.Disabled = disabled })
+}
+
+// RevokeUserTokens revokes every refresh token of the user and invalidates the
+// access tokens issued before at.
+func (s *Storage) RevokeUserTokens(ctx context.Context, userID int64, at time.Time) error {
+	const op = "storage.memory.RevokeUserTokens"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	user.TokensRevokedAt = time.UnixMilli(at.UnixMilli())
+	s.users[userID] = user
+	for id, token := range s.refreshTokens {
+		if token.UserID == userID {
+			token.Revoked = true
+			s.refreshTokens[id] = token
+		}
+	}
+	return nil
+}
+
+// DeleteUser removes the user and everything issued to them. The audit log is kept.
+func (s *Storage) DeleteUser(ctx context.Context, userID int64) error {
+	const op = "storage.memory.DeleteUser"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[userID]; !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	for id, token := range s.refreshTokens {
+		if token.UserID == userID {
+			delete(s.refreshTokens, id)
+		}
+	}
+	for hash, token := range s.emailTokens {
+		if token.UserID == userID {
+			delete(s.emailTokens, hash)
+		}
+	}
+	delete(s.mfa, userID)
+	for hash, challenge := range s.mfaChallenges {
+		if challenge.UserID == userID {
+			delete(s.mfaChallenges, hash)
+		}
+	}
+	for id, code := range s.recoveryCodes {
+		if code.UserID == userID {
+			delete(s.recoveryCodes, id)
+		}
+	}
+	for hash, code := range s.authCodes {
+		if code.UserID == userID {
+			delete(s.authCodes, hash)
+		}
+	}
+	for assignment := range s.userRoles {
+		if assignment.userID == userID {
+			delete(s.userRoles, assignment)
+		}
+	}
+	for member := range s.members {
+		if member.userID == userID {
+			delete(s.members, member)
+		}
+	}
+	delete(s.users, userID)
+	return nil
+}
+
+// updateUser applies update to the stored user.
+func (s *Storage) updateUser(op string, userID int64, update func(user *models.User)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	update(&user)
+	s.users[userID] = user
+	return nil
+}
+
+func cloneUser(user models.User) models.User {
+	user.PassHash = slices.Clone(user.PassHash)
+	return user
+}
+
+// asciiLower lowercases ASCII letters only, like sqlite does for LIKE.
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return r
+	}, s)
+}
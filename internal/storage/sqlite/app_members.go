@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// SetAppAdmin makes the user an admin of the app, or takes it back, adding
+// them as a member of the app if they aren't one yet.
+func (s *Storage) SetAppAdmin(ctx context.Context, userID int64, appID int, isAdmin bool) error {
+	const op = "storage.sqlite.SetAppAdmin"
+	stmt, err := s.stmt(ctx, `
+		INSERT INTO app_members (user_id, app_id, is_admin) VALUES (?,?,?)
+		ON CONFLICT (user_id, app_id) DO UPDATE SET is_admin=excluded.is_admin`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := stmt.ExecContext(ctx, userID, appID, isAdmin); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetAppAccess grants the user access to the app, or revokes it, adding them
+// as a member of the app if they aren't one yet.
+func (s *Storage) SetAppAccess(ctx context.Context, userID int64, appID int, hasAccess bool) error {
+	const op = "storage.sqlite.SetAppAccess"
+	stmt, err := s.stmt(ctx, `
+		INSERT INTO app_members (user_id, app_id, has_access) VALUES (?,?,?)
+		ON CONFLICT (user_id, app_id) DO UPDATE SET has_access=excluded.has_access`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := stmt.ExecContext(ctx, userID, appID, hasAccess); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// HasAppAccess reports whether the user was granted access to the app or is
+// one of its admins.
+func (s *Storage) HasAppAccess(ctx context.Context, userID int64, appID int) (hasAccess bool, err error) {
+	const op = "storage.sqlite.HasAppAccess"
+	stmt, err := s.stmt(ctx, "SELECT EXISTS(SELECT 1 FROM app_members WHERE user_id=? AND app_id=? AND (has_access OR is_admin))")
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := stmt.QueryRowContext(ctx, userID, appID).Scan(&hasAccess); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return hasAccess, nil
+}
+
+// AdminApps returns the IDs of the apps the user is an admin of.
+func (s *Storage) AdminApps(ctx context.Context, userID int64) (appIDs []int, err error) {
+	const op = "storage.sqlite.AdminApps"
+	stmt, err := s.stmt(ctx, "SELECT app_id FROM app_members WHERE user_id=? AND is_admin ORDER BY app_id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := stmt.QueryContext(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var appID int
+		if err := rows.Scan(&appID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		appIDs = append(appIDs, appID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return appIDs, nil
+}
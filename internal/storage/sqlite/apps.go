@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"strings"
+)
+
+// SaveApp creates the app together with its redirect URIs. app.ID is ignored.
+func (s *Storage) SaveApp(ctx context.Context, app models.App) (appID int, err error) {
+	const op = "storage.sqlite.SaveApp"
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO apps (org_id, name, secret, signing_alg, require_verified_email, restricted, client_scopes)
+		VALUES (?,?,?,?,?,?,?)`,
+		app.OrgID, app.Name, app.Secret, app.SigningAlg, app.RequireVerifiedEmail, app.Restricted, strings.Join(app.ClientScopes, " "))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrAppAlreadyExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := replaceRedirectURIs(ctx, tx, int(id), app.RedirectURIs); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(id), nil
+}
+
+// UpdateApp replaces the settings and redirect URIs of the app. The secret is
+// left alone, it is only changed by UpdateAppSecret, and apps never change orgs.
+func (s *Storage) UpdateApp(ctx context.Context, app models.App) error {
+	const op = "storage.sqlite.UpdateApp"
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `
+		UPDATE apps SET name=?, signing_alg=?, require_verified_email=?, restricted=?, client_scopes=?
+		WHERE id=?`,
+		app.Name, app.SigningAlg, app.RequireVerifiedEmail, app.Restricted, strings.Join(app.ClientScopes, " "), app.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrAppAlreadyExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := requireAffected(res, storage.ErrAppNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := replaceRedirectURIs(ctx, tx, app.ID, app.RedirectURIs); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) UpdateAppSecret(ctx context.Context, appID int, secret string) error {
+	const op = "storage.sqlite.UpdateAppSecret"
+	stmt, err := s.stmt(ctx, "UPDATE apps SET secret=? WHERE id=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, secret, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := requireAffected(res, storage.ErrAppNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteApp removes the app and everything issued for it. Dependent rows are
+// deleted explicitly, since foreign keys may not be enforced on the connection.
+func (s *Storage) DeleteApp(ctx context.Context, appID int) error {
+	const op = "storage.sqlite.DeleteApp"
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	for _, query := range []string{
+		"DELETE FROM user_roles WHERE role_id IN (SELECT id FROM roles WHERE app_id=?)",
+		"DELETE FROM role_permissions WHERE role_id IN (SELECT id FROM roles WHERE app_id=?)",
+	} {
+		if _, err := tx.ExecContext(ctx, query, appID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	for _, table := range []string{"roles", "policies", "refresh_tokens", "signing_keys", "mfa_challenges", "app_redirect_uris", "authorization_codes", "app_members"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE app_id=?", appID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM apps WHERE id=?", appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := requireAffected(res, storage.ErrAppNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func replaceRedirectURIs(ctx context.Context, tx *sql.Tx, appID int, redirectURIs []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM app_redirect_uris WHERE app_id=?", appID); err != nil {
+		return err
+	}
+	for _, redirectURI := range redirectURIs {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO app_redirect_uris (app_id, redirect_uri) VALUES (?,?) ON CONFLICT DO NOTHING", appID, redirectURI)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func requireAffected(res sql.Result, notFound error) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var sqlError sqlite3.Error
+	return errors.As(err, &sqlError) && errors.Is(sqlError.ExtendedCode, sqlite3.ErrConstraintUnique)
+}
@@ -0,0 +1,21 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"context"
+	"fmt"
+)
+
+func (s *Storage) SaveAuditEvent(ctx context.Context, event models.AuditEvent) error {
+	const op = "storage.sqlite.SaveAuditEvent"
+	stmt, err := s.stmt(ctx,
+		"INSERT INTO audit_log (user_id, event, ip, detail, created_at) VALUES (?,?,?,?,?)")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = stmt.ExecContext(ctx, event.UserID, event.Event, event.IP, event.Detail, event.CreatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
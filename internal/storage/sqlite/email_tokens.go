@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) SaveEmailToken(ctx context.Context, token models.EmailToken) error {
+	const op = "storage.sqlite.SaveEmailToken"
+	stmt, err := s.stmt(ctx,
+		"INSERT INTO email_tokens (token_hash, user_id, purpose, expires_at) VALUES (?,?,?,?)")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = stmt.ExecContext(ctx, token.TokenHash, token.UserID, token.Purpose, token.ExpiresAt.Unix())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UseEmailToken marks an unused, unexpired token as used and returns it. Unknown,
+// used and expired tokens all fail with storage.ErrEmailTokenNotFound.
+func (s *Storage) UseEmailToken(ctx context.Context, tokenHash string, purpose string, now time.Time) (token models.EmailToken, err error) {
+	const op = "storage.sqlite.UseEmailToken"
+	stmt, err := s.stmt(ctx, `
+		UPDATE email_tokens SET used = TRUE
+		WHERE token_hash=? AND purpose=? AND used = FALSE AND expires_at > ?
+		RETURNING token_hash, user_id, purpose, expires_at`)
+	if err != nil {
+		return models.EmailToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	var expiresAt int64
+	row := stmt.QueryRowContext(ctx, tokenHash, purpose, now.Unix())
+	err = row.Scan(&token.TokenHash, &token.UserID, &token.Purpose, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.EmailToken{}, storage.ErrEmailTokenNotFound
+		}
+		return models.EmailToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	token.ExpiresAt = time.Unix(expiresAt, 0)
+	return token, nil
+}
+
+// DeleteEmailTokens invalidates every token of the user issued for purpose.
+func (s *Storage) DeleteEmailTokens(ctx context.Context, userID int64, purpose string) error {
+	const op = "storage.sqlite.DeleteEmailTokens"
+	stmt, err := s.stmt(ctx, "DELETE FROM email_tokens WHERE user_id=? AND purpose=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err = stmt.ExecContext(ctx, userID, purpose); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteExpiredEmailTokens(ctx context.Context, now time.Time) (deleted int64, err error) {
+	const op = "storage.sqlite.DeleteExpiredEmailTokens"
+	stmt, err := s.stmt(ctx, "DELETE FROM email_tokens WHERE used = TRUE OR expires_at <= ?")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	deleted, err = res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LoginAttempts returns the failed login counter for key. Keys without failures
+// yield a zero counter rather than an error.
+func (s *Storage) LoginAttempts(ctx context.Context, key string) (attempts models.LoginAttempts, err error) {
+	const op = "storage.sqlite.LoginAttempts"
+	stmt, err := s.stmt(ctx,
+		"SELECT key, failures, last_failure, locked_until FROM login_attempts WHERE key=?")
+	if err != nil {
+		return models.LoginAttempts{}, fmt.Errorf("%s: %w", op, err)
+	}
+	var lastFailure, lockedUntil int64
+	err = stmt.QueryRowContext(ctx, key).Scan(&attempts.Key, &attempts.Failures, &lastFailure, &lockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LoginAttempts{Key: key}, nil
+		}
+		return models.LoginAttempts{}, fmt.Errorf("%s: %w", op, err)
+	}
+	attempts.LastFailure = time.Unix(lastFailure, 0)
+	attempts.LockedUntil = time.Unix(lockedUntil, 0)
+	return attempts, nil
+}
+
+// AddLoginFailure atomically counts a failed login. Failures older than windowStart
+// are forgotten and the counter starts over.
+func (s *Storage) AddLoginFailure(ctx context.Context, key string, now time.Time, windowStart time.Time) (failures int, err error) {
+	const op = "storage.sqlite.AddLoginFailure"
+	stmt, err := s.stmt(ctx, `INSERT INTO login_attempts (key, failures, last_failure) VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN last_failure < ? THEN 1 ELSE failures + 1 END,
+			last_failure = excluded.last_failure
+		RETURNING failures`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err = stmt.QueryRowContext(ctx, key, now.Unix(), windowStart.Unix()).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return failures, nil
+}
+
+func (s *Storage) LockLogin(ctx context.Context, key string, until time.Time) error {
+	const op = "storage.sqlite.LockLogin"
+	stmt, err := s.stmt(ctx, "UPDATE login_attempts SET locked_until=? WHERE key=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err = stmt.ExecContext(ctx, until.Unix(), key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteLoginAttempts(ctx context.Context, key string) error {
+	const op = "storage.sqlite.DeleteLoginAttempts"
+	stmt, err := s.stmt(ctx, "DELETE FROM login_attempts WHERE key=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err = stmt.ExecContext(ctx, key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteStaleLoginAttempts drops counters that are neither locked nor inside the failure window.
+func (s *Storage) DeleteStaleLoginAttempts(ctx context.Context, now time.Time, windowStart time.Time) (deleted int64, err error) {
+	const op = "storage.sqlite.DeleteStaleLoginAttempts"
+	stmt, err := s.stmt(ctx, "DELETE FROM login_attempts WHERE last_failure < ? AND locked_until < ?")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, windowStart.Unix(), now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	deleted, err = res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return deleted, nil
+}
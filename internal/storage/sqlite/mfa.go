@@ -0,0 +1,196 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SaveMFASecret stores a new, not yet confirmed enrollment, replacing a previous one.
+func (s *Storage) SaveMFASecret(ctx context.Context, userID int64, secret []byte) error {
+	const op = "storage.sqlite.SaveMFASecret"
+	stmt, err := s.stmt(ctx, `
+		INSERT INTO user_mfa (user_id, secret) VALUES (?,?)
+		ON CONFLICT (user_id) DO UPDATE SET secret = excluded.secret, enabled = FALSE, last_used_step = 0`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err = stmt.ExecContext(ctx, userID, secret); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) MFA(ctx context.Context, userID int64) (mfa models.MFA, err error) {
+	const op = "storage.sqlite.MFA"
+	stmt, err := s.stmt(ctx, "SELECT user_id, secret, enabled, last_used_step FROM user_mfa WHERE user_id=?")
+	if err != nil {
+		return models.MFA{}, fmt.Errorf("%s: %w", op, err)
+	}
+	row := stmt.QueryRowContext(ctx, userID)
+	err = row.Scan(&mfa.UserID, &mfa.Secret, &mfa.Enabled, &mfa.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.MFA{}, storage.ErrMFANotFound
+		}
+		return models.MFA{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return mfa, nil
+}
+
+func (s *Storage) EnableMFA(ctx context.Context, userID int64) error {
+	const op = "storage.sqlite.EnableMFA"
+	stmt, err := s.stmt(ctx, "UPDATE user_mfa SET enabled = TRUE WHERE user_id=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrMFANotFound)
+	}
+	return nil
+}
+
+// UseMFAStep records that the code of step was accepted. It fails with
+// storage.ErrMFAStepUsed when a code of this or a later step was accepted before.
+func (s *Storage) UseMFAStep(ctx context.Context, userID int64, step int64) error {
+	const op = "storage.sqlite.UseMFAStep"
+	stmt, err := s.stmt(ctx,
+		"UPDATE user_mfa SET last_used_step=? WHERE user_id=? AND last_used_step < ?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, step, userID, step)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrMFAStepUsed)
+	}
+	return nil
+}
+
+// DeleteMFA removes the enrollment together with its recovery codes.
+func (s *Storage) DeleteMFA(ctx context.Context, userID int64) error {
+	const op = "storage.sqlite.DeleteMFA"
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_mfa WHERE user_id=?", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id=?", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) SaveMFAChallenge(ctx context.Context, challenge models.MFAChallenge) error {
+	const op = "storage.sqlite.SaveMFAChallenge"
+	stmt, err := s.stmt(ctx,
+		"INSERT INTO mfa_challenges (token_hash, user_id, app_id, expires_at) VALUES (?,?,?,?)")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = stmt.ExecContext(ctx, challenge.TokenHash, challenge.UserID, challenge.AppID, challenge.ExpiresAt.Unix())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) MFAChallenge(ctx context.Context, tokenHash string) (challenge models.MFAChallenge, err error) {
+	const op = "storage.sqlite.MFAChallenge"
+	stmt, err := s.stmt(ctx,
+		"SELECT token_hash, user_id, app_id, expires_at, attempts FROM mfa_challenges WHERE token_hash=?")
+	if err != nil {
+		return models.MFAChallenge{}, fmt.Errorf("%s: %w", op, err)
+	}
+	var expiresAt int64
+	row := stmt.QueryRowContext(ctx, tokenHash)
+	err = row.Scan(&challenge.TokenHash, &challenge.UserID, &challenge.AppID, &expiresAt, &challenge.Attempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.MFAChallenge{}, storage.ErrMFAChallengeNotFound
+		}
+		return models.MFAChallenge{}, fmt.Errorf("%s: %w", op, err)
+	}
+	challenge.ExpiresAt = time.Unix(expiresAt, 0)
+	return challenge, nil
+}
+
+// AddMFAChallengeFailure counts a wrong code and returns the number of failures so far.
+func (s *Storage) AddMFAChallengeFailure(ctx context.Context, tokenHash string) (attempts int, err error) {
+	const op = "storage.sqlite.AddMFAChallengeFailure"
+	stmt, err := s.stmt(ctx,
+		"UPDATE mfa_challenges SET attempts = attempts + 1 WHERE token_hash=? RETURNING attempts")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err = stmt.QueryRowContext(ctx, tokenHash).Scan(&attempts); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrMFAChallengeNotFound
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return attempts, nil
+}
+
+// DeleteMFAChallenge fails with storage.ErrMFAChallengeNotFound when the challenge
+// is already gone, so only one caller can complete it.
+func (s *Storage) DeleteMFAChallenge(ctx context.Context, tokenHash string) error {
+	const op = "storage.sqlite.DeleteMFAChallenge"
+	stmt, err := s.stmt(ctx, "DELETE FROM mfa_challenges WHERE token_hash=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, tokenHash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrMFAChallengeNotFound)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteExpiredMFAChallenges(ctx context.Context, now time.Time) (deleted int64, err error) {
+	const op = "storage.sqlite.DeleteExpiredMFAChallenges"
+	stmt, err := s.stmt(ctx, "DELETE FROM mfa_challenges WHERE expires_at <= ?")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	deleted, err = res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return deleted, nil
+}
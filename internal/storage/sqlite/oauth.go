@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) RedirectURIs(ctx context.Context, appID int) (redirectURIs []string, err error) {
+	const op = "storage.sqlite.RedirectURIs"
+	stmt, err := s.stmt(ctx,
+		"SELECT redirect_uri FROM app_redirect_uris WHERE app_id=? ORDER BY redirect_uri")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := stmt.QueryContext(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var redirectURI string
+		if err := rows.Scan(&redirectURI); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		redirectURIs = append(redirectURIs, redirectURI)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return redirectURIs, nil
+}
+
+func (s *Storage) SaveAuthorizationCode(ctx context.Context, code models.AuthorizationCode) error {
+	const op = "storage.sqlite.SaveAuthorizationCode"
+	stmt, err := s.stmt(ctx, `
+		INSERT INTO authorization_codes (code_hash, app_id, user_id, redirect_uri, code_challenge, scope, nonce, auth_time, expires_at)
+		VALUES (?,?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = stmt.ExecContext(ctx, code.CodeHash, code.AppID, code.UserID, code.RedirectURI,
+		code.CodeChallenge, code.Scope, code.Nonce, code.AuthTime.Unix(), code.ExpiresAt.Unix())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UseAuthorizationCode marks an unused, unexpired code as used and returns it. Unknown,
+// used and expired codes all fail with storage.ErrAuthCodeNotFound.
+func (s *Storage) UseAuthorizationCode(ctx context.Context, codeHash string, now time.Time) (code models.AuthorizationCode, err error) {
+	const op = "storage.sqlite.UseAuthorizationCode"
+	stmt, err := s.stmt(ctx, `
+		UPDATE authorization_codes SET used = TRUE
+		WHERE code_hash=? AND used = FALSE AND expires_at > ?
+		RETURNING code_hash, app_id, user_id, redirect_uri, code_challenge, scope, nonce, auth_time, expires_at`)
+	if err != nil {
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+	var authTime, expiresAt int64
+	row := stmt.QueryRowContext(ctx, codeHash, now.Unix())
+	err = row.Scan(&code.CodeHash, &code.AppID, &code.UserID, &code.RedirectURI, &code.CodeChallenge, &code.Scope, &code.Nonce, &authTime, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.AuthorizationCode{}, storage.ErrAuthCodeNotFound
+		}
+		return models.AuthorizationCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+	code.AuthTime = time.Unix(authTime, 0)
+	code.ExpiresAt = time.Unix(expiresAt, 0)
+	return code, nil
+}
+
+func (s *Storage) DeleteExpiredAuthorizationCodes(ctx context.Context, now time.Time) (deleted int64, err error) {
+	const op = "storage.sqlite.DeleteExpiredAuthorizationCodes"
+	stmt, err := s.stmt(ctx, "DELETE FROM authorization_codes WHERE used = TRUE OR expires_at <= ?")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	deleted, err = res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return deleted, nil
+}
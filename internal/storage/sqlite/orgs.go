@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) SaveOrg(ctx context.Context, org models.Org) (orgID int64, err error) {
+	const op = "storage.sqlite.SaveOrg"
+	stmt, err := s.stmt(ctx, "INSERT INTO orgs (name, created_at) VALUES (?,?)")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, org.Name, org.CreatedAt.Unix())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrOrgAlreadyExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	orgID, err = res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return orgID, nil
+}
+
+func (s *Storage) Org(ctx context.Context, orgID int64) (org models.Org, err error) {
+	const op = "storage.sqlite.Org"
+	stmt, err := s.stmt(ctx, "SELECT id, name, created_at FROM orgs WHERE id=?")
+	if err != nil {
+		return models.Org{}, fmt.Errorf("%s: %w", op, err)
+	}
+	org, err = scanOrg(stmt.QueryRowContext(ctx, orgID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Org{}, fmt.Errorf("%s: %w", op, storage.ErrOrgNotFound)
+		}
+		return models.Org{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return org, nil
+}
+
+func (s *Storage) Orgs(ctx context.Context) (orgs []models.Org, err error) {
+	const op = "storage.sqlite.Orgs"
+	stmt, err := s.stmt(ctx, "SELECT id, name, created_at FROM orgs ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		org, err := scanOrg(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		orgs = append(orgs, org)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return orgs, nil
+}
+
+func scanOrg(row interface{ Scan(dest ...any) error }) (models.Org, error) {
+	var org models.Org
+	var createdAt int64
+	if err := row.Scan(&org.ID, &org.Name, &createdAt); err != nil {
+		return models.Org{}, err
+	}
+	if createdAt != 0 {
+		org.CreatedAt = time.Unix(createdAt, 0)
+	}
+	return org, nil
+}
@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// policyRule is how a models.PolicyRule is stored in the rules column.
+type policyRule struct {
+	Name      string `json:"name"`
+	Effect    string `json:"effect"`
+	Condition string `json:"condition"`
+}
+
+// SavePolicy stores the rules as the next version of the app's policy and
+// returns that version. policy.Version is ignored.
+func (s *Storage) SavePolicy(ctx context.Context, policy models.Policy) (version int, err error) {
+	const op = "storage.sqlite.SavePolicy"
+	rules := make([]policyRule, 0, len(policy.Rules))
+	for _, rule := range policy.Rules {
+		rules = append(rules, policyRule(rule))
+	}
+	encoded, err := json.Marshal(rules)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	stmt, err := s.stmt(ctx, `
+		INSERT INTO policies (app_id, version, rules, created_by, created_at)
+		SELECT ?, COALESCE(MAX(version), 0) + 1, ?, ?, ? FROM policies WHERE app_id=?
+		RETURNING version`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	row := stmt.QueryRowContext(ctx, policy.AppID, string(encoded), policy.CreatedBy, policy.CreatedAt.Unix(), policy.AppID)
+	if err := row.Scan(&version); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return version, nil
+}
+
+// Policy returns the given version of the app's policy, or the newest one for version 0.
+func (s *Storage) Policy(ctx context.Context, appID int, version int) (policy models.Policy, err error) {
+	const op = "storage.sqlite.Policy"
+	stmt, err := s.stmt(ctx, `
+		SELECT app_id, version, rules, created_by, created_at FROM policies
+		WHERE app_id=? AND (version=? OR ?=0) ORDER BY version DESC LIMIT 1`)
+	if err != nil {
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+	policy, err = scanPolicy(stmt.QueryRowContext(ctx, appID, version, version))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Policy{}, fmt.Errorf("%s: %w", op, storage.ErrPolicyNotFound)
+		}
+		return models.Policy{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return policy, nil
+}
+
+// PolicyVersions returns every version of the app's policy, newest first.
+func (s *Storage) PolicyVersions(ctx context.Context, appID int) (policies []models.Policy, err error) {
+	const op = "storage.sqlite.PolicyVersions"
+	stmt, err := s.stmt(ctx, `
+		SELECT app_id, version, rules, created_by, created_at FROM policies
+		WHERE app_id=? ORDER BY version DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := stmt.QueryContext(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		policy, err := scanPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		policies = append(policies, policy)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return policies, nil
+}
+
+func scanPolicy(row interface{ Scan(dest ...any) error }) (models.Policy, error) {
+	var policy models.Policy
+	var encoded string
+	var createdAt int64
+	if err := row.Scan(&policy.AppID, &policy.Version, &encoded, &policy.CreatedBy, &createdAt); err != nil {
+		return models.Policy{}, err
+	}
+	var rules []policyRule
+	if err := json.Unmarshal([]byte(encoded), &rules); err != nil {
+		return models.Policy{}, err
+	}
+	for _, rule := range rules {
+		policy.Rules = append(policy.Rules, models.PolicyRule(rule))
+	}
+	policy.CreatedAt = time.Unix(createdAt, 0)
+	return policy, nil
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReplaceRecoveryCodes drops every recovery code of the user, used or not, and stores the new ones.
+func (s *Storage) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes [][]byte) error {
+	const op = "storage.sqlite.ReplaceRecoveryCodes"
+	// Prepared before the transaction: it holds the write lock, and preparing
+	// needs a connection of its own, which may be the only one.
+	insert, err := s.stmt(ctx, "INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES (?,?)")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id=?", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	stmt := tx.StmtContext(ctx, insert)
+	defer stmt.Close()
+	for _, codeHash := range codeHashes {
+		if _, err := stmt.ExecContext(ctx, userID, codeHash); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RecoveryCodes returns the unused recovery codes of the user.
+func (s *Storage) RecoveryCodes(ctx context.Context, userID int64) (codes []models.RecoveryCode, err error) {
+	const op = "storage.sqlite.RecoveryCodes"
+	stmt, err := s.stmt(ctx,
+		"SELECT id, user_id, code_hash FROM mfa_recovery_codes WHERE user_id=? AND used_at = 0 ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := stmt.QueryContext(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var code models.RecoveryCode
+		if err := rows.Scan(&code.ID, &code.UserID, &code.CodeHash); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		codes = append(codes, code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode marks the code as used. It fails with storage.ErrRecoveryCodeUsed
+// when it was used already, so one code can't complete two logins.
+func (s *Storage) UseRecoveryCode(ctx context.Context, id int64, now time.Time) error {
+	const op = "storage.sqlite.UseRecoveryCode"
+	stmt, err := s.stmt(ctx, "UPDATE mfa_recovery_codes SET used_at=? WHERE id=? AND used_at = 0")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, now.Unix(), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrRecoveryCodeUsed)
+	}
+	return nil
+}
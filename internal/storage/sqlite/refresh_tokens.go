@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	const op = "storage.sqlite.SaveRefreshToken"
+	stmt, err := s.stmt(ctx,
+		"INSERT INTO refresh_tokens (token_hash, family_id, user_id, app_id, expires_at) VALUES (?,?,?,?,?)")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = stmt.ExecContext(ctx, token.TokenHash, token.FamilyID, token.UserID, token.AppID, token.ExpiresAt.Unix())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) RefreshToken(ctx context.Context, tokenHash string) (token models.RefreshToken, err error) {
+	const op = "storage.sqlite.RefreshToken"
+	stmt, err := s.stmt(ctx,
+		"SELECT id, token_hash, family_id, user_id, app_id, expires_at, used, revoked FROM refresh_tokens WHERE token_hash=?")
+	if err != nil {
+		return models.RefreshToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	var expiresAt int64
+	row := stmt.QueryRowContext(ctx, tokenHash)
+	err = row.Scan(&token.ID, &token.TokenHash, &token.FamilyID, &token.UserID, &token.AppID, &expiresAt, &token.Used, &token.Revoked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.RefreshToken{}, storage.ErrRefreshTokenNotFound
+		}
+		return models.RefreshToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+	token.ExpiresAt = time.Unix(expiresAt, 0)
+	return token, nil
+}
+
+// UseRefreshToken marks the token as used. It fails with storage.ErrRefreshTokenUsed
+// when the token has already been used or revoked, so concurrent refreshes
+// with the same token can't both succeed.
+func (s *Storage) UseRefreshToken(ctx context.Context, id int64) error {
+	const op = "storage.sqlite.UseRefreshToken"
+	stmt, err := s.stmt(ctx,
+		"UPDATE refresh_tokens SET used = TRUE WHERE id=? AND used = FALSE AND revoked = FALSE")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrRefreshTokenUsed)
+	}
+	return nil
+}
+
+func (s *Storage) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	const op = "storage.sqlite.RevokeRefreshTokenFamily"
+	stmt, err := s.stmt(ctx, "UPDATE refresh_tokens SET revoked = TRUE WHERE family_id=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err = stmt.ExecContext(ctx, familyID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteExpiredRefreshTokens removes refresh tokens that expired or were revoked.
+// Used tokens are kept until they expire, so their reuse is still detected.
+func (s *Storage) DeleteExpiredRefreshTokens(ctx context.Context, now time.Time) (deleted int64, err error) {
+	const op = "storage.sqlite.DeleteExpiredRefreshTokens"
+	stmt, err := s.stmt(ctx, "DELETE FROM refresh_tokens WHERE expires_at < ? OR revoked = TRUE")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	deleted, err = res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return deleted, nil
+}
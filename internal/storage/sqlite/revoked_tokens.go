@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func (s *Storage) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	const op = "storage.sqlite.RevokeToken"
+	stmt, err := s.stmt(ctx,
+		"INSERT INTO revoked_tokens (jti, expires_at) VALUES (?,?) ON CONFLICT DO NOTHING")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err = stmt.ExecContext(ctx, jti, expiresAt.Unix()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) IsTokenRevoked(ctx context.Context, jti string) (revoked bool, err error) {
+	const op = "storage.sqlite.IsTokenRevoked"
+	stmt, err := s.stmt(ctx, "SELECT 1 FROM revoked_tokens WHERE jti=?")
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	var found int
+	err = stmt.QueryRowContext(ctx, jti).Scan(&found)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return true, nil
+}
+
+// DeleteExpiredRevokedTokens drops denylist entries for tokens that would be rejected
+// as expired anyway.
+func (s *Storage) DeleteExpiredRevokedTokens(ctx context.Context, now time.Time) (deleted int64, err error) {
+	const op = "storage.sqlite.DeleteExpiredRevokedTokens"
+	stmt, err := s.stmt(ctx, "DELETE FROM revoked_tokens WHERE expires_at < ?")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	deleted, err = res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,200 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const roleQuery = `
+	SELECT r.id, r.app_id, r.name, COALESCE(p.permission, '')
+	FROM roles r LEFT JOIN role_permissions p ON p.role_id = r.id`
+
+// SaveRole creates the role together with its permissions. role.ID is ignored.
+func (s *Storage) SaveRole(ctx context.Context, role models.Role) (roleID int64, err error) {
+	const op = "storage.sqlite.SaveRole"
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, "INSERT INTO roles (app_id, name) VALUES (?,?)", role.AppID, role.Name)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrRoleAlreadyExists)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	roleID, err = res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := replacePermissions(ctx, tx, roleID, role.Permissions); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return roleID, nil
+}
+
+// UpdateRole replaces the name and permissions of the role. Its app can't change.
+func (s *Storage) UpdateRole(ctx context.Context, role models.Role) error {
+	const op = "storage.sqlite.UpdateRole"
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, "UPDATE roles SET name=? WHERE id=?", role.Name, role.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrRoleAlreadyExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := requireAffected(res, storage.ErrRoleNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := replacePermissions(ctx, tx, role.ID, role.Permissions); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteRole removes the role and its assignments.
+func (s *Storage) DeleteRole(ctx context.Context, roleID int64) error {
+	const op = "storage.sqlite.DeleteRole"
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	for _, table := range []string{"user_roles", "role_permissions"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE role_id=?", roleID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM roles WHERE id=?", roleID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := requireAffected(res, storage.ErrRoleNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) Role(ctx context.Context, roleID int64) (role models.Role, err error) {
+	const op = "storage.sqlite.Role"
+	roles, err := s.queryRoles(ctx, roleQuery+" WHERE r.id=?", roleID)
+	if err != nil {
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(roles) == 0 {
+		return models.Role{}, fmt.Errorf("%s: %w", op, storage.ErrRoleNotFound)
+	}
+	return roles[0], nil
+}
+
+// Roles returns the roles defined by the app ordered by id.
+func (s *Storage) Roles(ctx context.Context, appID int) (roles []models.Role, err error) {
+	const op = "storage.sqlite.Roles"
+	roles, err = s.queryRoles(ctx, roleQuery+" WHERE r.app_id=?", appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return roles, nil
+}
+
+// UserRoles returns the roles of the app assigned to the user.
+func (s *Storage) UserRoles(ctx context.Context, userID int64, appID int) (roles []models.Role, err error) {
+	const op = "storage.sqlite.UserRoles"
+	roles, err = s.queryRoles(ctx,
+		roleQuery+" JOIN user_roles u ON u.role_id = r.id WHERE u.user_id=? AND r.app_id=?", userID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return roles, nil
+}
+
+// AssignRole gives the role to the user. Assigning it twice is not an error.
+func (s *Storage) AssignRole(ctx context.Context, userID int64, roleID int64) error {
+	const op = "storage.sqlite.AssignRole"
+	stmt, err := s.stmt(ctx, "INSERT INTO user_roles (user_id, role_id) VALUES (?,?) ON CONFLICT DO NOTHING")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := stmt.ExecContext(ctx, userID, roleID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UnassignRole takes the role away from the user. Unassigning a role the user
+// doesn't have is not an error.
+func (s *Storage) UnassignRole(ctx context.Context, userID int64, roleID int64) error {
+	const op = "storage.sqlite.UnassignRole"
+	stmt, err := s.stmt(ctx, "DELETE FROM user_roles WHERE user_id=? AND role_id=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := stmt.ExecContext(ctx, userID, roleID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// queryRoles runs a query built on roleQuery and folds the permission rows into roles.
+func (s *Storage) queryRoles(ctx context.Context, query string, args ...any) ([]models.Role, error) {
+	stmt, err := s.stmt(ctx, query+" ORDER BY r.id, p.permission")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var roles []models.Role
+	for rows.Next() {
+		var role models.Role
+		var permission string
+		if err := rows.Scan(&role.ID, &role.AppID, &role.Name, &permission); err != nil {
+			return nil, err
+		}
+		if len(roles) == 0 || roles[len(roles)-1].ID != role.ID {
+			roles = append(roles, role)
+		}
+		if permission != "" {
+			last := &roles[len(roles)-1]
+			last.Permissions = append(last.Permissions, permission)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func replacePermissions(ctx context.Context, tx *sql.Tx, roleID int64, permissions []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role_id=?", roleID); err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO role_permissions (role_id, permission) VALUES (?,?) ON CONFLICT DO NOTHING", roleID, permission)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
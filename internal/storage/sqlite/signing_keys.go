@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RotateSigningKey saves key as the active key of its app and moves the app's
+// other active keys to the retiring status until retireAt. Both happen in one
+// transaction, so concurrent rotations always leave one key active.
+func (s *Storage) RotateSigningKey(ctx context.Context, key models.SigningKey, retireAt time.Time) error {
+	const op = "storage.sqlite.RotateSigningKey"
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO signing_keys (kid, app_id, alg, private_key, sealed, status, created_at) VALUES (?,?,?,?,?,?,?)",
+		key.ID, key.AppID, key.Alg, key.Key, key.Sealed, models.KeyStatusActive, key.CreatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE signing_keys SET status=?, retire_at=? WHERE app_id=? AND status=? AND kid<>?",
+		models.KeyStatusRetiring, retireAt.Unix(), key.AppID, models.KeyStatusActive, key.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SigningKeys returns the keys of an app in every status, newest first.
+func (s *Storage) SigningKeys(ctx context.Context, appID int) (keys []models.SigningKey, err error) {
+	const op = "storage.sqlite.SigningKeys"
+	stmt, err := s.stmt(ctx,
+		"SELECT kid, app_id, alg, private_key, sealed, status, created_at, retire_at FROM signing_keys WHERE app_id=? ORDER BY created_at DESC, rowid DESC")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := stmt.QueryContext(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	keys, err = scanSigningKeys(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return keys, nil
+}
+
+func (s *Storage) AllSigningKeys(ctx context.Context) (keys []models.SigningKey, err error) {
+	const op = "storage.sqlite.AllSigningKeys"
+	stmt, err := s.stmt(ctx,
+		"SELECT kid, app_id, alg, private_key, sealed, status, created_at, retire_at FROM signing_keys ORDER BY app_id, created_at DESC, rowid DESC")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	keys, err = scanSigningKeys(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return keys, nil
+}
+
+// RetireExpiredSigningKeys finishes the overlap window of retiring keys.
+func (s *Storage) RetireExpiredSigningKeys(ctx context.Context, now time.Time) (retired int64, err error) {
+	const op = "storage.sqlite.RetireExpiredSigningKeys"
+	stmt, err := s.stmt(ctx,
+		"UPDATE signing_keys SET status=? WHERE status=? AND retire_at <= ?")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, models.KeyStatusRetired, models.KeyStatusRetiring, now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	retired, err = res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return retired, nil
+}
+
+// SealSigningKey replaces a key saved before keys were sealed with its sealed form.
+func (s *Storage) SealSigningKey(ctx context.Context, kid string, sealed []byte) error {
+	const op = "storage.sqlite.SealSigningKey"
+	stmt, err := s.stmt(ctx,
+		"UPDATE signing_keys SET private_key=?, sealed=TRUE WHERE kid=? AND NOT sealed")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := stmt.ExecContext(ctx, sealed, kid); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func scanSigningKeys(rows *sql.Rows) ([]models.SigningKey, error) {
+	defer rows.Close()
+	var keys []models.SigningKey
+	for rows.Next() {
+		var key models.SigningKey
+		var createdAt, retireAt int64
+		if err := rows.Scan(&key.ID, &key.AppID, &key.Alg, &key.Key, &key.Sealed, &key.Status, &createdAt, &retireAt); err != nil {
+			return nil, err
+		}
+		key.CreatedAt = time.Unix(createdAt, 0)
+		if retireAt != 0 {
+			key.RetireAt = time.Unix(retireAt, 0)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
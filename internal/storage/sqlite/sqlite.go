@@ -9,29 +9,128 @@ import (
 	"fmt"
 	"github.com/mattn/go-sqlite3"
 	_ "github.com/mattn/go-sqlite3"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Storage struct {
 	db *sql.DB
+
+	mu    sync.RWMutex
+	stmts map[string]*sql.Stmt
+}
+
+// Options tune the connection to the database. JournalMode, Synchronous and
+// ForeignKeys are passed to the pragmas of the same name; empty values and zero
+// limits leave the sqlite and database/sql defaults in place.
+type Options struct {
+	JournalMode     string
+	Synchronous     string
+	ForeignKeys     string
+	BusyTimeout     time.Duration
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
-func New(storagePath string) (*Storage, error) {
+func New(storagePath string, opts Options) (*Storage, error) {
 	const op = "storage.sqlite.New"
 
-	db, err := sql.Open("sqlite3", storagePath)
+	db, err := sql.Open("sqlite3", dsn(storagePath, opts))
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	return &Storage{db: db}, nil
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	// Connecting applies the pragmas, so a bad option fails here rather than on
+	// the first request.
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &Storage{db: db, stmts: make(map[string]*sql.Stmt)}, nil
+}
+
+// dsn adds the options to the storage path. Transactions begin immediately so
+// that concurrent writers wait out the busy timeout instead of failing with
+// SQLITE_BUSY when their read lock can't be upgraded.
+func dsn(storagePath string, opts Options) string {
+	params := url.Values{}
+	params.Set("_txlock", "immediate")
+	if opts.JournalMode != "" {
+		params.Set("_journal_mode", opts.JournalMode)
+	}
+	if opts.Synchronous != "" {
+		params.Set("_synchronous", opts.Synchronous)
+	}
+	if opts.ForeignKeys != "" {
+		params.Set("_foreign_keys", opts.ForeignKeys)
+	}
+	if opts.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(opts.BusyTimeout.Milliseconds(), 10))
+	}
+	sep := "?"
+	if strings.Contains(storagePath, "?") {
+		sep = "&"
+	}
+	return storagePath + sep + params.Encode()
 }
 
-func (s *Storage) SaveUser(ctx context.Context, email string, passHash []byte) (uid int64, err error) {
+// stmt returns the statement for query, preparing it on first use. Statements
+// are kept until Close. Preparing waits for a free connection, so it happens
+// outside of the lock; whoever stores the statement first wins and the others
+// close theirs.
+func (s *Storage) stmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	s.mu.RLock()
+	stmt, ok := s.stmts[query]
+	s.mu.RUnlock()
+	if ok {
+		return stmt, nil
+	}
+	prepared, err := s.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stmt, ok := s.stmts[query]; ok {
+		prepared.Close()
+		return stmt, nil
+	}
+	s.stmts[query] = prepared
+	return prepared, nil
+}
+
+// Close closes the prepared statements and the database.
+func (s *Storage) Close() error {
+	const op = "storage.sqlite.Close"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for query, stmt := range s.stmts {
+		errs = append(errs, stmt.Close())
+		delete(s.stmts, query)
+	}
+	errs = append(errs, s.db.Close())
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) SaveUser(ctx context.Context, orgID int64, email string, passHash []byte) (uid int64, err error) {
 	const op = "storage.sqlite.SaveUser"
-	stmt, err := s.db.PrepareContext(ctx, "INSERT INTO users (email, pass_hash) VALUES (?,?)")
+	stmt, err := s.stmt(ctx, "INSERT INTO users (org_id, email, pass_hash, created_at) VALUES (?,?,?,?)")
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
-	res, err := stmt.ExecContext(ctx, email, passHash)
+	res, err := stmt.ExecContext(ctx, orgID, email, passHash, time.Now().Unix())
 	var sqlError sqlite3.Error
 	if err != nil {
 		if errors.As(err, &sqlError) && errors.Is(sqlError.ExtendedCode, sqlite3.ErrConstraintUnique) {
@@ -45,14 +144,28 @@ func (s *Storage) SaveUser(ctx context.Context, email string, passHash []byte) (
 	}
 	return uid, nil
 }
-func (s *Storage) User(ctx context.Context, email string) (user models.User, err error) {
+func (s *Storage) User(ctx context.Context, orgID int64, email string) (user models.User, err error) {
 	const op = "storage.sqlite.User"
-	stmt, err := s.db.PrepareContext(ctx, "SELECT id, email, pass_hash FROM users WHERE email=?")
+	stmt, err := s.stmt(ctx, "SELECT "+userColumns+" FROM users WHERE org_id=? AND email=?")
 	if err != nil {
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
-	row := stmt.QueryRowContext(ctx, email)
-	err = row.Scan(&user.ID, &user.Email, &user.PassHash)
+	user, err = scanUser(stmt.QueryRowContext(ctx, orgID, email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, storage.ErrUserNotFound
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, nil
+}
+func (s *Storage) UserByID(ctx context.Context, userID int64) (user models.User, err error) {
+	const op = "storage.sqlite.UserByID"
+	stmt, err := s.stmt(ctx, "SELECT "+userColumns+" FROM users WHERE id=?")
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	user, err = scanUser(stmt.QueryRowContext(ctx, userID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.User{}, storage.ErrUserNotFound
@@ -60,11 +173,10 @@ func (s *Storage) User(ctx context.Context, email string) (user models.User, err
 		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 	return user, nil
-
 }
 func (s *Storage) IsAdmin(ctx context.Context, userID int64) (isAdmin bool, err error) {
 	const op = "storage.sqlite.IsAdmin"
-	stmt, err := s.db.PrepareContext(ctx, "SELECT is_admin FROM users WHERE id=?")
+	stmt, err := s.stmt(ctx, "SELECT is_admin FROM users WHERE id=?")
 	if err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
@@ -80,17 +192,165 @@ func (s *Storage) IsAdmin(ctx context.Context, userID int64) (isAdmin bool, err
 }
 func (s *Storage) App(ctx context.Context, appId int32) (app models.App, err error) {
 	const op = "storage.sqlite.App"
-	stmt, err := s.db.PrepareContext(ctx, "SELECT id, name, secret FROM apps WHERE id=?")
+	stmt, err := s.stmt(ctx, "SELECT id, org_id, name, secret, signing_alg, require_verified_email, restricted, client_scopes, secret_signing FROM apps WHERE id=?")
 	if err != nil {
 		return models.App{}, fmt.Errorf("%s: %w", op, err)
 	}
+	var clientScopes string
 	row := stmt.QueryRowContext(ctx, appId)
-	err = row.Scan(&app.ID, &app.Name, &app.Secret)
+	err = row.Scan(&app.ID, &app.OrgID, &app.Name, &app.Secret, &app.SigningAlg, &app.RequireVerifiedEmail, &app.Restricted, &clientScopes, &app.SecretSigning)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.App{}, storage.ErrAppNotFound
 		}
 		return models.App{}, fmt.Errorf("%s: %w", op, err)
 	}
+	app.ClientScopes = strings.Fields(clientScopes)
 	return app, nil
 }
+func (s *Storage) Apps(ctx context.Context) (apps []models.App, err error) {
+	const op = "storage.sqlite.Apps"
+	stmt, err := s.stmt(ctx, "SELECT id, org_id, name, secret, signing_alg, require_verified_email, restricted, client_scopes, secret_signing FROM apps ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var app models.App
+		var clientScopes string
+		if err := rows.Scan(&app.ID, &app.OrgID, &app.Name, &app.Secret, &app.SigningAlg, &app.RequireVerifiedEmail, &app.Restricted, &clientScopes, &app.SecretSigning); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		app.ClientScopes = strings.Fields(clientScopes)
+		apps = append(apps, app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return apps, nil
+}
+func (s *Storage) UpdatePassHash(ctx context.Context, userID int64, passHash []byte) error {
+	const op = "storage.sqlite.UpdatePassHash"
+	stmt, err := s.stmt(ctx, "UPDATE users SET pass_hash=? WHERE id=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, passHash, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return nil
+}
+
+// ChangePassHash replaces the password hash only if it still equals oldHash. It fails
+// with storage.ErrPassHashChanged when another update got there first.
+func (s *Storage) ChangePassHash(ctx context.Context, userID int64, oldHash []byte, newHash []byte) error {
+	const op = "storage.sqlite.ChangePassHash"
+	stmt, err := s.stmt(ctx, "UPDATE users SET pass_hash=? WHERE id=? AND pass_hash=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, newHash, userID, oldHash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected > 0 {
+		return nil
+	}
+	if _, err := s.UserByID(ctx, userID); err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return fmt.Errorf("%s: %w", op, storage.ErrPassHashChanged)
+}
+func (s *Storage) SetEmailVerified(ctx context.Context, userID int64) error {
+	const op = "storage.sqlite.SetEmailVerified"
+	stmt, err := s.stmt(ctx, "UPDATE users SET email_verified = TRUE WHERE id=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	return nil
+}
+
+// RevokeUserTokens revokes every refresh token of the user and invalidates the
+// access tokens issued before at.
+func (s *Storage) RevokeUserTokens(ctx context.Context, userID int64, at time.Time) error {
+	const op = "storage.sqlite.RevokeUserTokens"
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, "UPDATE users SET tokens_revoked_at=? WHERE id=?", at.UnixMilli(), userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked = TRUE WHERE user_id=?", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+const userColumns = "id, org_id, email, pass_hash, email_verified, tokens_revoked_at, is_admin, disabled, created_at"
+
+// scanUser reads a row selected with userColumns.
+func scanUser(row interface{ Scan(dest ...any) error }) (models.User, error) {
+	var user models.User
+	var tokensRevokedAt, createdAt int64
+	err := row.Scan(&user.ID, &user.OrgID, &user.Email, &user.PassHash, &user.EmailVerified, &tokensRevokedAt,
+		&user.IsAdmin, &user.Disabled, &createdAt)
+	if err != nil {
+		return models.User{}, err
+	}
+	user.TokensRevokedAt = unixMilliTime(tokensRevokedAt)
+	if createdAt != 0 {
+		user.CreatedAt = time.Unix(createdAt, 0)
+	}
+	return user, nil
+}
+
+// unixMilliTime converts a stored unix millisecond timestamp, where 0 means unset, to time.Time.
+func unixMilliTime(msec int64) time.Time {
+	if msec == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(msec)
+}
@@ -0,0 +1,121 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/services/auth"
+	"AuthGRPC/internal/storage/storagetest"
+	"context"
+	migrate "github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestStorage opens a migrated database in a temporary directory.
+func newTestStorage(t *testing.T, opts Options) *Storage {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sso.db")
+	m, err := migrate.New("file://../../../migrations", "sqlite3://"+path)
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+	srcErr, dbErr := m.Close()
+	require.NoError(t, srcErr)
+	require.NoError(t, dbErr)
+
+	s, err := New(path, opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestContract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) auth.Storage {
+		return newTestStorage(t, Options{ForeignKeys: "on", BusyTimeout: time.Second})
+	})
+}
+
+func TestNew_Pragmas(t *testing.T) {
+	s := newTestStorage(t, Options{
+		JournalMode:  "WAL",
+		Synchronous:  "NORMAL",
+		ForeignKeys:  "on",
+		BusyTimeout:  1500 * time.Millisecond,
+		MaxOpenConns: 1,
+	})
+	ctx := context.Background()
+
+	var journalMode string
+	require.NoError(t, s.db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode))
+	assert.Equal(t, "wal", journalMode)
+	var synchronous, foreignKeys, busyTimeout int
+	require.NoError(t, s.db.QueryRowContext(ctx, "PRAGMA synchronous").Scan(&synchronous))
+	assert.Equal(t, 1, synchronous, "NORMAL")
+	require.NoError(t, s.db.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys))
+	assert.Equal(t, 1, foreignKeys)
+	require.NoError(t, s.db.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout))
+	assert.Equal(t, 1500, busyTimeout)
+}
+
+func TestNew_BadOption(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "sso.db"), Options{JournalMode: "bogus"})
+	assert.Error(t, err)
+}
+
+func TestStmt_Cache(t *testing.T) {
+	s := newTestStorage(t, Options{MaxOpenConns: 2})
+	ctx := context.Background()
+	const query = "SELECT id FROM users WHERE id=?"
+
+	stmts := make([]any, 8)
+	var wg sync.WaitGroup
+	for i := range stmts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stmt, err := s.stmt(ctx, query)
+			assert.NoError(t, err)
+			stmts[i] = stmt
+		}()
+	}
+	wg.Wait()
+	for _, stmt := range stmts {
+		assert.Same(t, stmts[0], stmt)
+	}
+	assert.Len(t, s.stmts, 1)
+
+	_, err := s.stmt(ctx, "SELECT nope FROM users")
+	assert.Error(t, err)
+	assert.Len(t, s.stmts, 1, "failed statements aren't cached")
+}
+
+func TestReplaceRecoveryCodes_SingleConnection(t *testing.T) {
+	s := newTestStorage(t, Options{MaxOpenConns: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	userID, err := s.SaveUser(ctx, 1, "user@sso.test", []byte("hash"))
+	require.NoError(t, err)
+
+	// The insert is prepared on first use, which must not wait for the
+	// connection held by the transaction.
+	require.NoError(t, s.ReplaceRecoveryCodes(ctx, userID, [][]byte{[]byte("a"), []byte("b")}))
+	codes, err := s.RecoveryCodes(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, codes, 2)
+}
+
+func TestClose(t *testing.T) {
+	s := newTestStorage(t, Options{})
+	ctx := context.Background()
+	_, err := s.stmt(ctx, "SELECT id FROM users WHERE id=?")
+	require.NoError(t, err)
+
+	require.NoError(t, s.Close())
+	assert.Empty(t, s.stmts)
+	assert.Error(t, s.db.PingContext(ctx))
+	_, err = s.stmt(ctx, "SELECT id FROM users WHERE id=?")
+	assert.Error(t, err)
+}
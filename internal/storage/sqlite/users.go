@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/storage"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Users returns the users matching the filter ordered by id.
+func (s *Storage) Users(ctx context.Context, filter models.UserFilter) (users []models.User, err error) {
+	const op = "storage.sqlite.Users"
+	query := "SELECT " + userColumns + " FROM users WHERE id > ?"
+	args := []any{filter.AfterID}
+	if filter.OrgID != 0 {
+		query += " AND org_id = ?"
+		args = append(args, filter.OrgID)
+	}
+	if filter.EmailPrefix != "" {
+		query += ` AND email LIKE ? ESCAPE '\'`
+		args = append(args, escapeLike(filter.EmailPrefix)+"%")
+	}
+	if filter.IsAdmin != nil {
+		query += " AND is_admin = ?"
+		args = append(args, *filter.IsAdmin)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, filter.CreatedAfter.Unix())
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query += " AND created_at < ?"
+		args = append(args, filter.CreatedBefore.Unix())
+	}
+	query += " ORDER BY id"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	stmt, err := s.stmt(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return users, nil
+}
+
+func (s *Storage) SetAdmin(ctx context.Context, userID int64, isAdmin bool) error {
+	const op = "storage.sqlite.SetAdmin"
+	stmt, err := s.stmt(ctx, "UPDATE users SET is_admin=? WHERE id=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, isAdmin, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := requireAffected(res, storage.ErrUserNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Storage) SetUserDisabled(ctx context.Context, userID int64, disabled bool) error {
+	const op = "storage.sqlite.SetUserDisabled"
+	stmt, err := s.stmt(ctx, "UPDATE users SET disabled=? WHERE id=?")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := stmt.ExecContext(ctx, disabled, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := requireAffected(res, storage.ErrUserNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteUser removes the user and everything issued to them. The audit log is
+// kept. Dependent rows are deleted explicitly, since foreign keys may not be
+// enforced on the connection.
+func (s *Storage) DeleteUser(ctx context.Context, userID int64) error {
+	const op = "storage.sqlite.DeleteUser"
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+	for _, table := range []string{"refresh_tokens", "email_tokens", "user_mfa", "mfa_challenges", "mfa_recovery_codes", "authorization_codes", "user_roles", "app_members"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE user_id=?", userID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id=?", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := requireAffected(res, storage.ErrUserNotFound); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcards in s for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -3,7 +3,22 @@ package storage
 import "errors"
 
 var (
-	ErrUserAlreadyExists = errors.New("user already exists")
-	ErrUserNotFound      = errors.New("user not found")
-	ErrAppNotFound       = errors.New("app not found")
+	ErrUserAlreadyExists    = errors.New("user already exists")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrAppNotFound          = errors.New("app not found")
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenUsed     = errors.New("refresh token already used")
+	ErrEmailTokenNotFound   = errors.New("email token not found")
+	ErrPassHashChanged      = errors.New("password hash was changed concurrently")
+	ErrMFANotFound          = errors.New("mfa enrollment not found")
+	ErrMFAStepUsed          = errors.New("mfa code already used")
+	ErrMFAChallengeNotFound = errors.New("mfa challenge not found")
+	ErrRecoveryCodeUsed     = errors.New("recovery code already used")
+	ErrAuthCodeNotFound     = errors.New("authorization code not found")
+	ErrAppAlreadyExists     = errors.New("app already exists")
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrRoleAlreadyExists    = errors.New("role already exists")
+	ErrPolicyNotFound       = errors.New("policy not found")
+	ErrOrgNotFound          = errors.New("org not found")
+	ErrOrgAlreadyExists     = errors.New("org already exists")
 )
@@ -0,0 +1,241 @@
+// Package storagetest holds the behavior every storage backend has to share,
+// so the sqlite and memory storages are held to the same contract.
+package storagetest
+
+import (
+	"AuthGRPC/internal/domain/models"
+	"AuthGRPC/internal/services/auth"
+	"AuthGRPC/internal/storage"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// Run runs the contract against the storages newStorage returns. Every test
+// gets a storage of its own, with the schema in place but no test data.
+func Run(t *testing.T, newStorage func(t *testing.T) auth.Storage) {
+	t.Run("RefreshTokenReuse", func(t *testing.T) { testRefreshTokenReuse(t, newStorage(t)) })
+	t.Run("DeleteExpiredRefreshTokens", func(t *testing.T) { testDeleteExpiredRefreshTokens(t, newStorage(t)) })
+	t.Run("SigningKeys", func(t *testing.T) { testSigningKeys(t, newStorage(t)) })
+	t.Run("AppAccess", func(t *testing.T) { testAppAccess(t, newStorage(t)) })
+	t.Run("LoginAttempts", func(t *testing.T) { testLoginAttempts(t, newStorage(t)) })
+}
+
+// now is truncated to seconds, the precision every backend keeps.
+func now() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
+func newUserAndApp(t *testing.T, s auth.Storage, name string) (userID int64, appID int) {
+	t.Helper()
+	ctx := context.Background()
+	userID, err := s.SaveUser(ctx, models.DefaultOrgID, name+"@storagetest.local", []byte("hash"))
+	require.NoError(t, err)
+	appID, err = s.SaveApp(ctx, models.App{OrgID: models.DefaultOrgID, Name: name, Secret: name + "-secret", SigningAlg: "HS256"})
+	require.NoError(t, err)
+	return userID, appID
+}
+
+func testRefreshTokenReuse(t *testing.T, s auth.Storage) {
+	ctx := context.Background()
+	userID, appID := newUserAndApp(t, s, "refresh")
+	saveToken := func(hash string) models.RefreshToken {
+		t.Helper()
+		require.NoError(t, s.SaveRefreshToken(ctx, models.RefreshToken{
+			TokenHash: hash, FamilyID: "family", UserID: userID, AppID: appID, ExpiresAt: now().Add(time.Hour),
+		}))
+		token, err := s.RefreshToken(ctx, hash)
+		require.NoError(t, err)
+		return token
+	}
+
+	first := saveToken("first")
+	assert.False(t, first.Used)
+	assert.Equal(t, userID, first.UserID)
+	assert.Equal(t, appID, first.AppID)
+	require.NoError(t, s.UseRefreshToken(ctx, first.ID))
+	assert.ErrorIs(t, s.UseRefreshToken(ctx, first.ID), storage.ErrRefreshTokenUsed, "tokens are single-use")
+	first, err := s.RefreshToken(ctx, "first")
+	require.NoError(t, err)
+	assert.True(t, first.Used, "used tokens are kept to detect reuse")
+
+	second := saveToken("second")
+	require.NoError(t, s.RevokeRefreshTokenFamily(ctx, "family"))
+	second, err = s.RefreshToken(ctx, "second")
+	require.NoError(t, err)
+	assert.True(t, second.Revoked)
+	assert.ErrorIs(t, s.UseRefreshToken(ctx, second.ID), storage.ErrRefreshTokenUsed, "revoked tokens can't be used")
+
+	_, err = s.RefreshToken(ctx, "missing")
+	assert.ErrorIs(t, err, storage.ErrRefreshTokenNotFound)
+}
+
+func testDeleteExpiredRefreshTokens(t *testing.T, s auth.Storage) {
+	ctx := context.Background()
+	userID, appID := newUserAndApp(t, s, "expired")
+	save := func(hash string, family string, expiresAt time.Time) {
+		t.Helper()
+		require.NoError(t, s.SaveRefreshToken(ctx, models.RefreshToken{
+			TokenHash: hash, FamilyID: family, UserID: userID, AppID: appID, ExpiresAt: expiresAt,
+		}))
+	}
+	save("expired", "a", now().Add(-time.Hour))
+	save("revoked", "b", now().Add(time.Hour))
+	save("used", "c", now().Add(time.Hour))
+	save("live", "d", now().Add(time.Hour))
+	require.NoError(t, s.RevokeRefreshTokenFamily(ctx, "b"))
+	used, err := s.RefreshToken(ctx, "used")
+	require.NoError(t, err)
+	require.NoError(t, s.UseRefreshToken(ctx, used.ID))
+
+	deleted, err := s.DeleteExpiredRefreshTokens(ctx, now())
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, deleted)
+	for _, hash := range []string{"expired", "revoked"} {
+		_, err := s.RefreshToken(ctx, hash)
+		assert.ErrorIs(t, err, storage.ErrRefreshTokenNotFound, hash)
+	}
+	for _, hash := range []string{"used", "live"} {
+		_, err := s.RefreshToken(ctx, hash)
+		assert.NoError(t, err, hash)
+	}
+}
+
+func testSigningKeys(t *testing.T, s auth.Storage) {
+	ctx := context.Background()
+	_, appID := newUserAndApp(t, s, "keys")
+	_, otherAppID := newUserAndApp(t, s, "other-keys")
+	rotate := func(id string, app int, createdAt time.Time, retireAt time.Time) {
+		t.Helper()
+		require.NoError(t, s.RotateSigningKey(ctx, models.SigningKey{
+			ID: id, AppID: app, Alg: "HS256", Key: []byte(id + "-key"), CreatedAt: createdAt,
+		}, retireAt))
+	}
+	statuses := func(app int) map[string]string {
+		t.Helper()
+		keys, err := s.SigningKeys(ctx, app)
+		require.NoError(t, err)
+		result := make(map[string]string, len(keys))
+		for _, key := range keys {
+			result[key.ID] = key.Status
+		}
+		return result
+	}
+
+	rotate("first", appID, now().Add(-2*time.Second), now().Add(time.Hour))
+	rotate("other", otherAppID, now().Add(-2*time.Second), now().Add(time.Hour))
+	rotate("second", appID, now().Add(-time.Second), now().Add(time.Hour))
+	assert.Equal(t, map[string]string{"second": models.KeyStatusActive, "first": models.KeyStatusRetiring}, statuses(appID))
+	assert.Equal(t, map[string]string{"other": models.KeyStatusActive}, statuses(otherAppID), "rotation is per app")
+
+	// Rotating again retires the active key right away; the one that was
+	// already retiring keeps its retirement time.
+	rotate("third", appID, now(), now().Add(-time.Second))
+	retired, err := s.RetireExpiredSigningKeys(ctx, now())
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, retired)
+	assert.Equal(t, map[string]string{
+		"third":  models.KeyStatusActive,
+		"second": models.KeyStatusRetired,
+		"first":  models.KeyStatusRetiring,
+	}, statuses(appID))
+
+	keys, err := s.SigningKeys(ctx, appID)
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	assert.Equal(t, []string{"third", "second", "first"}, []string{keys[0].ID, keys[1].ID, keys[2].ID}, "newest first")
+	assert.Equal(t, []byte("third-key"), keys[0].Key)
+	assert.Equal(t, now().Add(time.Hour), keys[2].RetireAt.Local())
+
+	all, err := s.AllSigningKeys(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	assert.Error(t, s.RotateSigningKey(ctx, models.SigningKey{ID: "third", AppID: appID, Alg: "HS256", Key: []byte("k"), CreatedAt: now()}, now()),
+		"key ids are unique")
+	assert.Equal(t, models.KeyStatusActive, statuses(appID)["third"], "a failed rotation changes nothing")
+
+	require.NoError(t, s.RotateSigningKey(ctx, models.SigningKey{
+		ID: "fourth", AppID: appID, Alg: "HS256", Key: []byte("sealed-fourth"), Sealed: true, CreatedAt: now().Add(time.Second),
+	}, now().Add(time.Hour)))
+	require.NoError(t, s.SealSigningKey(ctx, "third", []byte("sealed-third")))
+	require.NoError(t, s.SealSigningKey(ctx, "fourth", []byte("sealed-again")))
+	keys, err = s.SigningKeys(ctx, appID)
+	require.NoError(t, err)
+	require.Len(t, keys, 4)
+	for i, want := range []string{"sealed-fourth", "sealed-third"} {
+		assert.True(t, keys[i].Sealed, keys[i].ID)
+		assert.Equal(t, []byte(want), keys[i].Key, "keys are sealed once")
+	}
+	assert.False(t, keys[2].Sealed)
+}
+
+func testAppAccess(t *testing.T, s auth.Storage) {
+	ctx := context.Background()
+	userID, appID := newUserAndApp(t, s, "access")
+	hasAccess := func() bool {
+		t.Helper()
+		ok, err := s.HasAppAccess(ctx, userID, appID)
+		require.NoError(t, err)
+		return ok
+	}
+
+	assert.False(t, hasAccess())
+	require.NoError(t, s.SetAppAccess(ctx, userID, appID, true))
+	assert.True(t, hasAccess())
+	require.NoError(t, s.SetAppAccess(ctx, userID, appID, false))
+	assert.False(t, hasAccess())
+
+	require.NoError(t, s.SetAppAdmin(ctx, userID, appID, true))
+	assert.True(t, hasAccess(), "app admins always have access")
+	appIDs, err := s.AdminApps(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{appID}, appIDs)
+	require.NoError(t, s.SetAppAdmin(ctx, userID, appID, false))
+	assert.False(t, hasAccess())
+}
+
+func testLoginAttempts(t *testing.T, s auth.Storage) {
+	ctx := context.Background()
+	start := now()
+	window := 15 * time.Minute
+
+	attempts, err := s.LoginAttempts(ctx, "email:user")
+	require.NoError(t, err)
+	assert.Equal(t, models.LoginAttempts{Key: "email:user"}, attempts)
+
+	for want := 1; want <= 2; want++ {
+		failures, err := s.AddLoginFailure(ctx, "email:user", start, start.Add(-window))
+		require.NoError(t, err)
+		assert.Equal(t, want, failures)
+	}
+	require.NoError(t, s.LockLogin(ctx, "email:user", start.Add(time.Minute)))
+	attempts, err = s.LoginAttempts(ctx, "email:user")
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts.Failures)
+	assert.True(t, attempts.LockedUntil.Equal(start.Add(time.Minute)))
+
+	later := start.Add(window + time.Minute)
+	failures, err := s.AddLoginFailure(ctx, "email:user", later, later.Add(-window))
+	require.NoError(t, err)
+	assert.Equal(t, 1, failures, "failures outside of the window are forgotten")
+
+	_, err = s.AddLoginFailure(ctx, "ip:stale", start.Add(-time.Hour), start.Add(-2*time.Hour))
+	require.NoError(t, err)
+	_, err = s.AddLoginFailure(ctx, "ip:locked", start.Add(-time.Hour), start.Add(-2*time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, s.LockLogin(ctx, "ip:locked", start.Add(time.Hour)))
+	deleted, err := s.DeleteStaleLoginAttempts(ctx, start, start.Add(-window))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted, "only unlocked counters outside of the window go")
+	attempts, err = s.LoginAttempts(ctx, "ip:locked")
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts.Failures)
+
+	require.NoError(t, s.DeleteLoginAttempts(ctx, "email:user"))
+	attempts, err = s.LoginAttempts(ctx, "email:user")
+	require.NoError(t, err)
+	assert.Zero(t, attempts.Failures)
+}
@@ -0,0 +1,3 @@
+-- unix milliseconds, 0 if the user's tokens were never revoked
+ALTER TABLE users
+    ADD COLUMN tokens_revoked_at INTEGER NOT NULL DEFAULT 0;
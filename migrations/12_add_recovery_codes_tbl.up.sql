@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes
+(
+    id        INTEGER PRIMARY KEY,
+    user_id   INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash BLOB    NOT NULL,
+    used_at   INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes (user_id);
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id         INTEGER PRIMARY KEY,
+    user_id    INTEGER NOT NULL,
+    event      TEXT    NOT NULL,
+    ip         TEXT    NOT NULL DEFAULT '',
+    detail     TEXT    NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user_id ON audit_log (user_id, created_at);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS app_redirect_uris
+(
+    app_id       INTEGER NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    redirect_uri TEXT    NOT NULL,
+    PRIMARY KEY (app_id, redirect_uri)
+);
+CREATE TABLE IF NOT EXISTS authorization_codes
+(
+    code_hash      TEXT PRIMARY KEY,
+    app_id         INTEGER NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    user_id        INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    redirect_uri   TEXT    NOT NULL,
+    code_challenge TEXT    NOT NULL,
+    scope          TEXT    NOT NULL DEFAULT '',
+    expires_at     INTEGER NOT NULL,
+    used           BOOLEAN NOT NULL DEFAULT FALSE
+);
@@ -0,0 +1 @@
+ALTER TABLE apps ADD COLUMN client_scopes TEXT NOT NULL DEFAULT '';
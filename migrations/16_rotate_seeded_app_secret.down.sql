@@ -0,0 +1,2 @@
+-- The seeded secret was publicly known and isn't restored. Nothing to undo.
+SELECT 1;
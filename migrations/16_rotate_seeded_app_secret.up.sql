@@ -0,0 +1,6 @@
+-- 3_add_app seeded the app with a publicly known secret. Apps are created with
+-- the CreateApp admin RPC now; the new secret can be obtained with RotateAppSecret.
+UPDATE apps
+SET secret = lower(hex(randomblob(32)))
+WHERE id = 1
+  AND secret = 'test-secret';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_created_at;
+ALTER TABLE users DROP COLUMN disabled;
+ALTER TABLE users DROP COLUMN created_at;
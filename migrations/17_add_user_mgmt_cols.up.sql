@@ -0,0 +1,4 @@
+-- created_at stays 0 for users registered before it was tracked.
+ALTER TABLE users ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users (created_at);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS policies
+(
+    app_id     INTEGER NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    version    INTEGER NOT NULL,
+    rules      TEXT    NOT NULL,
+    created_by INTEGER NOT NULL,
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (app_id, version)
+);
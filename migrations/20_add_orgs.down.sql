@@ -0,0 +1,36 @@
+-- Fails if an email or app name is used in more than one org.
+CREATE TABLE apps_old
+(
+    id                     INTEGER PRIMARY KEY,
+    name                   TEXT    NOT NULL UNIQUE,
+    secret                 TEXT    NOT NULL UNIQUE,
+    signing_alg            TEXT    NOT NULL DEFAULT 'HS256',
+    require_verified_email BOOLEAN NOT NULL DEFAULT FALSE,
+    client_scopes          TEXT    NOT NULL DEFAULT ''
+);
+INSERT INTO apps_old (id, name, secret, signing_alg, require_verified_email, client_scopes)
+SELECT id, name, secret, signing_alg, require_verified_email, client_scopes
+FROM apps;
+DROP TABLE apps;
+ALTER TABLE apps_old RENAME TO apps;
+
+CREATE TABLE users_old
+(
+    id                INTEGER PRIMARY KEY,
+    email             TEXT    NOT NULL UNIQUE,
+    pass_hash         BLOB    NOT NULL,
+    is_admin          BOOLEAN NOT NULL DEFAULT FALSE,
+    email_verified    BOOLEAN NOT NULL DEFAULT FALSE,
+    tokens_revoked_at INTEGER NOT NULL DEFAULT 0,
+    created_at        INTEGER NOT NULL DEFAULT 0,
+    disabled          BOOLEAN NOT NULL DEFAULT FALSE
+);
+INSERT INTO users_old (id, email, pass_hash, is_admin, email_verified, tokens_revoked_at, created_at, disabled)
+SELECT id, email, pass_hash, is_admin, email_verified, tokens_revoked_at, created_at, disabled
+FROM users;
+DROP TABLE users;
+ALTER TABLE users_old RENAME TO users;
+CREATE INDEX IF NOT EXISTS idx_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users (created_at);
+
+DROP TABLE IF EXISTS orgs;
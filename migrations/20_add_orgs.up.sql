@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS orgs
+(
+    id         INTEGER PRIMARY KEY,
+    name       TEXT    NOT NULL UNIQUE,
+    created_at INTEGER NOT NULL DEFAULT 0
+);
+-- Everything that existed before orgs belongs to the default org. Its admins
+-- manage every org.
+INSERT INTO orgs (id, name)
+VALUES (1, 'default')
+ON CONFLICT DO NOTHING;
+
+-- SQLite can't drop the UNIQUE constraints on users.email and apps.name, so both
+-- tables are rebuilt to make them unique per org.
+CREATE TABLE users_new
+(
+    id                INTEGER PRIMARY KEY,
+    org_id            INTEGER NOT NULL DEFAULT 1 REFERENCES orgs (id),
+    email             TEXT    NOT NULL,
+    pass_hash         BLOB    NOT NULL,
+    is_admin          BOOLEAN NOT NULL DEFAULT FALSE,
+    email_verified    BOOLEAN NOT NULL DEFAULT FALSE,
+    tokens_revoked_at INTEGER NOT NULL DEFAULT 0,
+    created_at        INTEGER NOT NULL DEFAULT 0,
+    disabled          BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (org_id, email)
+);
+INSERT INTO users_new (id, email, pass_hash, is_admin, email_verified, tokens_revoked_at, created_at, disabled)
+SELECT id, email, pass_hash, is_admin, email_verified, tokens_revoked_at, created_at, disabled
+FROM users;
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+CREATE INDEX IF NOT EXISTS idx_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users (created_at);
+
+CREATE TABLE apps_new
+(
+    id                     INTEGER PRIMARY KEY,
+    org_id                 INTEGER NOT NULL DEFAULT 1 REFERENCES orgs (id),
+    name                   TEXT    NOT NULL,
+    secret                 TEXT    NOT NULL UNIQUE,
+    signing_alg            TEXT    NOT NULL DEFAULT 'HS256',
+    require_verified_email BOOLEAN NOT NULL DEFAULT FALSE,
+    client_scopes          TEXT    NOT NULL DEFAULT '',
+    UNIQUE (org_id, name)
+);
+INSERT INTO apps_new (id, name, secret, signing_alg, require_verified_email, client_scopes)
+SELECT id, name, secret, signing_alg, require_verified_email, client_scopes
+FROM apps;
+DROP TABLE apps;
+ALTER TABLE apps_new RENAME TO apps;
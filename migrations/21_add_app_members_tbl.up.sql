@@ -0,0 +1,10 @@
+-- users.is_admin stays as it is and keeps meaning admin of the user's org, or of
+-- every org for the default one. app_members only adds admins of single apps.
+CREATE TABLE IF NOT EXISTS app_members
+(
+    user_id  INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    app_id   INTEGER NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (user_id, app_id)
+);
+CREATE INDEX IF NOT EXISTS idx_app_members_app_id ON app_members (app_id);
@@ -0,0 +1,2 @@
+ALTER TABLE app_members DROP COLUMN has_access;
+ALTER TABLE apps DROP COLUMN restricted;
@@ -0,0 +1,6 @@
+-- Only members of restricted apps with has_access, and the app's admins, may
+-- log into them. Unrestricted apps ignore has_access.
+ALTER TABLE apps
+    ADD COLUMN restricted BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE app_members
+    ADD COLUMN has_access BOOLEAN NOT NULL DEFAULT FALSE;
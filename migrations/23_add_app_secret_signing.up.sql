@@ -0,0 +1,8 @@
+-- Apps that predate managed signing keys sign HS256 tokens with their secret
+-- until their first key rotation. Apps created since then always had a key.
+ALTER TABLE apps
+    ADD COLUMN secret_signing BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE apps
+SET secret_signing = TRUE
+WHERE signing_alg = 'HS256'
+  AND id NOT IN (SELECT app_id FROM signing_keys);
@@ -0,0 +1,20 @@
+CREATE TABLE users_old
+(
+    id                INTEGER PRIMARY KEY,
+    org_id            INTEGER NOT NULL DEFAULT 1 REFERENCES orgs (id),
+    email             TEXT    NOT NULL,
+    pass_hash         BLOB    NOT NULL,
+    is_admin          BOOLEAN NOT NULL DEFAULT FALSE,
+    email_verified    BOOLEAN NOT NULL DEFAULT FALSE,
+    tokens_revoked_at INTEGER NOT NULL DEFAULT 0,
+    created_at        INTEGER NOT NULL DEFAULT 0,
+    disabled          BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (org_id, email)
+);
+INSERT INTO users_old (id, org_id, email, pass_hash, is_admin, email_verified, tokens_revoked_at, created_at, disabled)
+SELECT id, org_id, email, pass_hash, is_admin, email_verified, tokens_revoked_at, created_at, disabled
+FROM users;
+DROP TABLE users;
+ALTER TABLE users_old RENAME TO users;
+CREATE INDEX IF NOT EXISTS idx_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users (created_at);
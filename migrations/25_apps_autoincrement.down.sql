@@ -0,0 +1,18 @@
+CREATE TABLE apps_old
+(
+    id                     INTEGER PRIMARY KEY,
+    org_id                 INTEGER NOT NULL DEFAULT 1 REFERENCES orgs (id),
+    name                   TEXT    NOT NULL,
+    secret                 TEXT    NOT NULL UNIQUE,
+    signing_alg            TEXT    NOT NULL DEFAULT 'HS256',
+    require_verified_email BOOLEAN NOT NULL DEFAULT FALSE,
+    client_scopes          TEXT    NOT NULL DEFAULT '',
+    restricted             BOOLEAN NOT NULL DEFAULT FALSE,
+    secret_signing         BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (org_id, name)
+);
+INSERT INTO apps_old (id, org_id, name, secret, signing_alg, require_verified_email, client_scopes, restricted, secret_signing)
+SELECT id, org_id, name, secret, signing_alg, require_verified_email, client_scopes, restricted, secret_signing
+FROM apps;
+DROP TABLE apps;
+ALTER TABLE apps_old RENAME TO apps;
@@ -0,0 +1,3 @@
+-- Older versions can't open sealed keys. Rotate the signing key of every app
+-- after downgrading.
+ALTER TABLE signing_keys DROP COLUMN sealed;
@@ -0,0 +1,4 @@
+-- Private keys are sealed with the MFA encryption key from now on. Keys saved
+-- before stay readable and are sealed by the next cleanup run.
+ALTER TABLE signing_keys
+    ADD COLUMN sealed BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,12 @@
+ALTER TABLE apps
+    ADD COLUMN signing_alg TEXT NOT NULL DEFAULT 'HS256';
+
+CREATE TABLE IF NOT EXISTS signing_keys
+(
+    kid         TEXT PRIMARY KEY,
+    app_id      INTEGER NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    alg         TEXT    NOT NULL,
+    private_key BLOB    NOT NULL,
+    created_at  INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_signing_keys_app_id ON signing_keys (app_id);
@@ -0,0 +1,2 @@
+ALTER TABLE signing_keys DROP COLUMN retire_at;
+ALTER TABLE signing_keys DROP COLUMN status;
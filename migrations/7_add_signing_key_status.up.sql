@@ -0,0 +1,4 @@
+ALTER TABLE signing_keys
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE signing_keys
+    ADD COLUMN retire_at INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+ALTER TABLE users
+    ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE apps
+    ADD COLUMN require_verified_email BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE TABLE IF NOT EXISTS email_tokens
+(
+    token_hash TEXT PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose    TEXT    NOT NULL,
+    expires_at INTEGER NOT NULL,
+    used       BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE INDEX IF NOT EXISTS idx_email_tokens_user_id ON email_tokens (user_id, purpose);
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestAppAccess_RestrictedApp(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	respApp, err := st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{Name: "internal-" + gofakeit.UUID(), Restricted: true})
+	require.NoError(t, err)
+	assert.True(t, respApp.GetApp().GetRestricted())
+	restrictedAppID := respApp.GetApp().GetId()
+
+	email, password := registerUser(ctx, t, st)
+	respUsers, err := st.AdminClient.ListUsers(adminCtx, &ssoa.ListUsersRequest{EmailPrefix: email})
+	require.NoError(t, err)
+	require.Len(t, respUsers.GetUsers(), 1)
+	userID := respUsers.GetUsers()[0].GetId()
+
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: restrictedAppID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: appId})
+	require.NoError(t, err, "unrestricted apps let every user in")
+
+	_, err = st.AdminClient.GrantAppAccess(adminCtx, &ssoa.GrantAppAccessRequest{UserId: userID, AppId: restrictedAppID})
+	require.NoError(t, err)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: restrictedAppID})
+	require.NoError(t, err)
+	respRefresh, err := st.AuthClient.Refresh(ctx, &ssoa.RefreshRequest{RefreshToken: respLogin.GetRefreshToken()})
+	require.NoError(t, err)
+
+	_, err = st.AdminClient.RevokeAppAccess(adminCtx, &ssoa.RevokeAppAccessRequest{UserId: userID, AppId: restrictedAppID})
+	require.NoError(t, err)
+	_, err = st.AuthClient.Refresh(ctx, &ssoa.RefreshRequest{RefreshToken: respRefresh.GetRefreshToken()})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: restrictedAppID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AdminClient.SetAppAdmin(adminCtx, &ssoa.SetAppAdminRequest{UserId: userID, AppId: restrictedAppID, IsAdmin: true})
+	require.NoError(t, err)
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: restrictedAppID})
+	require.NoError(t, err, "app admins may always log in")
+}
+
+func TestAppAccess_Grants(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	respApp, err := st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{Name: "internal-" + gofakeit.UUID()})
+	require.NoError(t, err)
+	appID := respApp.GetApp().GetId()
+
+	_, err = st.AdminClient.GrantAppAccess(adminCtx, &ssoa.GrantAppAccessRequest{UserId: 1 << 40, AppId: appID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.AdminClient.GrantAppAccess(adminCtx, &ssoa.GrantAppAccessRequest{UserId: 1, AppId: 1 << 30})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	email, password := registerUser(ctx, t, st)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: appId})
+	require.NoError(t, err)
+	userCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+	_, err = st.AdminClient.GrantAppAccess(userCtx, &ssoa.GrantAppAccessRequest{UserId: 1, AppId: appID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AdminClient.UpdateApp(adminCtx, &ssoa.UpdateAppRequest{AppId: appID, Name: respApp.GetApp().GetName(), Restricted: true})
+	require.NoError(t, err)
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: appID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestAppAdmins_Scope(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	respApp, err := st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{Name: "managed-" + gofakeit.UUID()})
+	require.NoError(t, err)
+	managedAppID := respApp.GetApp().GetId()
+	respApp, err = st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{Name: "other-" + gofakeit.UUID()})
+	require.NoError(t, err)
+	otherAppID := respApp.GetApp().GetId()
+
+	email, password := registerUser(ctx, t, st)
+	respUsers, err := st.AdminClient.ListUsers(adminCtx, &ssoa.ListUsersRequest{EmailPrefix: email})
+	require.NoError(t, err)
+	require.Len(t, respUsers.GetUsers(), 1)
+	userID := respUsers.GetUsers()[0].GetId()
+	otherEmail, _ := registerUser(ctx, t, st)
+	respUsers, err = st.AdminClient.ListUsers(adminCtx, &ssoa.ListUsersRequest{EmailPrefix: otherEmail})
+	require.NoError(t, err)
+	require.Len(t, respUsers.GetUsers(), 1)
+	otherUserID := respUsers.GetUsers()[0].GetId()
+
+	_, err = st.AdminClient.SetAppAdmin(adminCtx, &ssoa.SetAppAdminRequest{UserId: userID, AppId: managedAppID, IsAdmin: true})
+	require.NoError(t, err)
+
+	respIsAdmin, err := st.AuthClient.IsAdmin(ctx, &ssoa.IsAdminRequest{UserId: userID, AppId: managedAppID})
+	require.NoError(t, err)
+	assert.True(t, respIsAdmin.GetIsAdmin())
+	respIsAdmin, err = st.AuthClient.IsAdmin(ctx, &ssoa.IsAdminRequest{UserId: userID, AppId: otherAppID})
+	require.NoError(t, err)
+	assert.False(t, respIsAdmin.GetIsAdmin())
+	respIsAdmin, err = st.AuthClient.IsAdmin(ctx, &ssoa.IsAdminRequest{UserId: userID})
+	require.NoError(t, err)
+	assert.False(t, respIsAdmin.GetIsAdmin(), "app admins aren't super admins")
+	respIsAdmin, err = st.AuthClient.IsAdmin(ctx, &ssoa.IsAdminRequest{UserId: otherUserID, AppId: managedAppID})
+	require.NoError(t, err)
+	assert.False(t, respIsAdmin.GetIsAdmin())
+	_, err = st.AuthClient.IsAdmin(ctx, &ssoa.IsAdminRequest{UserId: userID, AppId: 1 << 30})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: managedAppID})
+	require.NoError(t, err)
+	appAdminCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	_, err = st.AdminClient.CreateRole(appAdminCtx, &ssoa.CreateRoleRequest{AppId: managedAppID, Name: "editor"})
+	require.NoError(t, err)
+	_, err = st.AdminClient.CreateRole(appAdminCtx, &ssoa.CreateRoleRequest{AppId: otherAppID, Name: "editor"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	respApps, err := st.AdminClient.ListApps(appAdminCtx, &ssoa.ListAppsRequest{})
+	require.NoError(t, err)
+	require.Len(t, respApps.GetApps(), 1)
+	assert.Equal(t, managedAppID, respApps.GetApps()[0].GetId())
+
+	_, err = st.AdminClient.CreateApp(appAdminCtx, &ssoa.CreateAppRequest{Name: "own-" + gofakeit.UUID()})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AdminClient.DeleteApp(appAdminCtx, &ssoa.DeleteAppRequest{AppId: managedAppID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AdminClient.DisableUser(appAdminCtx, &ssoa.DisableUserRequest{UserId: otherUserID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AdminClient.SetAdmin(appAdminCtx, &ssoa.SetAdminRequest{UserId: userID, IsAdmin: true})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AdminClient.SetAppAdmin(appAdminCtx, &ssoa.SetAppAdminRequest{UserId: otherUserID, AppId: managedAppID, IsAdmin: true})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AdminClient.SetAppAdmin(adminCtx, &ssoa.SetAppAdminRequest{UserId: userID, AppId: managedAppID})
+	require.NoError(t, err)
+	_, err = st.AdminClient.ListApps(appAdminCtx, &ssoa.ListAppsRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestAppAdmins_OtherOrg(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	respOrg, err := st.AdminClient.CreateOrg(adminCtx, &ssoa.CreateOrgRequest{Name: "org-" + gofakeit.UUID()})
+	require.NoError(t, err)
+	respApp, err := st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{Name: "org-app", OrgId: respOrg.GetOrg().GetId()})
+	require.NoError(t, err)
+
+	email, _ := registerUser(ctx, t, st)
+	respUsers, err := st.AdminClient.ListUsers(adminCtx, &ssoa.ListUsersRequest{EmailPrefix: email})
+	require.NoError(t, err)
+	require.Len(t, respUsers.GetUsers(), 1)
+
+	_, err = st.AdminClient.SetAppAdmin(adminCtx, &ssoa.SetAppAdminRequest{UserId: respUsers.GetUsers()[0].GetId(), AppId: respApp.GetApp().GetId(), IsAdmin: true})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
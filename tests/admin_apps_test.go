@@ -0,0 +1,204 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestAdminApps_Lifecycle(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	name := "app-" + gofakeit.UUID()
+
+	respCreate, err := st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{
+		Name:         name,
+		SigningAlg:   "RS256",
+		ClientScopes: []string{"reports:read"},
+		RedirectUris: []string{"https://app.example.com/callback"},
+	})
+	require.NoError(t, err)
+	app := respCreate.GetApp()
+	require.NotZero(t, app.GetId())
+	assert.Equal(t, name, app.GetName())
+	assert.Equal(t, "RS256", app.GetSigningAlg())
+	secret := respCreate.GetSecret()
+	require.NotEmpty(t, secret)
+
+	respGet, err := st.AdminClient.GetApp(adminCtx, &ssoa.GetAppRequest{AppId: app.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://app.example.com/callback"}, respGet.GetApp().GetRedirectUris())
+	assert.Equal(t, []string{"reports:read"}, respGet.GetApp().GetClientScopes())
+
+	respList, err := st.AdminClient.ListApps(adminCtx, &ssoa.ListAppsRequest{})
+	require.NoError(t, err)
+	var listed bool
+	for _, a := range respList.GetApps() {
+		listed = listed || a.GetId() == app.GetId()
+	}
+	assert.True(t, listed)
+
+	respToken, err := st.AuthClient.ClientCredentials(ctx, &ssoa.ClientCredentialsRequest{AppId: app.GetId(), ClientSecret: secret})
+	require.NoError(t, err)
+
+	respRotate, err := st.AdminClient.RotateAppSecret(adminCtx, &ssoa.RotateAppSecretRequest{AppId: app.GetId()})
+	require.NoError(t, err)
+	require.NotEqual(t, secret, respRotate.GetSecret())
+	_, err = st.AuthClient.ClientCredentials(ctx, &ssoa.ClientCredentialsRequest{AppId: app.GetId(), ClientSecret: secret})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = st.AuthClient.ClientCredentials(ctx, &ssoa.ClientCredentialsRequest{AppId: app.GetId(), ClientSecret: respRotate.GetSecret()})
+	require.NoError(t, err)
+
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respToken.GetToken()})
+	require.NoError(t, err)
+	assert.True(t, respValidate.GetValid(), "rotating the secret keeps tokens signed with the managed key valid")
+
+	respUpdate, err := st.AdminClient.UpdateApp(adminCtx, &ssoa.UpdateAppRequest{
+		AppId:                app.GetId(),
+		Name:                 name + "-renamed",
+		SigningAlg:           "ES256",
+		RequireVerifiedEmail: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, name+"-renamed", respUpdate.GetApp().GetName())
+	respGet, err = st.AdminClient.GetApp(adminCtx, &ssoa.GetAppRequest{AppId: app.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", respGet.GetApp().GetSigningAlg())
+	assert.True(t, respGet.GetApp().GetRequireVerifiedEmail())
+	assert.Empty(t, respGet.GetApp().GetRedirectUris())
+
+	_, err = st.AdminClient.DeleteApp(adminCtx, &ssoa.DeleteAppRequest{AppId: app.GetId()})
+	require.NoError(t, err)
+	_, err = st.AdminClient.GetApp(adminCtx, &ssoa.GetAppRequest{AppId: app.GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	respValidate, err = st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respToken.GetToken()})
+	require.NoError(t, err)
+	assert.False(t, respValidate.GetValid())
+	assert.Equal(t, ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_UNKNOWN_APP, respValidate.GetReason())
+}
+
+func TestAdminApps_SecretDoesNotSignTokens(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+
+	respCreate, err := st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{Name: "app-" + gofakeit.UUID(), SigningAlg: "HS256"})
+	require.NoError(t, err)
+	appID := respCreate.GetApp().GetId()
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"uid":   1,
+		"email": adminEmail,
+		"appid": appID,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(respCreate.GetSecret()))
+	require.NoError(t, err)
+
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: forged})
+	require.NoError(t, err)
+	assert.False(t, respValidate.GetValid())
+	assert.Equal(t, ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_BAD_SIGNATURE, respValidate.GetReason())
+
+	respToken, err := st.AuthClient.ClientCredentials(ctx, &ssoa.ClientCredentialsRequest{AppId: appID, ClientSecret: respCreate.GetSecret()})
+	require.NoError(t, err)
+	respValidate, err = st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respToken.GetToken()})
+	require.NoError(t, err)
+	assert.True(t, respValidate.GetValid())
+}
+
+func TestAdminApps_RotateSigningKey(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	respCreate, err := st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{Name: "app-" + gofakeit.UUID(), SigningAlg: "ES256"})
+	require.NoError(t, err)
+	appID := respCreate.GetApp().GetId()
+	clientToken := func() string {
+		t.Helper()
+		resp, err := st.AuthClient.ClientCredentials(ctx, &ssoa.ClientCredentialsRequest{AppId: appID, ClientSecret: respCreate.GetSecret()})
+		require.NoError(t, err)
+		return resp.GetToken()
+	}
+	kid := func(token string) any {
+		t.Helper()
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+		require.NoError(t, err)
+		return parsed.Header["kid"]
+	}
+	before := clientToken()
+
+	respRotate, err := st.AdminClient.RotateSigningKey(adminCtx, &ssoa.RotateSigningKeyRequest{AppId: appID})
+	require.NoError(t, err)
+	require.NotEmpty(t, respRotate.GetKid())
+	assert.NotEqual(t, kid(before), respRotate.GetKid())
+	assert.Equal(t, respRotate.GetKid(), kid(clientToken()), "new tokens are signed with the new key")
+
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: before})
+	require.NoError(t, err)
+	assert.True(t, respValidate.GetValid(), "the previous key keeps verifying its tokens")
+	respJWKS, err := st.AuthClient.JWKS(ctx, &ssoa.JWKSRequest{AppId: appID})
+	require.NoError(t, err)
+	assert.Len(t, respJWKS.GetKeys(), 2)
+
+	_, err = st.AdminClient.RotateSigningKey(adminCtx, &ssoa.RotateSigningKeyRequest{AppId: 99999})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAdminApps_Fails(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	name := "app-" + gofakeit.UUID()
+	_, err := st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{Name: name})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		req  *ssoa.CreateAppRequest
+		code codes.Code
+	}{
+		{name: "duplicate name", req: &ssoa.CreateAppRequest{Name: name}, code: codes.AlreadyExists},
+		{name: "unsupported alg", req: &ssoa.CreateAppRequest{Name: gofakeit.UUID(), SigningAlg: "none"}, code: codes.InvalidArgument},
+		{name: "relative redirect uri", req: &ssoa.CreateAppRequest{Name: gofakeit.UUID(), RedirectUris: []string{"/callback"}}, code: codes.InvalidArgument},
+		{name: "redirect uri with fragment", req: &ssoa.CreateAppRequest{Name: gofakeit.UUID(), RedirectUris: []string{"https://app.example.com/cb#x"}}, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := st.AdminClient.CreateApp(adminCtx, tt.req)
+			require.Error(t, err)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+
+	_, err = st.AdminClient.DeleteApp(adminCtx, &ssoa.DeleteAppRequest{AppId: 99999})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAdminApps_RequiresAdmin(t *testing.T) {
+	ctx, st := suite.New(t)
+	email, password := registerUser(ctx, t, st)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: appId})
+	require.NoError(t, err)
+	userCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	_, err = st.AdminClient.ListApps(userCtx, &ssoa.ListAppsRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AdminClient.RotateAppSecret(ctx, &ssoa.RotateAppSecretRequest{AppId: appId})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAdminApps_RequiresManagedKeyToken(t *testing.T) {
+	ctx, st := suite.New(t)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: adminEmail, Password: adminPassword, AppId: appId})
+	require.NoError(t, err)
+	secretCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	_, err = st.AdminClient.ListApps(secretCtx, &ssoa.ListAppsRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "the test app signs with its secret")
+	_, err = st.AdminClient.ListApps(adminContext(ctx, t, st), &ssoa.ListAppsRequest{})
+	require.NoError(t, err)
+}
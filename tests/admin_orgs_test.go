@@ -0,0 +1,114 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestOrgs_UsersAndTokens(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	respOrg, err := st.AdminClient.CreateOrg(adminCtx, &ssoa.CreateOrgRequest{Name: "org-" + gofakeit.UUID()})
+	require.NoError(t, err)
+	orgID := respOrg.GetOrg().GetId()
+	respApp, err := st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{Name: "test", OrgId: orgID})
+	require.NoError(t, err, "app names are unique per org")
+	orgAppID := respApp.GetApp().GetId()
+	assert.Equal(t, orgID, respApp.GetApp().GetOrgId())
+
+	email := gofakeit.Email()
+	defaultPassword, orgPassword := randomFakePassword(), randomFakePassword()
+	_, err = st.AuthClient.Register(ctx, &ssoa.RegisterRequest{Email: email, Password: defaultPassword})
+	require.NoError(t, err)
+	respRegister, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{Email: email, Password: orgPassword, AppId: orgAppID})
+	require.NoError(t, err, "emails are unique per org")
+	_, err = st.AuthClient.Register(ctx, &ssoa.RegisterRequest{Email: email, Password: orgPassword, AppId: orgAppID})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: orgPassword, AppId: orgAppID})
+	require.NoError(t, err)
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(respLogin.GetToken(), claims)
+	require.NoError(t, err)
+	assert.EqualValues(t, orgID, claims["org"])
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	assert.Equal(t, respRegister.GetUserId(), respValidate.GetUserId())
+	assert.Equal(t, orgID, respValidate.GetOrgId())
+
+	// Each password only works for the org it was registered in.
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: orgPassword, AppId: appId})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: defaultPassword, AppId: orgAppID})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	respLogin, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: defaultPassword, AppId: appId})
+	require.NoError(t, err)
+	respValidate, err = st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, respValidate.GetOrgId())
+}
+
+func TestOrgs_OrgAdminScope(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	respOrg, err := st.AdminClient.CreateOrg(adminCtx, &ssoa.CreateOrgRequest{Name: "org-" + gofakeit.UUID()})
+	require.NoError(t, err)
+	orgID := respOrg.GetOrg().GetId()
+	respApp, err := st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{Name: "org-app", OrgId: orgID})
+	require.NoError(t, err)
+	orgAppID := respApp.GetApp().GetId()
+
+	email, password := gofakeit.Email(), randomFakePassword()
+	respRegister, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{Email: email, Password: password, AppId: orgAppID})
+	require.NoError(t, err)
+	_, err = st.AdminClient.SetAdmin(adminCtx, &ssoa.SetAdminRequest{UserId: respRegister.GetUserId(), IsAdmin: true})
+	require.NoError(t, err)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: orgAppID})
+	require.NoError(t, err)
+	orgAdminCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	defaultEmail, _ := registerUser(ctx, t, st)
+	respUsers, err := st.AdminClient.ListUsers(adminCtx, &ssoa.ListUsersRequest{EmailPrefix: defaultEmail})
+	require.NoError(t, err)
+	require.Len(t, respUsers.GetUsers(), 1)
+	defaultUserID := respUsers.GetUsers()[0].GetId()
+
+	respUsers, err = st.AdminClient.ListUsers(orgAdminCtx, &ssoa.ListUsersRequest{})
+	require.NoError(t, err)
+	require.Len(t, respUsers.GetUsers(), 1)
+	assert.Equal(t, respRegister.GetUserId(), respUsers.GetUsers()[0].GetId())
+	assert.Equal(t, orgID, respUsers.GetUsers()[0].GetOrgId())
+	_, err = st.AdminClient.ListUsers(orgAdminCtx, &ssoa.ListUsersRequest{OrgId: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = st.AdminClient.GetUser(orgAdminCtx, &ssoa.GetUserRequest{UserId: defaultUserID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.AdminClient.DisableUser(orgAdminCtx, &ssoa.DisableUserRequest{UserId: defaultUserID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	respApps, err := st.AdminClient.ListApps(orgAdminCtx, &ssoa.ListAppsRequest{})
+	require.NoError(t, err)
+	require.Len(t, respApps.GetApps(), 1)
+	assert.Equal(t, orgAppID, respApps.GetApps()[0].GetId())
+	_, err = st.AdminClient.GetApp(orgAdminCtx, &ssoa.GetAppRequest{AppId: appId})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.AdminClient.CreateApp(orgAdminCtx, &ssoa.CreateAppRequest{Name: "other-" + gofakeit.UUID(), OrgId: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	respCreated, err := st.AdminClient.CreateApp(orgAdminCtx, &ssoa.CreateAppRequest{Name: "own-" + gofakeit.UUID()})
+	require.NoError(t, err)
+	assert.Equal(t, orgID, respCreated.GetApp().GetOrgId())
+
+	_, err = st.AdminClient.CreateOrg(orgAdminCtx, &ssoa.CreateOrgRequest{Name: "org-" + gofakeit.UUID()})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	respOrgs, err := st.AdminClient.ListOrgs(orgAdminCtx, &ssoa.ListOrgsRequest{})
+	require.NoError(t, err)
+	require.Len(t, respOrgs.GetOrgs(), 1)
+	assert.Equal(t, orgID, respOrgs.GetOrgs()[0].GetId())
+}
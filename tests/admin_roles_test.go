@@ -0,0 +1,123 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestRoles_ClaimsAndCheckPermission(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	email, password := registerUser(ctx, t, st)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: appId})
+	require.NoError(t, err)
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	userID := respValidate.GetUserId()
+	assert.Empty(t, respValidate.GetRoles())
+
+	name := "editor-" + gofakeit.LetterN(8)
+	respRole, err := st.AdminClient.CreateRole(adminCtx, &ssoa.CreateRoleRequest{
+		AppId:       appId,
+		Name:        name,
+		Permissions: []string{"posts:write", "posts:read", "posts:read"},
+	})
+	require.NoError(t, err)
+	role := respRole.GetRole()
+	assert.Equal(t, []string{"posts:read", "posts:write"}, role.GetPermissions())
+
+	_, err = st.AdminClient.AssignRole(adminCtx, &ssoa.AssignRoleRequest{UserId: userID, RoleId: role.GetId()})
+	require.NoError(t, err)
+
+	respRefresh, err := st.AuthClient.Refresh(ctx, &ssoa.RefreshRequest{RefreshToken: respLogin.GetRefreshToken()})
+	require.NoError(t, err)
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(respRefresh.GetToken(), claims)
+	require.NoError(t, err)
+	assert.Equal(t, []any{name}, claims["roles"])
+	assert.Equal(t, []any{"posts:read", "posts:write"}, claims["permissions"])
+
+	respValidate, err = st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respRefresh.GetToken()})
+	require.NoError(t, err)
+	assert.Equal(t, []string{name}, respValidate.GetRoles())
+	assert.Equal(t, []string{"posts:read", "posts:write"}, respValidate.GetPermissions())
+
+	respCheck, err := st.AuthClient.CheckPermission(ctx, &ssoa.CheckPermissionRequest{UserId: userID, AppId: appId, Permission: "posts:write"})
+	require.NoError(t, err)
+	assert.True(t, respCheck.GetAllowed())
+	respCheck, err = st.AuthClient.CheckPermission(ctx, &ssoa.CheckPermissionRequest{UserId: userID, AppId: appId, Permission: "posts:delete"})
+	require.NoError(t, err)
+	assert.False(t, respCheck.GetAllowed())
+
+	_, err = st.AdminClient.UpdateRole(adminCtx, &ssoa.UpdateRoleRequest{RoleId: role.GetId(), Name: name, Permissions: []string{"posts:delete"}})
+	require.NoError(t, err)
+	respCheck, err = st.AuthClient.CheckPermission(ctx, &ssoa.CheckPermissionRequest{UserId: userID, AppId: appId, Permission: "posts:delete"})
+	require.NoError(t, err)
+	assert.True(t, respCheck.GetAllowed())
+
+	_, err = st.AdminClient.UnassignRole(adminCtx, &ssoa.UnassignRoleRequest{UserId: userID, RoleId: role.GetId()})
+	require.NoError(t, err)
+	respCheck, err = st.AuthClient.CheckPermission(ctx, &ssoa.CheckPermissionRequest{UserId: userID, AppId: appId, Permission: "posts:delete"})
+	require.NoError(t, err)
+	assert.False(t, respCheck.GetAllowed())
+
+	_, err = st.AdminClient.DeleteRole(adminCtx, &ssoa.DeleteRoleRequest{RoleId: role.GetId()})
+	require.NoError(t, err)
+	respList, err := st.AdminClient.ListRoles(adminCtx, &ssoa.ListRolesRequest{AppId: appId})
+	require.NoError(t, err)
+	for _, r := range respList.GetRoles() {
+		assert.NotEqual(t, role.GetId(), r.GetId())
+	}
+}
+
+func TestRoles_CheckAdminPermission(t *testing.T) {
+	ctx, st := suite.New(t)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: adminEmail, Password: adminPassword, AppId: appId})
+	require.NoError(t, err)
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	adminID := respValidate.GetUserId()
+	email, _ := registerUser(ctx, t, st)
+	respUsers, err := st.AdminClient.ListUsers(adminContext(ctx, t, st), &ssoa.ListUsersRequest{EmailPrefix: email})
+	require.NoError(t, err)
+	require.Len(t, respUsers.GetUsers(), 1)
+	userID := respUsers.GetUsers()[0].GetId()
+
+	for _, tt := range []struct {
+		userID int64
+		want   bool
+	}{{userID: adminID, want: true}, {userID: userID, want: false}} {
+		respIsAdmin, err := st.AuthClient.IsAdmin(ctx, &ssoa.IsAdminRequest{UserId: tt.userID})
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, respIsAdmin.GetIsAdmin())
+		respCheck, err := st.AuthClient.CheckPermission(ctx, &ssoa.CheckPermissionRequest{UserId: tt.userID, Permission: "admin"})
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, respCheck.GetAllowed())
+	}
+}
+
+func TestRoles_Fails(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	name := "viewer-" + gofakeit.LetterN(8)
+	_, err := st.AdminClient.CreateRole(adminCtx, &ssoa.CreateRoleRequest{AppId: appId, Name: name})
+	require.NoError(t, err)
+
+	_, err = st.AdminClient.CreateRole(adminCtx, &ssoa.CreateRoleRequest{AppId: appId, Name: name})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = st.AdminClient.CreateRole(adminCtx, &ssoa.CreateRoleRequest{AppId: appId, Name: "has space"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = st.AdminClient.CreateRole(adminCtx, &ssoa.CreateRoleRequest{AppId: 99999, Name: name})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.AdminClient.AssignRole(adminCtx, &ssoa.AssignRoleRequest{UserId: 1, RoleId: 99999})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.AuthClient.CheckPermission(ctx, &ssoa.CheckPermissionRequest{UserId: 99999999, AppId: appId, Permission: "x"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestAdminUsers_ListPaginated(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	prefix := "list_" + gofakeit.LetterN(12)
+	var userIDs []int64
+	for i := 0; i < 3; i++ {
+		resp, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+			Email:    prefix + gofakeit.LetterN(4) + "@example.com",
+			Password: randomFakePassword(),
+		})
+		require.NoError(t, err)
+		userIDs = append(userIDs, resp.GetUserId())
+	}
+
+	var listed []int64
+	pageToken := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		resp, err := st.AdminClient.ListUsers(adminCtx, &ssoa.ListUsersRequest{
+			PageSize:    2,
+			PageToken:   pageToken,
+			EmailPrefix: prefix,
+		})
+		require.NoError(t, err)
+		for _, user := range resp.GetUsers() {
+			listed = append(listed, user.GetId())
+			assert.NotZero(t, user.GetCreatedAt())
+			assert.False(t, user.GetIsAdmin())
+		}
+		pageToken = resp.GetNextPageToken()
+		if pageToken == "" {
+			break
+		}
+	}
+	assert.Equal(t, userIDs, listed)
+
+	isAdmin := true
+	resp, err := st.AdminClient.ListUsers(adminCtx, &ssoa.ListUsersRequest{EmailPrefix: prefix, IsAdmin: &isAdmin})
+	require.NoError(t, err)
+	assert.Empty(t, resp.GetUsers())
+
+	resp, err = st.AdminClient.ListUsers(adminCtx, &ssoa.ListUsersRequest{
+		EmailPrefix:   prefix,
+		CreatedBefore: time.Now().Add(-time.Hour).Unix(),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.GetUsers())
+
+	_, err = st.AdminClient.ListUsers(adminCtx, &ssoa.ListUsersRequest{PageToken: "not-a-token"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAdminUsers_DisableAndEnable(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	email, password := registerUser(ctx, t, st)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: appId})
+	require.NoError(t, err)
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	userID := respValidate.GetUserId()
+
+	_, err = st.AdminClient.DisableUser(adminCtx, &ssoa.DisableUserRequest{UserId: userID})
+	require.NoError(t, err)
+	respGet, err := st.AdminClient.GetUser(adminCtx, &ssoa.GetUserRequest{UserId: userID})
+	require.NoError(t, err)
+	assert.True(t, respGet.GetUser().GetDisabled())
+
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: appId})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	respValidate, err = st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	assert.False(t, respValidate.GetValid())
+	_, err = st.AuthClient.Refresh(ctx, &ssoa.RefreshRequest{RefreshToken: respLogin.GetRefreshToken()})
+	assert.Error(t, err)
+
+	_, err = st.AdminClient.EnableUser(adminCtx, &ssoa.EnableUserRequest{UserId: userID})
+	require.NoError(t, err)
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: appId})
+	require.NoError(t, err)
+	respValidate, err = st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	assert.False(t, respValidate.GetValid(), "enabling the user doesn't bring old tokens back")
+}
+
+func TestAdminUsers_SetAdminAndDelete(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	email, password := registerUser(ctx, t, st)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: adminAppId})
+	require.NoError(t, err)
+	userCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	userID := respValidate.GetUserId()
+
+	_, err = st.AdminClient.ListUsers(userCtx, &ssoa.ListUsersRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AdminClient.SetAdmin(adminCtx, &ssoa.SetAdminRequest{UserId: userID, IsAdmin: true})
+	require.NoError(t, err)
+	_, err = st.AdminClient.ListUsers(userCtx, &ssoa.ListUsersRequest{PageSize: 1})
+	require.NoError(t, err)
+	_, err = st.AdminClient.SetAdmin(userCtx, &ssoa.SetAdminRequest{UserId: userID, IsAdmin: false})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err), "admins can't demote themselves")
+
+	_, err = st.AdminClient.SetAdmin(adminCtx, &ssoa.SetAdminRequest{UserId: userID, IsAdmin: false})
+	require.NoError(t, err)
+	_, err = st.AdminClient.ListUsers(userCtx, &ssoa.ListUsersRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AdminClient.DeleteUser(adminCtx, &ssoa.DeleteUserRequest{UserId: userID})
+	require.NoError(t, err)
+	_, err = st.AdminClient.GetUser(adminCtx, &ssoa.GetUserRequest{UserId: userID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = st.AdminClient.DeleteUser(adminCtx, &ssoa.DeleteUserRequest{UserId: userID})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	respValidate, err = st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	assert.False(t, respValidate.GetValid())
+
+	respRegister, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{Email: gofakeit.Email(), Password: randomFakePassword(), AppId: appId})
+	require.NoError(t, err)
+	assert.Greater(t, respRegister.GetUserId(), userID, "ids of deleted users aren't reused")
+	respValidate, err = st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	assert.False(t, respValidate.GetValid())
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestChangePassword_HappyPath(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	newPassword := randomFakePassword()
+	respChange, err := st.AuthClient.ChangePassword(authCtx, &ssoa.ChangePasswordRequest{
+		CurrentPassword: password,
+		NewPassword:     newPassword,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, respChange.GetToken())
+
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	assert.True(t, respValidate.GetValid(), "other sessions are kept unless asked otherwise")
+
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.Error(t, err)
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: newPassword,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+}
+
+func TestChangePassword_RevokeOtherSessions(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	respChange, err := st.AuthClient.ChangePassword(authCtx, &ssoa.ChangePasswordRequest{
+		CurrentPassword:     password,
+		NewPassword:         randomFakePassword(),
+		RevokeOtherSessions: true,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, respChange.GetToken())
+	require.NotEmpty(t, respChange.GetRefreshToken())
+
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	assert.False(t, respValidate.GetValid())
+	_, err = st.AuthClient.Refresh(ctx, &ssoa.RefreshRequest{RefreshToken: respLogin.GetRefreshToken()})
+	require.Error(t, err)
+
+	respValidate, err = st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respChange.GetToken()})
+	require.NoError(t, err)
+	assert.True(t, respValidate.GetValid())
+}
+
+func TestChangePassword_FailCases(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+
+	tests := []struct {
+		name            string
+		withToken       bool
+		currentPassword string
+		newPassword     string
+		expectedCode    codes.Code
+	}{
+		{
+			name:            "Missing token",
+			currentPassword: password,
+			newPassword:     randomFakePassword(),
+			expectedCode:    codes.Unauthenticated,
+		},
+		{
+			name:            "Wrong current password",
+			withToken:       true,
+			currentPassword: randomFakePassword(),
+			newPassword:     randomFakePassword(),
+			expectedCode:    codes.InvalidArgument,
+		},
+		{
+			name:            "Too short",
+			withToken:       true,
+			currentPassword: password,
+			newPassword:     "short",
+			expectedCode:    codes.InvalidArgument,
+		},
+		{
+			name:            "Unchanged",
+			withToken:       true,
+			currentPassword: password,
+			newPassword:     password,
+			expectedCode:    codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCtx := ctx
+			if tt.withToken {
+				callCtx = authCtx
+			}
+			_, err := st.AuthClient.ChangePassword(callCtx, &ssoa.ChangePasswordRequest{
+				CurrentPassword: tt.currentPassword,
+				NewPassword:     tt.newPassword,
+			})
+			require.Error(t, err)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestClientCredentials_HappyPath(t *testing.T) {
+	ctx, st := suite.New(t)
+	resp, err := st.AuthClient.ClientCredentials(ctx, &ssoa.ClientCredentialsRequest{
+		AppId:        appId,
+		ClientSecret: appSecret,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders:read", "orders:write"}, resp.GetScopes())
+	assert.EqualValues(t, st.Cfg.TokenTTl.Seconds(), resp.GetExpiresIn())
+
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: resp.GetToken(), AppId: appId})
+	require.NoError(t, err)
+	require.True(t, respValidate.GetValid())
+	assert.True(t, respValidate.GetClient())
+	assert.Zero(t, respValidate.GetUserId())
+	assert.Empty(t, respValidate.GetEmail())
+	assert.Equal(t, []string{"orders:read", "orders:write"}, respValidate.GetScopes())
+
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(resp.GetToken(), claims)
+	require.NoError(t, err)
+	assert.Equal(t, "app:"+strconv.Itoa(appId), claims["sub"])
+	assert.Equal(t, "client", claims["token_use"])
+	assert.NotContains(t, claims, "uid")
+}
+
+func TestClientCredentials_Fails(t *testing.T) {
+	ctx, st := suite.New(t)
+	tests := []struct {
+		name   string
+		req    *ssoa.ClientCredentialsRequest
+		code   codes.Code
+		errMsg string
+	}{
+		{
+			name:   "wrong secret",
+			req:    &ssoa.ClientCredentialsRequest{AppId: appId, ClientSecret: "wrong-secret"},
+			code:   codes.Unauthenticated,
+			errMsg: "invalid client credentials",
+		},
+		{
+			name:   "unknown app",
+			req:    &ssoa.ClientCredentialsRequest{AppId: 9999, ClientSecret: appSecret},
+			code:   codes.Unauthenticated,
+			errMsg: "invalid client credentials",
+		},
+		{
+			name:   "scope not allowed",
+			req:    &ssoa.ClientCredentialsRequest{AppId: appId, ClientSecret: appSecret, Scopes: []string{"orders:read", "admin"}},
+			code:   codes.PermissionDenied,
+			errMsg: "scope is not allowed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := st.AuthClient.ClientCredentials(ctx, tt.req)
+			require.Error(t, err)
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestClientCredentials_TokenIsNotAUserToken(t *testing.T) {
+	ctx, st := suite.New(t)
+	resp, err := st.AuthClient.ClientCredentials(ctx, &ssoa.ClientCredentialsRequest{
+		AppId:        appId,
+		ClientSecret: appSecret,
+		Scopes:       []string{"orders:read"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders:read"}, resp.GetScopes())
+
+	clientCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+resp.GetToken())
+	_, err = st.AuthClient.EnrollMFA(clientCtx, &ssoa.EnrollMFARequest{})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestClientCredentials_TokenEndpoint(t *testing.T) {
+	_, st := suite.New(t)
+	req, err := http.NewRequest(http.MethodPost, oauthURL(st, "/token"), strings.NewReader(url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {"orders:write"},
+	}.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(strconv.Itoa(appId), appSecret)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "orders:write", body["scope"])
+	assert.NotEmpty(t, body["access_token"])
+	assert.NotContains(t, body, "refresh_token")
+
+	req, err = http.NewRequest(http.MethodPost, oauthURL(st, "/token"), strings.NewReader(url.Values{
+		"grant_type": {"client_credentials"},
+	}.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(strconv.Itoa(appId), "wrong-secret")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"testing"
+)
+
+func TestJWKS_AsymmetricApps(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		appID int32
+		alg   string
+	}{
+		{name: "RS256", appID: 2, alg: "RS256"},
+		{name: "ES256", appID: 3, alg: "ES256"},
+		{name: "EdDSA", appID: 4, alg: "EdDSA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+				Email:    email,
+				Password: password,
+				AppId:    tt.appID,
+			})
+			require.NoError(t, err)
+
+			respJWKS, err := st.AuthClient.JWKS(ctx, &ssoa.JWKSRequest{AppId: tt.appID})
+			require.NoError(t, err)
+			require.NotEmpty(t, respJWKS.GetKeys())
+
+			tokenParsed, err := jwt.Parse(respLogin.GetToken(), func(token *jwt.Token) (interface{}, error) {
+				for _, key := range respJWKS.GetKeys() {
+					if key.GetKid() == token.Header["kid"] {
+						return publicKey(t, key), nil
+					}
+				}
+				return nil, fmt.Errorf("unknown kid %v", token.Header["kid"])
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, tokenParsed.Method.Alg())
+			claims := tokenParsed.Claims.(jwt.MapClaims)
+			assert.Equal(t, email, claims["email"].(string))
+
+			resp, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+			require.NoError(t, err)
+			assert.True(t, resp.GetValid())
+		})
+	}
+}
+
+func TestJWKS_HTTP(t *testing.T) {
+	_, st := suite.New(t)
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/.well-known/jwks.json?app_id=1", st.Cfg.HTTP.Port))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Empty(t, body.Keys, "HS256 apps must not publish their secret")
+}
+
+func publicKey(t *testing.T, key *ssoa.JWK) interface{} {
+	t.Helper()
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+	switch key.GetKty() {
+	case "RSA":
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(decode(key.GetN())),
+			E: int(new(big.Int).SetBytes(decode(key.GetE())).Int64()),
+		}
+	case "EC":
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(decode(key.GetX())),
+			Y:     new(big.Int).SetBytes(decode(key.GetY())),
+		}
+	case "OKP":
+		return ed25519.PublicKey(decode(key.GetX()))
+	}
+	t.Fatalf("unexpected key type %s", key.GetKty())
+	return nil
+}
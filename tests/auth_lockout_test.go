@@ -0,0 +1,104 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"context"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+const (
+	adminEmail    = "admin@sso.test"
+	adminPassword = "admin-password"
+	// adminAppId signs with a managed key; admin calls refuse tokens signed
+	// with an app secret, like those of the test app.
+	adminAppId = 2
+)
+
+func TestLogin_LockoutAndUnlock(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < st.Cfg.Lockout.MaxAttempts; i++ {
+		_, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+			Email:    email,
+			Password: randomFakePassword(),
+			AppId:    appId,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid credentials")
+	}
+
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.Error(t, err)
+	grpcStatus, _ := status.FromError(err)
+	require.Equal(t, codes.ResourceExhausted, grpcStatus.Code())
+	require.Len(t, grpcStatus.Details(), 1)
+	retryInfo, ok := grpcStatus.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Positive(t, retryInfo.GetRetryDelay().AsDuration())
+
+	_, err = st.AdminClient.UnlockAccount(adminContext(ctx, t, st), &ssoa.UnlockAccountRequest{Email: email})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+}
+
+func TestUnlockAccount_RequiresAdmin(t *testing.T) {
+	ctx, st := suite.New(t)
+	_, err := st.AdminClient.UnlockAccount(ctx, &ssoa.UnlockAccountRequest{Email: gofakeit.Email()})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err = st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+	userCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+	_, err = st.AdminClient.UnlockAccount(userCtx, &ssoa.UnlockAccountRequest{Email: gofakeit.Email()})
+	require.Error(t, err)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+// adminContext logs in as the seeded admin and attaches the token to ctx.
+func adminContext(ctx context.Context, t *testing.T, st *suite.Suite) context.Context {
+	t.Helper()
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    adminEmail,
+		Password: adminPassword,
+		AppId:    adminAppId,
+	})
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+}
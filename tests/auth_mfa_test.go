@@ -0,0 +1,237 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/internal/lib/totp"
+	"AuthGRPC/tests/suite"
+	"context"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMFA_EnrollLoginDisable(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	authCtx := registerAndLogin(ctx, t, st, email, password)
+
+	respEnroll, err := st.AuthClient.EnrollMFA(authCtx, &ssoa.EnrollMFARequest{})
+	require.NoError(t, err)
+	secret := respEnroll.GetSecret()
+	require.NotEmpty(t, secret)
+	assert.True(t, strings.HasPrefix(respEnroll.GetOtpauthUri(), "otpauth://totp/"))
+	assert.Contains(t, respEnroll.GetOtpauthUri(), "secret="+secret)
+
+	// Every accepted code burns its time step, so each call uses a later one.
+	_, err = st.AuthClient.ConfirmMFA(authCtx, &ssoa.ConfirmMFARequest{Code: totpCode(t, secret, -1)})
+	require.NoError(t, err)
+
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+	require.True(t, respLogin.GetMfaRequired())
+	assert.Empty(t, respLogin.GetToken())
+	assert.Empty(t, respLogin.GetRefreshToken())
+	require.NotEmpty(t, respLogin.GetMfaToken())
+
+	_, err = st.AuthClient.VerifyMFA(ctx, &ssoa.VerifyMFARequest{MfaToken: respLogin.GetMfaToken(), Code: "000000"})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	respVerify, err := st.AuthClient.VerifyMFA(ctx, &ssoa.VerifyMFARequest{
+		MfaToken: respLogin.GetMfaToken(),
+		Code:     totpCode(t, secret, 0),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, respVerify.GetToken())
+	require.NotEmpty(t, respVerify.GetRefreshToken())
+
+	_, err = st.AuthClient.VerifyMFA(ctx, &ssoa.VerifyMFARequest{
+		MfaToken: respLogin.GetMfaToken(),
+		Code:     totpCode(t, secret, 1),
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "challenges are single-use")
+
+	_, err = st.AuthClient.DisableMFA(authCtx, &ssoa.DisableMFARequest{Code: totpCode(t, secret, 1)})
+	require.NoError(t, err)
+
+	respLogin, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+	assert.False(t, respLogin.GetMfaRequired())
+	assert.NotEmpty(t, respLogin.GetToken())
+}
+
+func TestMFA_VerifyLocksAcrossChallenges(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	authCtx := registerAndLogin(ctx, t, st, email, password)
+	respEnroll, err := st.AuthClient.EnrollMFA(authCtx, &ssoa.EnrollMFARequest{})
+	require.NoError(t, err)
+	secret := respEnroll.GetSecret()
+	_, err = st.AuthClient.ConfirmMFA(authCtx, &ssoa.ConfirmMFARequest{Code: totpCode(t, secret, -1)})
+	require.NoError(t, err)
+	login := func() string {
+		t.Helper()
+		respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: appId})
+		require.NoError(t, err)
+		require.True(t, respLogin.GetMfaRequired())
+		return respLogin.GetMfaToken()
+	}
+
+	// A fresh challenge per guess stays below the per-challenge limit.
+	wrong := totpCode(t, secret, 5)
+	for i := 0; i < st.Cfg.MFA.MaxAttempts; i++ {
+		_, err = st.AuthClient.VerifyMFA(ctx, &ssoa.VerifyMFARequest{MfaToken: login(), Code: wrong})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+	_, err = st.AuthClient.VerifyMFA(ctx, &ssoa.VerifyMFARequest{MfaToken: login(), Code: totpCode(t, secret, 0)})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "locked even for the right code")
+}
+
+func TestMFA_DisableLocksAfterWrongCodes(t *testing.T) {
+	ctx, st := suite.New(t)
+	authCtx := registerAndLogin(ctx, t, st, gofakeit.Email(), randomFakePassword())
+	respEnroll, err := st.AuthClient.EnrollMFA(authCtx, &ssoa.EnrollMFARequest{})
+	require.NoError(t, err)
+	secret := respEnroll.GetSecret()
+	_, err = st.AuthClient.ConfirmMFA(authCtx, &ssoa.ConfirmMFARequest{Code: totpCode(t, secret, -1)})
+	require.NoError(t, err)
+
+	for i := 0; i < st.Cfg.MFA.MaxAttempts; i++ {
+		_, err = st.AuthClient.DisableMFA(authCtx, &ssoa.DisableMFARequest{Code: "aaaaa-aaaaa"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+	_, err = st.AuthClient.DisableMFA(authCtx, &ssoa.DisableMFARequest{Code: totpCode(t, secret, 0)})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "locked even for the right code")
+	assertRemaining(t, st, authCtx, st.Cfg.MFA.RecoveryCodes)
+}
+
+func TestMFA_RegenerateLocksAfterWrongCodes(t *testing.T) {
+	ctx, st := suite.New(t)
+	authCtx := registerAndLogin(ctx, t, st, gofakeit.Email(), randomFakePassword())
+	respEnroll, err := st.AuthClient.EnrollMFA(authCtx, &ssoa.EnrollMFARequest{})
+	require.NoError(t, err)
+	secret := respEnroll.GetSecret()
+	_, err = st.AuthClient.ConfirmMFA(authCtx, &ssoa.ConfirmMFARequest{Code: totpCode(t, secret, -1)})
+	require.NoError(t, err)
+
+	wrong := totpCode(t, secret, 5)
+	for i := 0; i < st.Cfg.MFA.MaxAttempts; i++ {
+		_, err = st.AuthClient.RegenerateRecoveryCodes(authCtx, &ssoa.RegenerateRecoveryCodesRequest{Code: wrong})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+	_, err = st.AuthClient.RegenerateRecoveryCodes(authCtx, &ssoa.RegenerateRecoveryCodesRequest{Code: totpCode(t, secret, 0)})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "locked even for the right code")
+}
+
+func TestMFA_ConfirmWithWrongCode(t *testing.T) {
+	ctx, st := suite.New(t)
+	authCtx := registerAndLogin(ctx, t, st, gofakeit.Email(), randomFakePassword())
+
+	_, err := st.AuthClient.ConfirmMFA(authCtx, &ssoa.ConfirmMFARequest{Code: "123456"})
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err), "not enrolled")
+
+	_, err = st.AuthClient.EnrollMFA(authCtx, &ssoa.EnrollMFARequest{})
+	require.NoError(t, err)
+	_, err = st.AuthClient.ConfirmMFA(authCtx, &ssoa.ConfirmMFARequest{Code: "12345"})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestMFA_RecoveryCodes(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	authCtx := registerAndLogin(ctx, t, st, email, password)
+
+	respEnroll, err := st.AuthClient.EnrollMFA(authCtx, &ssoa.EnrollMFARequest{})
+	require.NoError(t, err)
+	secret := respEnroll.GetSecret()
+	respConfirm, err := st.AuthClient.ConfirmMFA(authCtx, &ssoa.ConfirmMFARequest{Code: totpCode(t, secret, -1)})
+	require.NoError(t, err)
+	recoveryCodes := respConfirm.GetRecoveryCodes()
+	require.Len(t, recoveryCodes, st.Cfg.MFA.RecoveryCodes)
+	assertRemaining(t, st, authCtx, len(recoveryCodes))
+
+	loginWithCode := func(code string) error {
+		respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+			Email:    email,
+			Password: password,
+			AppId:    appId,
+		})
+		require.NoError(t, err)
+		require.True(t, respLogin.GetMfaRequired())
+		_, err = st.AuthClient.VerifyMFA(ctx, &ssoa.VerifyMFARequest{MfaToken: respLogin.GetMfaToken(), Code: code})
+		return err
+	}
+
+	require.NoError(t, loginWithCode(recoveryCodes[0]))
+	assertRemaining(t, st, authCtx, len(recoveryCodes)-1)
+
+	err = loginWithCode(recoveryCodes[0])
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "recovery codes are single-use")
+
+	require.NoError(t, loginWithCode(strings.ToUpper(strings.ReplaceAll(recoveryCodes[1], "-", ""))))
+	assertRemaining(t, st, authCtx, len(recoveryCodes)-2)
+
+	respRegenerate, err := st.AuthClient.RegenerateRecoveryCodes(authCtx, &ssoa.RegenerateRecoveryCodesRequest{
+		Code: totpCode(t, secret, 0),
+	})
+	require.NoError(t, err)
+	require.Len(t, respRegenerate.GetRecoveryCodes(), st.Cfg.MFA.RecoveryCodes)
+	assertRemaining(t, st, authCtx, st.Cfg.MFA.RecoveryCodes)
+
+	err = loginWithCode(recoveryCodes[2])
+	require.Error(t, err, "regenerating drops the old codes")
+	require.NoError(t, loginWithCode(respRegenerate.GetRecoveryCodes()[0]))
+}
+
+func assertRemaining(t *testing.T, st *suite.Suite, authCtx context.Context, expected int) {
+	t.Helper()
+	resp, err := st.AuthClient.RecoveryCodesRemaining(authCtx, &ssoa.RecoveryCodesRemainingRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(expected), resp.GetRemaining())
+}
+
+// registerAndLogin returns ctx carrying the new user's access token.
+func registerAndLogin(ctx context.Context, t *testing.T, st *suite.Suite, email string, password string) context.Context {
+	t.Helper()
+	_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+respLogin.GetToken())
+}
+
+// totpCode returns the code offset time steps away from now.
+func totpCode(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+	require.NoError(t, err)
+	return code
+}
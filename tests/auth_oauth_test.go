@@ -0,0 +1,272 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const redirectURI = "http://localhost:9999/callback"
+
+// noRedirectClient returns redirects as responses, so the tests can read the code.
+var noRedirectClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+func TestOAuth_AuthorizationCodeFlow(t *testing.T) {
+	ctx, st := suite.New(t)
+	email, password := registerUser(ctx, t, st)
+	verifier := gofakeit.LetterN(64)
+	params := authorizeParams(verifier)
+
+	resp, err := noRedirectClient.Get(oauthURL(st, "/authorize") + "?" + params.Encode())
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+
+	code := authorize(t, st, params, email, password)
+
+	token := exchangeCode(t, st, code, verifier)
+	require.Equal(t, http.StatusOK, token.status)
+	assert.Equal(t, "Bearer", token.body["token_type"])
+	assert.Equal(t, "openid", token.body["scope"])
+	assert.EqualValues(t, st.Cfg.TokenTTl.Seconds(), token.body["expires_in"])
+	assert.NotEmpty(t, token.body["refresh_token"])
+
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{
+		Token: token.body["access_token"].(string),
+		AppId: appId,
+	})
+	require.NoError(t, err)
+	require.True(t, respValidate.GetValid())
+	assert.Equal(t, email, respValidate.GetEmail())
+
+	reused := exchangeCode(t, st, code, verifier)
+	assert.Equal(t, http.StatusBadRequest, reused.status)
+	assert.Equal(t, "invalid_grant", reused.body["error"])
+}
+
+func TestOAuth_WrongCodeVerifier(t *testing.T) {
+	ctx, st := suite.New(t)
+	email, password := registerUser(ctx, t, st)
+	params := authorizeParams(gofakeit.LetterN(64))
+	code := authorize(t, st, params, email, password)
+
+	token := exchangeCode(t, st, code, gofakeit.LetterN(64))
+	assert.Equal(t, http.StatusBadRequest, token.status)
+	assert.Equal(t, "invalid_grant", token.body["error"])
+}
+
+func TestOAuth_ExchangeForDisabledUser(t *testing.T) {
+	ctx, st := suite.New(t)
+	email, password := registerUser(ctx, t, st)
+	verifier := gofakeit.LetterN(64)
+	code := authorize(t, st, authorizeParams(verifier), email, password)
+
+	adminCtx := adminContext(ctx, t, st)
+	respUsers, err := st.AdminClient.ListUsers(adminCtx, &ssoa.ListUsersRequest{EmailPrefix: email})
+	require.NoError(t, err)
+	require.Len(t, respUsers.GetUsers(), 1)
+	_, err = st.AdminClient.DisableUser(adminCtx, &ssoa.DisableUserRequest{UserId: respUsers.GetUsers()[0].GetId()})
+	require.NoError(t, err)
+
+	token := exchangeCode(t, st, code, verifier)
+	assert.Equal(t, http.StatusBadRequest, token.status)
+	assert.Equal(t, "invalid_grant", token.body["error"])
+}
+
+func TestOAuth_AuthorizeFails(t *testing.T) {
+	ctx, st := suite.New(t)
+	email, password := registerUser(ctx, t, st)
+
+	t.Run("unregistered redirect uri is not redirected to", func(t *testing.T) {
+		params := authorizeParams(gofakeit.LetterN(64))
+		params.Set("redirect_uri", "http://evil.test/callback")
+		resp, err := noRedirectClient.Get(oauthURL(st, "/authorize") + "?" + params.Encode())
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Location"))
+	})
+	t.Run("missing pkce is redirected with an error", func(t *testing.T) {
+		params := authorizeParams(gofakeit.LetterN(64))
+		params.Del("code_challenge")
+		resp, err := noRedirectClient.Get(oauthURL(st, "/authorize") + "?" + params.Encode())
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		location, err := url.Parse(resp.Header.Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, "invalid_request", location.Query().Get("error"))
+		assert.Equal(t, params.Get("state"), location.Query().Get("state"))
+	})
+	t.Run("wrong password shows the page again", func(t *testing.T) {
+		form := authorizeParams(gofakeit.LetterN(64))
+		form.Set("email", email)
+		form.Set("password", password+"x")
+		resp := postAuthorize(t, st, form)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Regexp(t, csrfTokenRe, string(body), "the page keeps the csrf token")
+	})
+	t.Run("post without csrf token is rejected", func(t *testing.T) {
+		form := authorizeParams(gofakeit.LetterN(64))
+		form.Set("email", email)
+		form.Set("password", password)
+		resp, err := noRedirectClient.PostForm(oauthURL(st, "/authorize"), form)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Location"))
+	})
+	t.Run("csrf token of another browser is rejected", func(t *testing.T) {
+		params := authorizeParams(gofakeit.LetterN(64))
+		form := url.Values{}
+		for key, values := range params {
+			form[key] = values
+		}
+		form.Set("email", email)
+		form.Set("password", password)
+		form.Set("csrf_token", csrfToken(t, st, newBrowser(t), params))
+		resp, err := newBrowser(t).PostForm(oauthURL(st, "/authorize"), form)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
+
+type tokenResult struct {
+	status int
+	body   map[string]any
+}
+
+func registerUser(ctx context.Context, t *testing.T, st *suite.Suite) (email string, password string) {
+	t.Helper()
+	email = gofakeit.Email()
+	password = randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{Email: email, Password: password})
+	require.NoError(t, err)
+	return email, password
+}
+
+func authorizeParams(verifier string) url.Values {
+	sum := sha256.Sum256([]byte(verifier))
+	return url.Values{
+		"response_type":         {"code"},
+		"client_id":             {strconv.Itoa(appId)},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {"openid"},
+		"state":                 {gofakeit.LetterN(16)},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+}
+
+// authorize submits the sign-in page and returns the code from the redirect.
+func authorize(t *testing.T, st *suite.Suite, params url.Values, email string, password string) string {
+	t.Helper()
+	form := url.Values{}
+	for key, values := range params {
+		form[key] = values
+	}
+	form.Set("email", email)
+	form.Set("password", password)
+	resp := postAuthorize(t, st, form)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(location.String(), redirectURI))
+	assert.Equal(t, params.Get("state"), location.Query().Get("state"))
+	code := location.Query().Get("code")
+	require.NotEmpty(t, code)
+	return code
+}
+
+var csrfTokenRe = regexp.MustCompile(`name="csrf_token" value="([^"]+)"`)
+
+// postAuthorize opens the sign-in page like a browser would and posts form with
+// the page's CSRF token.
+func postAuthorize(t *testing.T, st *suite.Suite, form url.Values) *http.Response {
+	t.Helper()
+	browser := newBrowser(t)
+	form.Set("csrf_token", csrfToken(t, st, browser, form))
+	resp, err := browser.PostForm(oauthURL(st, "/authorize"), form)
+	require.NoError(t, err)
+	return resp
+}
+
+// newBrowser returns a client that keeps cookies and, like noRedirectClient,
+// returns redirects as responses.
+func newBrowser(t *testing.T) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	return &http.Client{Jar: jar, CheckRedirect: noRedirectClient.CheckRedirect}
+}
+
+// csrfToken opens the sign-in page for params and returns its CSRF token.
+func csrfToken(t *testing.T, st *suite.Suite, browser *http.Client, params url.Values) string {
+	t.Helper()
+	query := url.Values{}
+	for _, key := range []string{"response_type", "client_id", "redirect_uri", "scope", "state", "code_challenge", "code_challenge_method", "nonce"} {
+		if params.Has(key) {
+			query.Set(key, params.Get(key))
+		}
+	}
+	resp, err := browser.Get(oauthURL(st, "/authorize") + "?" + query.Encode())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	match := csrfTokenRe.FindStringSubmatch(string(body))
+	require.Len(t, match, 2)
+	return match[1]
+}
+
+func exchangeCode(t *testing.T, st *suite.Suite, code string, verifier string) tokenResult {
+	t.Helper()
+	return exchangeCodeFor(t, st, appId, code, verifier)
+}
+
+func exchangeCodeFor(t *testing.T, st *suite.Suite, appID int, code string, verifier string) tokenResult {
+	t.Helper()
+	resp, err := http.PostForm(oauthURL(st, "/token"), url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"client_id":     {strconv.Itoa(appID)},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return tokenResult{status: resp.StatusCode, body: body}
+}
+
+func oauthURL(st *suite.Suite, path string) string {
+	return fmt.Sprintf("http://localhost:%d%s", st.Cfg.HTTP.Port, path)
+}
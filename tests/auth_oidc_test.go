@@ -0,0 +1,120 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"encoding/json"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestOIDC_Discovery(t *testing.T) {
+	_, st := suite.New(t)
+	resp, err := http.Get(oauthURL(st, "/.well-known/openid-configuration"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var metadata map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&metadata))
+	issuer := st.Cfg.OAuth.Issuer
+	assert.Equal(t, issuer, metadata["issuer"])
+	assert.Equal(t, issuer+"/authorize", metadata["authorization_endpoint"])
+	assert.Equal(t, issuer+"/token", metadata["token_endpoint"])
+	assert.Equal(t, issuer+"/userinfo", metadata["userinfo_endpoint"])
+	assert.Equal(t, issuer+"/.well-known/jwks.json", metadata["jwks_uri"])
+	assert.Contains(t, metadata["code_challenge_methods_supported"], "S256")
+}
+
+func TestOIDC_IDTokenAndUserInfo(t *testing.T) {
+	ctx, st := suite.New(t)
+	email, password := registerUser(ctx, t, st)
+	verifier := gofakeit.LetterN(64)
+	nonce := gofakeit.LetterN(16)
+	params := authorizeParams(verifier)
+	params.Set("nonce", nonce)
+	code := authorize(t, st, params, email, password)
+
+	token := exchangeCode(t, st, code, verifier)
+	require.Equal(t, http.StatusOK, token.status)
+	idToken, ok := token.body["id_token"].(string)
+	require.True(t, ok)
+
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(idToken, claims)
+	require.NoError(t, err)
+	assert.Equal(t, st.Cfg.OAuth.Issuer, claims["iss"])
+	assert.Equal(t, strconv.Itoa(appId), claims["aud"])
+	assert.Equal(t, nonce, claims["nonce"])
+	assert.Equal(t, email, claims["email"])
+	assert.NotEmpty(t, claims["sub"])
+	assert.NotEmpty(t, claims["auth_time"])
+
+	accessClaims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(token.body["access_token"].(string), accessClaims)
+	require.NoError(t, err)
+	assert.Equal(t, claims["sub"], accessClaims["sub"])
+	assert.Equal(t, st.Cfg.OAuth.Issuer, accessClaims["iss"])
+
+	t.Run("id token is not an access token", func(t *testing.T) {
+		respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: idToken})
+		require.NoError(t, err)
+		assert.False(t, respValidate.GetValid())
+	})
+	t.Run("userinfo", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, oauthURL(st, "/userinfo"), nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token.body["access_token"].(string))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var info map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+		assert.Equal(t, claims["sub"], info["sub"])
+		assert.Equal(t, email, info["email"])
+		assert.Equal(t, false, info["email_verified"])
+	})
+	t.Run("userinfo rejects invalid tokens", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, oauthURL(st, "/userinfo"), nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+idToken)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "invalid_token")
+	})
+}
+
+func TestOIDC_HS256IDTokenSignedWithClientSecret(t *testing.T) {
+	ctx, st := suite.New(t)
+	respCreate, err := st.AdminClient.CreateApp(adminContext(ctx, t, st), &ssoa.CreateAppRequest{
+		Name:         "app-" + gofakeit.UUID(),
+		SigningAlg:   "HS256",
+		RedirectUris: []string{redirectURI},
+	})
+	require.NoError(t, err)
+	appID := int(respCreate.GetApp().GetId())
+	email, password := registerUser(ctx, t, st)
+	verifier := gofakeit.LetterN(64)
+	params := authorizeParams(verifier)
+	params.Set("client_id", strconv.Itoa(appID))
+	code := authorize(t, st, params, email, password)
+
+	token := exchangeCodeFor(t, st, appID, code, verifier)
+	require.Equal(t, http.StatusOK, token.status)
+	idToken, ok := token.body["id_token"].(string)
+	require.True(t, ok)
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(respCreate.GetSecret()), nil
+	}, jwt.WithValidMethods([]string{"HS256"}))
+	require.NoError(t, err, "relying parties verify HS256 ID tokens with their client secret")
+	assert.Equal(t, strconv.Itoa(appID), claims["aud"])
+}
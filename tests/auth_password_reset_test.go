@@ -0,0 +1,82 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"regexp"
+	"testing"
+)
+
+var resetCode = regexp.MustCompile(`reset your password: (\S+)`)
+
+func TestPasswordReset_HappyPath(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.RequestPasswordReset(ctx, &ssoa.RequestPasswordResetRequest{Email: email})
+	require.NoError(t, err)
+	token := lastEmailToken(t, st, email, resetCode)
+
+	newPassword := randomFakePassword()
+	_, err = st.AuthClient.ConfirmPasswordReset(ctx, &ssoa.ConfirmPasswordResetRequest{
+		Token:       token,
+		NewPassword: newPassword,
+	})
+	require.NoError(t, err)
+
+	respValidate, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	assert.False(t, respValidate.GetValid())
+	assert.Equal(t, ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_REVOKED, respValidate.GetReason())
+
+	_, err = st.AuthClient.Refresh(ctx, &ssoa.RefreshRequest{RefreshToken: respLogin.GetRefreshToken()})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.Error(t, err)
+
+	respLogin, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: newPassword,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+	respValidate, err = st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	assert.True(t, respValidate.GetValid())
+
+	_, err = st.AuthClient.ConfirmPasswordReset(ctx, &ssoa.ConfirmPasswordResetRequest{
+		Token:       token,
+		NewPassword: randomFakePassword(),
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPasswordReset_UnknownEmail(t *testing.T) {
+	ctx, st := suite.New(t)
+	_, err := st.AuthClient.RequestPasswordReset(ctx, &ssoa.RequestPasswordResetRequest{Email: gofakeit.Email()})
+	require.NoError(t, err)
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"testing"
+)
+
+func TestAuthorize_Policy(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+	respApp, err := st.AdminClient.CreateApp(adminCtx, &ssoa.CreateAppRequest{Name: "policy-" + gofakeit.UUID()})
+	require.NoError(t, err)
+	appID := respApp.GetApp().GetId()
+
+	email, password := registerUser(ctx, t, st)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: appID})
+	require.NoError(t, err)
+	token := respLogin.GetToken()
+
+	authorize := func(action string, resource map[string]any) *ssoa.AuthorizeResponse {
+		t.Helper()
+		res, err := structpb.NewStruct(resource)
+		require.NoError(t, err)
+		resp, err := st.AuthClient.Authorize(ctx, &ssoa.AuthorizeRequest{Token: token, Action: action, Resource: res})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := authorize("read", nil)
+	assert.False(t, resp.GetAllowed(), "apps without a policy deny everything")
+	assert.Zero(t, resp.GetPolicyVersion())
+
+	respPut, err := st.AdminClient.PutPolicy(adminCtx, &ssoa.PutPolicyRequest{AppId: appID, Rules: []*ssoa.PolicyRule{
+		{Name: "no-deletes", Effect: ssoa.PolicyEffect_POLICY_EFFECT_DENY, Condition: `action == "delete"`},
+		{Name: "owner", Effect: ssoa.PolicyEffect_POLICY_EFFECT_ALLOW, Condition: `has(resource.owner_email) && resource.owner_email == subject.email`},
+	}})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, respPut.GetPolicy().GetVersion())
+
+	resp = authorize("edit", map[string]any{"owner_email": email})
+	assert.True(t, resp.GetAllowed())
+	assert.Equal(t, "owner", resp.GetRule())
+	assert.EqualValues(t, 1, resp.GetPolicyVersion())
+
+	resp = authorize("delete", map[string]any{"owner_email": email})
+	assert.False(t, resp.GetAllowed())
+	assert.Equal(t, "no-deletes", resp.GetRule())
+
+	resp = authorize("edit", map[string]any{"owner_email": "someone@else.com"})
+	assert.False(t, resp.GetAllowed())
+	assert.Empty(t, resp.GetRule())
+	assert.NotEmpty(t, resp.GetReason())
+
+	_, err = st.AdminClient.PutPolicy(adminCtx, &ssoa.PutPolicyRequest{AppId: appID, Rules: []*ssoa.PolicyRule{
+		{Name: "readers", Effect: ssoa.PolicyEffect_POLICY_EFFECT_ALLOW, Condition: `action == "read" && context.time.getFullYear() >= 2024`},
+	}})
+	require.NoError(t, err)
+	resp = authorize("read", nil)
+	assert.True(t, resp.GetAllowed())
+	assert.EqualValues(t, 2, resp.GetPolicyVersion())
+
+	respGet, err := st.AdminClient.GetPolicy(adminCtx, &ssoa.GetPolicyRequest{AppId: appID, Version: 1})
+	require.NoError(t, err)
+	require.Len(t, respGet.GetPolicy().GetRules(), 2)
+	assert.Equal(t, "no-deletes", respGet.GetPolicy().GetRules()[0].GetName())
+	respVersions, err := st.AdminClient.ListPolicyVersions(adminCtx, &ssoa.ListPolicyVersionsRequest{AppId: appID})
+	require.NoError(t, err)
+	require.Len(t, respVersions.GetPolicies(), 2)
+	assert.EqualValues(t, 2, respVersions.GetPolicies()[0].GetVersion())
+}
+
+func TestAuthorize_Fails(t *testing.T) {
+	ctx, st := suite.New(t)
+	adminCtx := adminContext(ctx, t, st)
+
+	_, err := st.AdminClient.PutPolicy(adminCtx, &ssoa.PutPolicyRequest{AppId: appId, Rules: []*ssoa.PolicyRule{
+		{Name: "broken", Effect: ssoa.PolicyEffect_POLICY_EFFECT_ALLOW, Condition: `action ==`},
+	}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = st.AdminClient.PutPolicy(adminCtx, &ssoa.PutPolicyRequest{AppId: appId, Rules: []*ssoa.PolicyRule{
+		{Name: "no effect", Condition: `true`},
+	}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = st.AdminClient.GetPolicy(adminCtx, &ssoa.GetPolicyRequest{AppId: appId, Version: 9999})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = st.AuthClient.Authorize(ctx, &ssoa.AuthorizeRequest{Token: "not-a-token", Action: "read"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
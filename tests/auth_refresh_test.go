@@ -0,0 +1,50 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRefresh_RotationAndReuse(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, respLogin.GetRefreshToken())
+
+	respRefresh, err := st.AuthClient.Refresh(ctx, &ssoa.RefreshRequest{RefreshToken: respLogin.GetRefreshToken()})
+	require.NoError(t, err)
+	assert.NotEmpty(t, respRefresh.GetToken())
+	require.NotEmpty(t, respRefresh.GetRefreshToken())
+	assert.NotEqual(t, respLogin.GetRefreshToken(), respRefresh.GetRefreshToken())
+
+	_, err = st.AuthClient.Refresh(ctx, &ssoa.RefreshRequest{RefreshToken: respLogin.GetRefreshToken()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "refresh token reused")
+
+	_, err = st.AuthClient.Refresh(ctx, &ssoa.RefreshRequest{RefreshToken: respRefresh.GetRefreshToken()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid refresh token")
+}
+
+func TestRefresh_UnknownToken(t *testing.T) {
+	ctx, st := suite.New(t)
+	_, err := st.AuthClient.Refresh(ctx, &ssoa.RefreshRequest{RefreshToken: gofakeit.UUID()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid refresh token")
+}
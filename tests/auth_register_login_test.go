@@ -1,12 +1,15 @@
 package tests
 
 import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/internal/lib/hasher"
 	"AuthGRPC/tests/suite"
-	ssoa "github.com/AmirBazanov/protoForAuthGRPC/gen/go/sso"
+	"fmt"
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 	"strings"
 	"testing"
 	"time"
@@ -206,6 +209,62 @@ func TestLogin_FailCases(t *testing.T) {
 	}
 }
 
+func TestLogin_UpgradesPasswordHash(t *testing.T) {
+	ctx, st := suite.New(t)
+	db := st.Storage()
+	argon2 := st.Cfg.PasswordHashing.Argon2
+	configured := fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$", argon2.MemoryKiB, argon2.Time, argon2.Threads)
+
+	tests := []struct {
+		name string
+		hash func(t *testing.T, password string) []byte
+	}{
+		{
+			name: "bcrypt",
+			hash: func(t *testing.T, password string) []byte {
+				hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+				require.NoError(t, err)
+				return hash
+			},
+		},
+		{
+			name: "weaker argon2id",
+			hash: func(t *testing.T, password string) []byte {
+				h, err := hasher.New(hasher.AlgArgon2id, 0, hasher.Argon2Params{
+					Memory: 64, Time: 1, Threads: 1, KeyLen: argon2.KeyLen, SaltLen: argon2.SaltLen,
+				})
+				require.NoError(t, err)
+				hash, err := h.Hash(password)
+				require.NoError(t, err)
+				return hash
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := gofakeit.Email()
+			password := randomFakePassword()
+			_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{Email: email, Password: password})
+			require.NoError(t, err)
+			old := tt.hash(t, password)
+			_, err = db.ExecContext(ctx, "UPDATE users SET pass_hash = ? WHERE email = ?", old, email)
+			require.NoError(t, err)
+
+			login := func() {
+				t.Helper()
+				respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{Email: email, Password: password, AppId: appId})
+				require.NoError(t, err)
+				assert.NotEmpty(t, respLogin.GetToken())
+			}
+			login()
+			var upgraded []byte
+			require.NoError(t, db.QueryRowContext(ctx, "SELECT pass_hash FROM users WHERE email = ?", email).Scan(&upgraded))
+			assert.True(t, strings.HasPrefix(string(upgraded), configured), "got %s", upgraded)
+			login()
+		})
+	}
+}
+
 func randomFakePassword() string {
 	return gofakeit.Password(true, true, true, true, true, passDefault)
 }
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestValidateToken_HappyPath(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	respReg, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+
+	resp, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken(), AppId: appId})
+	require.NoError(t, err)
+	assert.True(t, resp.GetValid())
+	assert.Equal(t, respReg.GetUserId(), resp.GetUserId())
+	assert.Equal(t, email, resp.GetEmail())
+	assert.Equal(t, int32(appId), resp.GetAppId())
+	assert.InDelta(t, time.Now().Add(st.Cfg.TokenTTl).Unix(), resp.GetExp(), 5)
+}
+
+func TestValidateToken_InvalidCases(t *testing.T) {
+	ctx, st := suite.New(t)
+	sign := func(secret string, claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		require.NoError(t, err)
+		return token
+	}
+	claims := func(appID int, exp time.Time) jwt.MapClaims {
+		return jwt.MapClaims{"uid": 1, "email": gofakeit.Email(), "appid": appID, "exp": exp.Unix()}
+	}
+	tests := []struct {
+		name           string
+		token          string
+		appID          int32
+		expectedReason ssoa.TokenInvalidReason
+	}{
+		{
+			name:           "Malformed token",
+			token:          "not-a-token",
+			expectedReason: ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_MALFORMED,
+		}, {
+			name:           "Expired token",
+			token:          sign(appSecret, claims(appId, time.Now().Add(-time.Hour))),
+			expectedReason: ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_EXPIRED,
+		}, {
+			name:           "Wrong secret",
+			token:          sign("wrong-secret", claims(appId, time.Now().Add(time.Hour))),
+			expectedReason: ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_BAD_SIGNATURE,
+		}, {
+			name:           "Secret of an asymmetric app",
+			token:          sign("test-secret-rs256", claims(2, time.Now().Add(time.Hour))),
+			expectedReason: ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_BAD_SIGNATURE,
+		}, {
+			name:           "Unknown app",
+			token:          sign(appSecret, claims(999, time.Now().Add(time.Hour))),
+			expectedReason: ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_UNKNOWN_APP,
+		}, {
+			name:           "Another app",
+			token:          sign(appSecret, claims(appId, time.Now().Add(time.Hour))),
+			appID:          999,
+			expectedReason: ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_APP_MISMATCH,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: tt.token, AppId: tt.appID})
+			require.NoError(t, err)
+			assert.False(t, resp.GetValid())
+			assert.Equal(t, tt.expectedReason, resp.GetReason())
+		})
+	}
+}
+
+func TestLogout_RevokesTokens(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+	respLogin, err := st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Logout(ctx, &ssoa.LogoutRequest{
+		Token:        respLogin.GetToken(),
+		RefreshToken: respLogin.GetRefreshToken(),
+	})
+	require.NoError(t, err)
+
+	resp, err := st.AuthClient.ValidateToken(ctx, &ssoa.ValidateTokenRequest{Token: respLogin.GetToken()})
+	require.NoError(t, err)
+	assert.False(t, resp.GetValid())
+	assert.Equal(t, ssoa.TokenInvalidReason_TOKEN_INVALID_REASON_REVOKED, resp.GetReason())
+
+	_, err = st.AuthClient.Refresh(ctx, &ssoa.RefreshRequest{RefreshToken: respLogin.GetRefreshToken()})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid refresh token")
+}
@@ -0,0 +1,102 @@
+package tests
+
+import (
+	ssoa "AuthGRPC/gen/go/sso"
+	"AuthGRPC/tests/suite"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const verifiedEmailAppId = 5
+
+var verificationCode = regexp.MustCompile(`verify your email address: (\S+)`)
+
+func TestVerifyEmail_RequiredByApp(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	password := randomFakePassword()
+	respReg, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: password,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    verifiedEmailAppId,
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    appId,
+	})
+	require.NoError(t, err, "apps without the requirement must accept unverified users")
+
+	token := lastEmailToken(t, st, email, verificationCode)
+	respVerify, err := st.AuthClient.VerifyEmail(ctx, &ssoa.VerifyEmailRequest{Token: token})
+	require.NoError(t, err)
+	assert.Equal(t, respReg.GetUserId(), respVerify.GetUserId())
+
+	_, err = st.AuthClient.Login(ctx, &ssoa.LoginRequest{
+		Email:    email,
+		Password: password,
+		AppId:    verifiedEmailAppId,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.VerifyEmail(ctx, &ssoa.VerifyEmailRequest{Token: token})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestResendVerification(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	_, err := st.AuthClient.Register(ctx, &ssoa.RegisterRequest{
+		Email:    email,
+		Password: randomFakePassword(),
+	})
+	require.NoError(t, err)
+	oldToken := lastEmailToken(t, st, email, verificationCode)
+
+	_, err = st.AuthClient.ResendVerification(ctx, &ssoa.ResendVerificationRequest{Email: email})
+	require.NoError(t, err)
+	newToken := lastEmailToken(t, st, email, verificationCode)
+	require.NotEqual(t, oldToken, newToken)
+
+	_, err = st.AuthClient.VerifyEmail(ctx, &ssoa.VerifyEmailRequest{Token: oldToken})
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.VerifyEmail(ctx, &ssoa.VerifyEmailRequest{Token: newToken})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.ResendVerification(ctx, &ssoa.ResendVerificationRequest{Email: gofakeit.Email()})
+	require.NoError(t, err, "unknown emails must not be revealed")
+}
+
+// lastEmailToken extracts the token from the newest email the file mailer wrote for to.
+func lastEmailToken(t *testing.T, st *suite.Suite, to string, pattern *regexp.Regexp) string {
+	t.Helper()
+	require.NotEmpty(t, st.Cfg.Mailer.FilePath, "tests need the file mailer")
+	data, err := os.ReadFile(filepath.Join("..", st.Cfg.Mailer.FilePath))
+	require.NoError(t, err)
+	mail := string(data)
+	start := strings.LastIndex(mail, "To: "+to+"\r\n")
+	require.NotEqual(t, -1, start, "no email sent to %s", to)
+	match := pattern.FindStringSubmatch(mail[start:])
+	require.NotNil(t, match, "no token in the email to %s", to)
+	return match[1]
+}
@@ -0,0 +1,5 @@
+INSERT INTO apps (id, name, secret, signing_alg)
+VALUES (2, 'test-rs256', 'test-secret-rs256', 'RS256'),
+       (3, 'test-es256', 'test-secret-es256', 'ES256'),
+       (4, 'test-eddsa', 'test-secret-eddsa', 'EdDSA')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+INSERT INTO users (email, pass_hash, is_admin)
+VALUES ('admin@sso.test', '$2a$10$iBga6TfN/ZkS.GOxBppHk.v8kNH.x8hQtSoZX2KcZGh4ufL2rONVG', TRUE)
+ON CONFLICT DO NOTHING;
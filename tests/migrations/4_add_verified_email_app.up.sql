@@ -0,0 +1,3 @@
+INSERT INTO apps (id, name, secret, require_verified_email)
+VALUES (5, 'test-verified-email', 'test-secret-verified-email', TRUE)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+INSERT INTO app_redirect_uris (app_id, redirect_uri)
+VALUES (1, 'http://localhost:9999/callback'),
+       (2, 'http://localhost:9999/callback')
+ON CONFLICT DO NOTHING;
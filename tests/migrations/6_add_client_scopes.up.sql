@@ -0,0 +1 @@
+UPDATE apps SET client_scopes = 'orders:read orders:write' WHERE id = 1;
@@ -0,0 +1,3 @@
+UPDATE apps
+SET secret = 'test-secret'
+WHERE id = 1;
@@ -1,20 +1,24 @@
 package suite
 
 import (
+	ssoa "AuthGRPC/gen/go/sso"
 	"AuthGRPC/internal/config"
 	"context"
-	ssoa "github.com/AmirBazanov/protoForAuthGRPC/gen/go/sso"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"net"
+	"path/filepath"
 	"strconv"
 	"testing"
 )
 
 type Suite struct {
 	*testing.T
-	Cfg        *config.Config
-	AuthClient ssoa.AuthClient
+	Cfg         *config.Config
+	AuthClient  ssoa.AuthClient
+	AdminClient ssoa.AdminClient
 }
 
 const (
@@ -36,12 +40,30 @@ func New(t *testing.T) (context.Context, *Suite) {
 		t.Fatal("grpc server connection failed: ", err)
 	}
 	return ctx, &Suite{
-		T:          t,
-		Cfg:        cfg,
-		AuthClient: ssoa.NewAuthClient(cc),
+		T:           t,
+		Cfg:         cfg,
+		AuthClient:  ssoa.NewAuthClient(cc),
+		AdminClient: ssoa.NewAdminClient(cc),
 	}
 }
 
+// Storage opens the database of the server under test, for what the API doesn't
+// show, like the password hashes. Relative paths in the config are relative to
+// the repository root.
+func (s *Suite) Storage() *sql.DB {
+	s.Helper()
+	path := s.Cfg.StoragePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join("..", path)
+	}
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000")
+	if err != nil {
+		s.Fatal("storage connection failed: ", err)
+	}
+	s.Cleanup(func() { db.Close() })
+	return db
+}
+
 func grpcAddress(cfg *config.Config) string {
 	return net.JoinHostPort(grpcHost, strconv.Itoa(cfg.GRPC.Port))
 }